@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/BigBullas/TP_DB_project/internal/config"
+	"github.com/BigBullas/TP_DB_project/internal/pkg/forume/repo"
+	"log"
+)
+
+// runCheckConsistency выполняет подкоманду `check-consistency [-repair]`.
+// Без -repair завершается ошибкой, если нашлись расхождения, чтобы её можно было запускать по расписанию.
+func runCheckConsistency(args []string) error {
+	repair := false
+	rest := make([]string, 0, len(args))
+	for _, arg := range args {
+		if arg == "-repair" || arg == "--repair" {
+			repair = true
+			continue
+		}
+		rest = append(rest, arg)
+	}
+
+	cfg, err := config.Load(rest)
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if cfg.Storage != "postgres" {
+		return fmt.Errorf("check-consistency needs postgres storage, got %q", cfg.Storage)
+	}
+
+	ctx := context.Background()
+	pool, err := repo.NewPool(ctx, cfg.DB)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	report, err := repo.NewRepoPostgres(pool, cfg.DB, nil).CheckConsistency(ctx, repair)
+	if err != nil {
+		return err
+	}
+	for _, m := range report.Mismatches {
+		switch {
+		case m.Nickname != "":
+			fmt.Printf("%s\tforum=%s\tnickname=%s\n", m.Kind, m.Forum, m.Nickname)
+		case m.Thread != 0:
+			fmt.Printf("%s\tforum=%s\tthread=%d\tstored=%d\tactual=%d\n", m.Kind, m.Forum, m.Thread, m.Stored, m.Actual)
+		default:
+			fmt.Printf("%s\tforum=%s\tstored=%d\tactual=%d\n", m.Kind, m.Forum, m.Stored, m.Actual)
+		}
+	}
+
+	switch {
+	case len(report.Mismatches) == 0:
+		log.Print("no mismatches found")
+	case report.Repaired:
+		log.Printf("repaired %d mismatches", len(report.Mismatches))
+	default:
+		return fmt.Errorf("found %d mismatches, run with -repair to fix them", len(report.Mismatches))
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/BigBullas/TP_DB_project/db"
+	"github.com/BigBullas/TP_DB_project/internal/config"
+	"github.com/BigBullas/TP_DB_project/internal/migrate"
+	"github.com/BigBullas/TP_DB_project/internal/pkg/forume/repo"
+	"log"
+	"strconv"
+	"strings"
+)
+
+const migrateUsage = "usage: main migrate up|down [steps]|status [flags]"
+
+// runMigrate выполняет подкоманду `migrate up|down [steps]|status`.
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+	action, rest := args[0], args[1:]
+	if action != "up" && action != "down" && action != "status" {
+		return errors.New(migrateUsage)
+	}
+
+	steps := 1
+	if action == "down" && len(rest) > 0 && !strings.HasPrefix(rest[0], "-") {
+		n, err := strconv.Atoi(rest[0])
+		if err != nil || n <= 0 {
+			return fmt.Errorf("bad steps %q: %s", rest[0], migrateUsage)
+		}
+		steps, rest = n, rest[1:]
+	}
+
+	cfg, err := config.Load(rest)
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	pool, err := repo.NewPool(ctx, cfg.DB)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	migrator, err := migrate.New(pool, db.Migrations)
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			log.Printf("applied %04d_%s", m.Version, m.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			log.Print("schema is up to date")
+		}
+		return err
+	case "down":
+		reverted, err := migrator.Down(ctx, steps)
+		for _, m := range reverted {
+			log.Printf("reverted %04d_%s", m.Version, m.Name)
+		}
+		return err
+	default:
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			if s.Applied {
+				fmt.Printf("%04d_%s\tapplied at %s\n", s.Version, s.Name, s.AppliedAt.Format("2006-01-02 15:04:05"))
+			} else {
+				fmt.Printf("%04d_%s\tpending\n", s.Version, s.Name)
+			}
+		}
+	}
+	return nil
+}
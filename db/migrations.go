@@ -0,0 +1,8 @@
+package db
+
+import "embed"
+
+// Migrations содержит версионированные миграции схемы в формате NNNN_name.up.sql / NNNN_name.down.sql.
+//
+//go:embed migrations/*.sql
+var Migrations embed.FS
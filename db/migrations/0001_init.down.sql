@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS users_forum, vote, post, thread, forum, users CASCADE;
+
+DROP FUNCTION IF EXISTS addUserFirstVote();
+DROP FUNCTION IF EXISTS changeVoteOnThread();
+DROP FUNCTION IF EXISTS PostUpdateUserForum();
+DROP FUNCTION IF EXISTS ThreadUpdateUserForum();
+DROP FUNCTION IF EXISTS addThreadInForum();
+DROP FUNCTION IF EXISTS addPostInForum();
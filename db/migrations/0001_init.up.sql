@@ -1,6 +1,6 @@
 CREATE EXTENSION IF NOT EXISTS CITEXT; -- eliminate calls to lower
 
-CREATE UNLOGGED TABLE users
+CREATE UNLOGGED TABLE IF NOT EXISTS users
 (
     Nickname   CITEXT PRIMARY KEY,
     FullName   TEXT NOT NULL,
@@ -8,7 +8,7 @@ CREATE UNLOGGED TABLE users
     Email      CITEXT UNIQUE
 );
 
-CREATE UNLOGGED TABLE forum
+CREATE UNLOGGED TABLE IF NOT EXISTS forum
 (
     Title    TEXT   NOT NULL,
     "user"   CITEXT,
@@ -17,7 +17,7 @@ CREATE UNLOGGED TABLE forum
     Threads  INT    DEFAULT 0
 );
 
-CREATE UNLOGGED TABLE thread
+CREATE UNLOGGED TABLE IF NOT EXISTS thread
 (
     Id      SERIAL    PRIMARY KEY,
     Title   TEXT      NOT NULL,
@@ -29,7 +29,7 @@ CREATE UNLOGGED TABLE thread
     Created TIMESTAMP WITH TIME ZONE DEFAULT now()
 );
 
-CREATE UNLOGGED TABLE post
+CREATE UNLOGGED TABLE IF NOT EXISTS post
 (
     Id        SERIAL      PRIMARY KEY,
     Author    CITEXT,
@@ -44,7 +44,7 @@ CREATE UNLOGGED TABLE post
     FOREIGN KEY (author) REFERENCES "users"  (nickname)
 );
 
-CREATE UNLOGGED TABLE vote
+CREATE UNLOGGED TABLE IF NOT EXISTS vote
 (
     ID       SERIAL PRIMARY KEY,
     Author   CITEXT    REFERENCES "users" (Nickname),
@@ -55,7 +55,7 @@ CREATE UNLOGGED TABLE vote
 );
 
 
-CREATE UNLOGGED TABLE users_forum
+CREATE UNLOGGED TABLE IF NOT EXISTS users_forum
 (
     Nickname  CITEXT  NOT NULL,
     FullName  TEXT    NOT NULL,
@@ -77,6 +77,7 @@ return NEW;
 END
 $$ LANGUAGE plpgsql;
 
+DROP TRIGGER IF EXISTS on_insert_vote ON vote;
 CREATE TRIGGER on_insert_vote
     AFTER INSERT ON vote
     FOR EACH ROW
@@ -91,6 +92,7 @@ return NEW;
 END
 $$ LANGUAGE plpgsql;
 
+DROP TRIGGER IF EXISTS on_update_vote ON vote;
 CREATE TRIGGER on_update_vote
     AFTER UPDATE ON vote
     FOR EACH ROW
@@ -113,6 +115,7 @@ return NEW;
 END
 $$ LANGUAGE plpgsql;
 
+DROP TRIGGER IF EXISTS post_update_user_forum ON post;
 CREATE TRIGGER post_update_user_forum
     AFTER INSERT ON post
     FOR EACH ROW
@@ -134,6 +137,7 @@ return NEW;
 END
 $$ LANGUAGE plpgsql;
 
+DROP TRIGGER IF EXISTS thread_update_users_forum ON thread;
 CREATE TRIGGER thread_update_users_forum
     AFTER INSERT ON thread
     FOR EACH ROW
@@ -149,6 +153,7 @@ return NEW;
 END
 $$ LANGUAGE plpgsql;
 
+DROP TRIGGER IF EXISTS new_thread_in_forum ON thread;
 CREATE TRIGGER new_thread_in_forum
     AFTER INSERT ON thread
     FOR EACH ROW
@@ -173,6 +178,7 @@ return NEW;
 END
 $$ LANGUAGE plpgsql;
 
+DROP TRIGGER IF EXISTS new_post_in_forum ON post;
 CREATE TRIGGER new_post_in_forum
     BEFORE INSERT ON post
     FOR EACH ROW
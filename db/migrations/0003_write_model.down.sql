@@ -0,0 +1,78 @@
+CREATE OR REPLACE FUNCTION addUserFirstVote() RETURNS TRIGGER AS
+$$
+BEGIN
+UPDATE thread SET Votes=(Votes+New.Voice) WHERE Id = NEW.Thread;
+return NEW;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION changeVoteOnThread() RETURNS TRIGGER AS
+$$
+BEGIN
+UPDATE thread SET Votes=(Votes+2*New.Voice) WHERE Id = NEW.Thread;
+return NEW;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION ThreadUpdateUserForum() RETURNS TRIGGER AS
+$$
+DECLARE
+authorFullName CITEXT;
+   authorAbout    CITEXT;
+   authorEmail    CITEXT;
+BEGIN
+SELECT FullName, About, Email FROM users WHERE Nickname = NEW.Author INTO authorFullName, authorAbout, authorEmail;
+INSERT INTO users_forum (Nickname, FullName, About, Email, Slug)
+VALUES (NEW.Author, authorFullName, authorAbout, authorEmail, NEW.Forum)
+    ON CONFLICT DO NOTHING;
+return NEW;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION addThreadInForum() RETURNS TRIGGER AS
+$$
+BEGIN
+UPDATE forum SET Threads=(Threads + 1) WHERE Slug = NEW.Forum;
+return NEW;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION addPostInForum() RETURNS TRIGGER AS
+$$
+DECLARE
+parent_path INTEGER[];
+BEGIN
+    IF current_setting('forum.bulk_import', true) = 'on' THEN
+        return NEW;
+END IF;
+
+    IF (NEW.parent = 0) THEN
+        NEW.path := array_append(NEW.Path, NEW.Id);
+ELSE
+SELECT Path FROM post WHERE Id = NEW.Parent INTO parent_path;
+NEW.Path := parent_path || NEW.Id;
+END IF;
+
+UPDATE forum SET Posts=(Posts + 1) WHERE Slug = NEW.Forum;
+return NEW;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION PostUpdateUserForum() RETURNS TRIGGER AS
+$$
+DECLARE
+authorFullName TEXT;
+   authorAbout    TEXT;
+   authorEmail    CITEXT;
+BEGIN
+    IF current_setting('forum.bulk_import', true) = 'on' THEN
+        return NEW;
+END IF;
+
+SELECT FullName, About, Email FROM users WHERE Nickname = NEW.Author INTO authorFullName, authorAbout, authorEmail;
+INSERT INTO users_forum (Nickname, FullName, About, Email, Slug)
+VALUES (NEW.Author, authorFullName, authorAbout, authorEmail, NEW.Forum)
+    ON CONFLICT DO NOTHING;
+return NEW;
+END
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS post_search_idx;
+DROP INDEX IF EXISTS thread_search_idx;
+
+DROP TRIGGER IF EXISTS post_search ON post;
+DROP TRIGGER IF EXISTS thread_search ON thread;
+DROP FUNCTION IF EXISTS postSearchVector();
+DROP FUNCTION IF EXISTS threadSearchVector();
+DROP FUNCTION IF EXISTS forumSearchConfig(CITEXT);
+
+ALTER TABLE post DROP COLUMN IF EXISTS Search;
+ALTER TABLE thread DROP COLUMN IF EXISTS Search;
+ALTER TABLE forum DROP COLUMN IF EXISTS Language;
@@ -0,0 +1,48 @@
+-- Полнотекстовый поиск. Конфигурация текстового поиска задаётся на форум (NULL — simple),
+-- векторы постов и веток считают триггеры при любой модели записи, в том числе при импорте.
+
+ALTER TABLE forum ADD COLUMN IF NOT EXISTS Language regconfig;
+ALTER TABLE thread ADD COLUMN IF NOT EXISTS Search tsvector;
+ALTER TABLE post ADD COLUMN IF NOT EXISTS Search tsvector;
+
+CREATE OR REPLACE FUNCTION forumSearchConfig(forum_slug CITEXT) RETURNS regconfig AS
+$$
+SELECT COALESCE((SELECT Language FROM forum WHERE Slug = forum_slug), 'simple'::regconfig);
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION threadSearchVector() RETURNS TRIGGER AS
+$$
+DECLARE
+config regconfig := forumSearchConfig(NEW.Forum);
+BEGIN
+    NEW.Search := setweight(to_tsvector(config, NEW.Title), 'A') || setweight(to_tsvector(config, NEW.Message), 'B');
+return NEW;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS thread_search ON thread;
+CREATE TRIGGER thread_search
+    BEFORE INSERT OR UPDATE OF Title, Message ON thread
+    FOR EACH ROW
+    EXECUTE PROCEDURE threadSearchVector();
+
+CREATE OR REPLACE FUNCTION postSearchVector() RETURNS TRIGGER AS
+$$
+BEGIN
+    NEW.Search := to_tsvector(forumSearchConfig(NEW.Forum), NEW.Message::text);
+return NEW;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS post_search ON post;
+CREATE TRIGGER post_search
+    BEFORE INSERT OR UPDATE OF Message ON post
+    FOR EACH ROW
+    EXECUTE PROCEDURE postSearchVector();
+
+UPDATE thread SET Search = setweight(to_tsvector(forumSearchConfig(Forum), Title), 'A') ||
+                           setweight(to_tsvector(forumSearchConfig(Forum), Message), 'B');
+UPDATE post SET Search = to_tsvector(forumSearchConfig(Forum), Message::text);
+
+CREATE INDEX IF NOT EXISTS thread_search_idx ON thread USING GIN (Search);
+CREATE INDEX IF NOT EXISTS post_search_idx ON post USING GIN (Search);
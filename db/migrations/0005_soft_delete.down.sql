@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS post_visibility ON post;
+DROP FUNCTION IF EXISTS postVisibilityChanged();
+
+-- Без флага удалённые посты снова станут видимыми, поэтому счётчики пересчитываются по всем постам.
+UPDATE forum f SET Posts = (SELECT count(*) FROM post p WHERE p.Forum = f.Slug);
+
+ALTER TABLE post DROP COLUMN IF EXISTS IsDeleted;
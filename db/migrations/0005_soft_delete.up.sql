@@ -0,0 +1,22 @@
+-- Удалённый пост остаётся в таблице, чтобы не ломать Path потомков; текст и автор скрываются при чтении.
+-- forum.Posts считает только видимые посты: при triggers его поправляет триггер, при app — репозиторий.
+
+ALTER TABLE post ADD COLUMN IF NOT EXISTS IsDeleted BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE OR REPLACE FUNCTION postVisibilityChanged() RETURNS TRIGGER AS
+$$
+BEGIN
+    IF current_setting('forum.write_model', true) = 'app' OR NEW.IsDeleted = OLD.IsDeleted THEN
+        return NEW;
+END IF;
+
+UPDATE forum SET Posts = Posts + CASE WHEN NEW.IsDeleted THEN -1 ELSE 1 END WHERE Slug = NEW.Forum;
+return NEW;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS post_visibility ON post;
+CREATE TRIGGER post_visibility
+    AFTER UPDATE OF IsDeleted ON post
+    FOR EACH ROW
+    EXECUTE PROCEDURE postVisibilityChanged();
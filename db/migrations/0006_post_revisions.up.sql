@@ -0,0 +1,12 @@
+-- Ревизии сообщений постов. Пока пост не правили, ревизий нет: исходный текст становится
+-- ревизией 1 при первой правке, поэтому вставка постов и импорт сюда не пишут.
+
+CREATE UNLOGGED TABLE IF NOT EXISTS post_revisions
+(
+    Post     INT       NOT NULL REFERENCES post (Id),
+    Revision INT       NOT NULL,
+    Message  TEXT      NOT NULL,
+    Editor   CITEXT    REFERENCES users (Nickname),
+    Created  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    PRIMARY KEY (Post, Revision)
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS credential;
+DROP TABLE IF EXISTS user_password;
@@ -0,0 +1,21 @@
+-- Пароли и выданные сессии и API-токены. Таблицы нежурналируемые, как и users, на которую
+-- они ссылаются: Postgres не даёт журналируемой таблице внешний ключ на UNLOGGED.
+-- Храним только хеши: argon2id для паролей и sha256 для случайных токенов.
+
+CREATE UNLOGGED TABLE IF NOT EXISTS user_password
+(
+    Nickname CITEXT PRIMARY KEY REFERENCES users (Nickname) ON DELETE CASCADE,
+    Hash     TEXT   NOT NULL
+);
+
+CREATE UNLOGGED TABLE IF NOT EXISTS credential
+(
+    Hash     TEXT      PRIMARY KEY,
+    Kind     TEXT      NOT NULL CHECK (Kind IN ('session', 'token')),
+    Nickname CITEXT    NOT NULL REFERENCES users (Nickname) ON DELETE CASCADE,
+    Name     TEXT      NOT NULL DEFAULT '',
+    Created  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    Expires  TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS credential_nickname ON credential (Nickname);
@@ -0,0 +1,12 @@
+-- Назначенные роли в форумах. Владелец форума — forum."user", администраторы задаются
+-- конфигурацией, все остальные без записи здесь — обычные участники.
+
+CREATE UNLOGGED TABLE IF NOT EXISTS forum_role
+(
+    Forum     CITEXT    NOT NULL REFERENCES forum (Slug) ON DELETE CASCADE,
+    Nickname  CITEXT    NOT NULL REFERENCES users (Nickname) ON DELETE CASCADE,
+    Role      TEXT      NOT NULL CHECK (Role IN ('moderator', 'banned')),
+    GrantedBy CITEXT,
+    Granted   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    PRIMARY KEY (Forum, Nickname)
+);
@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"golang.org/x/crypto/argon2"
+	"strings"
+)
+
+// Параметры argon2id по рекомендации OWASP: 19 MiB памяти, 2 прохода, 1 поток.
+// Они записываются в сам хеш, так что их можно поднимать без миграции старых паролей.
+const (
+	argonTime    = 2
+	argonMemory  = 19 * 1024
+	argonThreads = 1
+	argonKeyLen  = 32
+	saltLen      = 16
+	tokenLen     = 32
+)
+
+// HashPassword возвращает хеш в формате PHC: $argon2id$v=19$m=...,t=...,p=...$соль$ключ.
+func HashPassword(password string) (string, error) {
+	salt := make([]byte, saltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, argonTime, argonMemory, argonThreads, argonKeyLen)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, argonMemory, argonTime, argonThreads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// CheckPassword сравнивает пароль с хешем из HashPassword; испорченный хеш не совпадает ни с чем.
+func CheckPassword(encoded string, password string) bool {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return false
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false
+	}
+	var memory, time uint32
+	var threads uint8
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &time, &threads); err != nil {
+		return false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return false
+	}
+	got := argon2.IDKey([]byte(password), salt, time, memory, threads, uint32(len(key)))
+	return subtle.ConstantTimeCompare(got, key) == 1
+}
+
+// NewToken создаёт случайный токен сессии или API. Хранить нужно только HashToken от него.
+func NewToken() (string, error) {
+	raw := make([]byte, tokenLen)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// У токена 256 бит энтропии, так что медленный хеш не нужен: sha256 защищает от утечки базы.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+type callerKey struct{}
+
+// WithCaller привязывает к контексту запроса ник аутентифицированного пользователя.
+func WithCaller(ctx context.Context, nickname string) context.Context {
+	return context.WithValue(ctx, callerKey{}, nickname)
+}
+
+// Caller возвращает ник из WithCaller или пустую строку для анонимного запроса.
+func Caller(ctx context.Context) string {
+	nickname, _ := ctx.Value(callerKey{}).(string)
+	return nickname
+}
@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestPassword(t *testing.T) {
+	hash, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=19456,t=2,p=1$") {
+		t.Errorf("unexpected hash format %q", hash)
+	}
+	if !CheckPassword(hash, "correct horse") {
+		t.Error("password does not match its hash")
+	}
+	if CheckPassword(hash, "correct horse ") {
+		t.Error("wrong password matches")
+	}
+	if again, _ := HashPassword("correct horse"); again == hash {
+		t.Error("hashes of the same password share salt")
+	}
+	for _, broken := range []string{"", "plain", strings.Replace(hash, "argon2id", "argon2i", 1), strings.TrimSuffix(hash, hash[strings.LastIndex(hash, "$"):]) + "$"} {
+		if CheckPassword(broken, "correct horse") {
+			t.Errorf("broken hash %q matches", broken)
+		}
+	}
+}
+
+func TestToken(t *testing.T) {
+	a, err := NewToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := NewToken()
+	if a == b || len(a) != 43 {
+		t.Errorf("tokens %q and %q", a, b)
+	}
+	if HashToken(a) != HashToken(a) || HashToken(a) == HashToken(b) {
+		t.Error("token hash is not a function of the token")
+	}
+}
+
+func TestCaller(t *testing.T) {
+	ctx := context.Background()
+	if Caller(ctx) != "" {
+		t.Error("anonymous context has a caller")
+	}
+	if Caller(WithCaller(ctx, "alice")) != "alice" {
+		t.Error("caller is lost")
+	}
+}
@@ -0,0 +1,182 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	file := writeFile(t, "log_level: warn\nhttp:\n  listen: \":6000\"\n  read_timeout: 3s\ndb:\n  max_conns: 50\n")
+
+	tests := []struct {
+		name  string
+		env   map[string]string
+		args  []string
+		check func(t *testing.T, cfg Config)
+	}{
+		{
+			name: "defaults",
+			check: func(t *testing.T, cfg Config) {
+				want := Default()
+				if cfg.LogLevel != want.LogLevel || cfg.HTTP.Listen != want.HTTP.Listen || cfg.DB.MaxConns != want.DB.MaxConns {
+					t.Errorf("got %+v, want defaults", cfg)
+				}
+				if !cfg.Auth.Required || cfg.RateLimit.Enabled {
+					t.Errorf("want auth.required on and rate_limit.enabled off by default: %+v, %+v", cfg.Auth, cfg.RateLimit)
+				}
+			},
+		},
+		{
+			name: "file over defaults",
+			args: []string{"-config", file},
+			check: func(t *testing.T, cfg Config) {
+				if cfg.LogLevel != "warn" || cfg.HTTP.Listen != ":6000" || cfg.HTTP.ReadTimeout != 3*time.Second || cfg.DB.MaxConns != 50 {
+					t.Errorf("got %+v", cfg)
+				}
+				if cfg.HTTP.WriteTimeout != Default().HTTP.WriteTimeout {
+					t.Errorf("keys missing from the file must keep defaults: write_timeout %v", cfg.HTTP.WriteTimeout)
+				}
+			},
+		},
+		{
+			name: "file from environment",
+			env:  map[string]string{"FORUM_CONFIG": file},
+			check: func(t *testing.T, cfg Config) {
+				if cfg.HTTP.Listen != ":6000" {
+					t.Errorf("listen %q, want :6000", cfg.HTTP.Listen)
+				}
+			},
+		},
+		{
+			name: "environment over file",
+			env:  map[string]string{"FORUM_LISTEN": ":7000", "FORUM_DB_MAX_CONNS": "70", "FORUM_AUTH_REQUIRED": "false"},
+			args: []string{"-config", file},
+			check: func(t *testing.T, cfg Config) {
+				if cfg.HTTP.Listen != ":7000" || cfg.DB.MaxConns != 70 || cfg.Auth.Required || cfg.LogLevel != "warn" {
+					t.Errorf("got %+v", cfg)
+				}
+			},
+		},
+		{
+			name: "flags over environment",
+			env:  map[string]string{"FORUM_LISTEN": ":7000", "FORUM_DB_MAX_CONNS": "70"},
+			args: []string{"-config", file, "-listen", ":8000", "-rate-limit", "-auth-admins", "root, alice"},
+			check: func(t *testing.T, cfg Config) {
+				if cfg.HTTP.Listen != ":8000" || cfg.DB.MaxConns != 70 || !cfg.RateLimit.Enabled {
+					t.Errorf("got %+v", cfg)
+				}
+				if strings.Join(cfg.Auth.Admins, ",") != "root,alice" {
+					t.Errorf("admins %q", cfg.Auth.Admins)
+				}
+			},
+		},
+		{
+			name: "budgets merge with defaults",
+			env:  map[string]string{"FORUM_RATE_LIMIT_BUDGETS": "vote=1/2"},
+			args: []string{"-rate-limit-budgets", "post=3/4"},
+			check: func(t *testing.T, cfg Config) {
+				if cfg.RateLimit.Budgets["vote"] != (Limit{Rate: 1, Burst: 2}) || cfg.RateLimit.Budgets["post"] != (Limit{Rate: 3, Burst: 4}) {
+					t.Errorf("budgets %+v", cfg.RateLimit.Budgets)
+				}
+				if cfg.RateLimit.Budgets["read"] != Default().RateLimit.Budgets["read"] {
+					t.Errorf("read budget %+v, want default", cfg.RateLimit.Budgets["read"])
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			cfg, err := Load(tt.args)
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			tt.check(t, cfg)
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		args []string
+		want string
+	}{
+		{name: "missing file", args: []string{"-config", filepath.Join(t.TempDir(), "nope.yaml")}, want: "read config file"},
+		{name: "broken file", args: []string{"-config", writeFile(t, "http: [")}, want: "parse config file"},
+		{name: "bad env duration", env: map[string]string{"FORUM_HTTP_READ_TIMEOUT": "soon"}, want: "FORUM_HTTP_READ_TIMEOUT"},
+		{name: "bad env budget", env: map[string]string{"FORUM_RATE_LIMIT_BUDGETS": "vote"}, want: "FORUM_RATE_LIMIT_BUDGETS"},
+		{name: "unknown flag", args: []string{"-no-such-flag"}, want: "no-such-flag"},
+		{name: "invalid value", args: []string{"-storage", "redis"}, want: `storage: unknown backend "redis"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			_, err := Load(tt.args)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Load: got %v, want error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(c *Config)
+		want   string
+	}{
+		{name: "log level", change: func(c *Config) { c.LogLevel = "loud" }, want: `log_level: unknown level "loud"`},
+		{name: "log format", change: func(c *Config) { c.LogFormat = "xml" }, want: `log_format: unknown format "xml"`},
+		{name: "empty listen", change: func(c *Config) { c.HTTP.Listen = "" }, want: "http.listen: must not be empty"},
+		{name: "negative timeout", change: func(c *Config) { c.HTTP.DrainDelay = -time.Second }, want: "http: timeouts must not be negative"},
+		{name: "dsn", change: func(c *Config) { c.DB.DSN = "postgres://%zz" }, want: "db.dsn:"},
+		{name: "max conns", change: func(c *Config) { c.DB.MaxConns = 0 }, want: "db.max_conns: must be positive"},
+		{name: "min conns", change: func(c *Config) { c.DB.MinConns = c.DB.MaxConns + 1 }, want: "db.min_conns"},
+		{name: "write model", change: func(c *Config) { c.DB.WriteModel = "magic" }, want: `db.write_model: unknown model "magic"`},
+		{name: "heartbeat", change: func(c *Config) { c.Events.Heartbeat = 0 }, want: "events.heartbeat: must be positive"},
+		{name: "session ttl", change: func(c *Config) { c.Auth.SessionTTL = 0 }, want: "auth: session_ttl and token_ttl must be positive"},
+		{name: "budget", change: func(c *Config) { c.RateLimit.Budgets["vote"] = Limit{Rate: 1} }, want: "rate_limit.budgets.vote"},
+		{name: "route syntax", change: func(c *Config) { c.RateLimit.Routes["vote"] = "vote" }, want: `"vote" is not "METHOD /path"`},
+		{name: "route budget", change: func(c *Config) { c.RateLimit.Routes["GET /api/x"] = "nope" }, want: `unknown budget "nope"`},
+		{name: "exporter", change: func(c *Config) { c.Tracing.Exporter = "jaeger" }, want: `tracing.exporter: unknown exporter "jaeger"`},
+		{name: "trace file", change: func(c *Config) { c.Tracing.Exporter, c.Tracing.File = "file", "" }, want: "tracing.file: must not be empty"},
+		{name: "sample ratio", change: func(c *Config) { c.Tracing.SampleRatio = 2 }, want: "tracing.sample_ratio: must be between 0 and 1"},
+	}
+	if err := Default().Validate(); err != nil {
+		t.Fatalf("defaults: %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			tt.change(&cfg)
+			err := cfg.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate: got %v, want error containing %q", err, tt.want)
+			}
+		})
+	}
+
+	cfg := Default()
+	cfg.LogLevel, cfg.DB.MaxConns = "loud", 0
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "log_level") || !strings.Contains(err.Error(), "db.max_conns") {
+		t.Errorf("Validate reports every problem at once: %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+// Package cursor превращает models.Cursor в непрозрачную строку и обратно.
+// Строка — base64 от JSON и HMAC-SHA256 подписи, так что клиент не может ни прочитать
+// позицию как часть API, ни подделать её.
+package cursor
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"github.com/BigBullas/TP_DB_project/internal/errs"
+	"github.com/BigBullas/TP_DB_project/internal/models"
+	"github.com/mailru/easyjson"
+	"strings"
+)
+
+const macSize = 16
+
+var encoding = base64.RawURLEncoding
+
+type Codec struct {
+	secret []byte
+}
+
+// New создаёт кодек с ключом secret. Пустой ключ заменяется случайным:
+// курсоры тогда живут до перезапуска процесса.
+func New(secret []byte) *Codec {
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			panic("cursor: no random source: " + err.Error())
+		}
+	}
+	return &Codec{secret: secret}
+}
+
+func (c *Codec) Encode(cur models.Cursor) string {
+	payload, _ := easyjson.Marshal(cur)
+	return encoding.EncodeToString(payload) + "." + encoding.EncodeToString(c.sign(payload))
+}
+
+// Decode возвращает errs.BadRequest с полем cursor, если строка испорчена или подписана другим ключом.
+func (c *Codec) Decode(s string) (models.Cursor, error) {
+	var cur models.Cursor
+	bad := errs.New(errs.CodeBadRequest, "invalid cursor").WithField("cursor")
+
+	payloadPart, macPart, ok := strings.Cut(s, ".")
+	if !ok {
+		return cur, bad
+	}
+	payload, err := encoding.DecodeString(payloadPart)
+	if err != nil {
+		return cur, bad
+	}
+	mac, err := encoding.DecodeString(macPart)
+	if err != nil || !hmac.Equal(mac, c.sign(payload)) {
+		return cur, bad
+	}
+	if err := easyjson.Unmarshal(payload, &cur); err != nil {
+		return cur, bad
+	}
+	return cur, nil
+}
+
+func (c *Codec) sign(payload []byte) []byte {
+	h := hmac.New(sha256.New, c.secret)
+	h.Write(payload)
+	return h.Sum(nil)[:macSize]
+}
@@ -0,0 +1,41 @@
+package cursor
+
+import (
+	"errors"
+	"github.com/BigBullas/TP_DB_project/internal/errs"
+	"github.com/BigBullas/TP_DB_project/internal/models"
+	"testing"
+	"time"
+)
+
+func TestRoundTrip(t *testing.T) {
+	c := New([]byte("secret"))
+	in := models.Cursor{Scope: "threads:pirate", Desc: true, Backward: true, ID: 42, Created: time.Date(2024, 1, 2, 3, 4, 5, 6000, time.UTC)}
+
+	out, err := c.Decode(c.Encode(in))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if out.Scope != in.Scope || out.Desc != in.Desc || out.Backward != in.Backward || out.ID != in.ID || !out.Created.Equal(in.Created) {
+		t.Fatalf("got %+v, want %+v", out, in)
+	}
+}
+
+func TestRejectsForeignAndBroken(t *testing.T) {
+	c := New([]byte("secret"))
+	token := c.Encode(models.Cursor{Scope: "users:pirate", Nickname: "jack"})
+
+	for name, s := range map[string]string{
+		"other key": New([]byte("other")).Encode(models.Cursor{Scope: "users:pirate", Nickname: "jack"}),
+		"tampered":  "x" + token,
+		"no mac":    token[:len(token)-23],
+		"garbage":   "not a cursor",
+		"empty":     "",
+	} {
+		_, err := c.Decode(s)
+		var e *errs.Error
+		if !errors.As(err, &e) || e.Code != errs.CodeBadRequest || e.Field != "cursor" {
+			t.Errorf("%s: got %v, want bad request on cursor", name, err)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+// Package diff строит построчный unified diff двух текстов.
+// Общая подпоследовательность ищется динамикой за O(n*m), чего хватает для текстов постов.
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Context — сколько неизменных строк показывать вокруг изменений, как у diff -u.
+const Context = 3
+
+type opKind byte
+
+const (
+	opEqual  opKind = ' '
+	opDelete opKind = '-'
+	opInsert opKind = '+'
+)
+
+type op struct {
+	kind opKind
+	line string
+	// Номера строки в старом и новом тексте, считая с нуля.
+	a, b int
+}
+
+// Unified возвращает diff от a к b с заголовками fromName и toName; для одинаковых текстов — пустую строку.
+func Unified(a, b, fromName, toName string) string {
+	ops := compare(splitLines(a), splitLines(b))
+	hunks := group(ops)
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+	for _, h := range hunks {
+		aStart, aLen, bStart, bLen := h[0].a, 0, h[0].b, 0
+		for _, o := range h {
+			if o.kind != opInsert {
+				aLen++
+			}
+			if o.kind != opDelete {
+				bLen++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(aStart, aLen), hunkRange(bStart, bLen))
+		for _, o := range h {
+			out.WriteByte(byte(o.kind))
+			out.WriteString(o.line)
+			out.WriteByte('\n')
+		}
+	}
+	return out.String()
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+func compare(a, b []string) []op {
+	// lcs[i][j] — длина общей подпоследовательности a[i:] и b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var ops []op
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, op{opEqual, a[i], i, j})
+			i, j = i+1, j+1
+		case j == len(b) || i < len(a) && lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, op{opDelete, a[i], i, j})
+			i++
+		default:
+			ops = append(ops, op{opInsert, b[j], i, j})
+			j++
+		}
+	}
+	return ops
+}
+
+// group режет операции на ханки: изменения плюс до Context строк вокруг;
+// ханки, между которыми не больше 2*Context общих строк, сливаются.
+func group(ops []op) [][]op {
+	var hunks [][]op
+	start, end := -1, -1
+	for k, o := range ops {
+		if o.kind == opEqual {
+			continue
+		}
+		lo, hi := max(k-Context, 0), min(k+Context+1, len(ops))
+		if start >= 0 && lo > end {
+			hunks = append(hunks, ops[start:end])
+			start = -1
+		}
+		if start < 0 {
+			start = lo
+		}
+		end = hi
+	}
+	if start >= 0 {
+		hunks = append(hunks, ops[start:end])
+	}
+	return hunks
+}
+
+// hunkRange пишет диапазон строк в формате unified diff: пустой диапазон указывает на строку перед ним.
+func hunkRange(start, length int) string {
+	if length == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if length == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, length)
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
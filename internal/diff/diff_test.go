@@ -0,0 +1,26 @@
+package diff
+
+import "testing"
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{"same", "a\nb\n", "a\nb", ""},
+		{"replace line", "one\ntwo\nthree", "one\n2\nthree",
+			"--- r1\n+++ r2\n@@ -1,3 +1,3 @@\n one\n-two\n+2\n three\n"},
+		{"from empty", "", "hello", "--- r1\n+++ r2\n@@ -0,0 +1 @@\n+hello\n"},
+		{"to empty", "hello", "", "--- r1\n+++ r2\n@@ -1 +0,0 @@\n-hello\n"},
+		{"two hunks", "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12", "x\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\ny",
+			"--- r1\n+++ r2\n@@ -1,4 +1,4 @@\n-1\n+x\n 2\n 3\n 4\n@@ -9,4 +9,4 @@\n 9\n 10\n 11\n-12\n+y\n"},
+		{"merged hunk", "1\n2\n3\n4\n5\n6\n7", "x\n2\n3\n4\n5\n6\ny",
+			"--- r1\n+++ r2\n@@ -1,7 +1,7 @@\n-1\n+x\n 2\n 3\n 4\n 5\n 6\n-7\n+y\n"},
+	}
+	for _, tt := range tests {
+		if got := Unified(tt.a, tt.b, "r1", "r2"); got != tt.want {
+			t.Errorf("%s:\ngot:\n%s\nwant:\n%s", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package errs
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+type Code string
+
+const (
+	CodeBadRequest   Code = "bad_request"
+	CodeUnauthorized Code = "unauthorized"
+	CodeForbidden    Code = "forbidden"
+	CodeNotFound     Code = "not_found"
+	CodeConflict     Code = "conflict"
+	CodeTooMany      Code = "too_many_requests"
+	CodeInternal     Code = "internal"
+)
+
+// Сравнивать ошибки нужно через errors.Is(err, errs.NotFound): совпадение идёт только по коду.
+var (
+	BadRequest   = &Error{Code: CodeBadRequest}
+	Unauthorized = &Error{Code: CodeUnauthorized}
+	Forbidden    = &Error{Code: CodeForbidden}
+	NotFound     = &Error{Code: CodeNotFound}
+	Conflict     = &Error{Code: CodeConflict}
+	TooMany      = &Error{Code: CodeTooMany}
+	Internal     = &Error{Code: CodeInternal}
+)
+
+var statuses = map[Code]int{
+	CodeBadRequest:   http.StatusBadRequest,
+	CodeUnauthorized: http.StatusUnauthorized,
+	CodeForbidden:    http.StatusForbidden,
+	CodeNotFound:     http.StatusNotFound,
+	CodeConflict:     http.StatusConflict,
+	CodeTooMany:      http.StatusTooManyRequests,
+	CodeInternal:     http.StatusInternalServerError,
+}
+
+type Error struct {
+	Code    Code
+	Message string
+	// Field указывает на поле запроса, из-за которого возникла ошибка, например "posts[3].parent".
+	Field string
+	Err   error
+}
+
+func New(code Code, format string, args ...interface{}) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// Wrap превращает произвольную ошибку в внутреннюю, сохраняя причину.
+// Если err уже *Error, он возвращается без изменений.
+func Wrap(err error, format string, args ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return err
+	}
+	return &Error{Code: CodeInternal, Message: fmt.Sprintf(format, args...), Err: err}
+}
+
+func (e *Error) WithField(field string) *Error {
+	c := *e
+	c.Field = field
+	return &c
+}
+
+func (e *Error) Error() string {
+	msg := string(e.Code)
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	if e.Field != "" {
+		msg += " (" + e.Field + ")"
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok {
+		return false
+	}
+	return t.Message == "" && t.Field == "" && t.Err == nil && t.Code == e.Code
+}
+
+// CodeOf возвращает код ошибки; ошибки не из этого пакета считаются внутренними.
+func CodeOf(err error) Code {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return CodeInternal
+}
+
+func HTTPStatus(err error) int {
+	if status, ok := statuses[CodeOf(err)]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
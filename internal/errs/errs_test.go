@@ -0,0 +1,93 @@
+package errs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestHTTPStatus(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want int
+	}{
+		{New(CodeBadRequest, "bad"), http.StatusBadRequest},
+		{New(CodeUnauthorized, "who"), http.StatusUnauthorized},
+		{New(CodeForbidden, "no"), http.StatusForbidden},
+		{New(CodeNotFound, "gone"), http.StatusNotFound},
+		{New(CodeConflict, "taken"), http.StatusConflict},
+		{New(CodeTooMany, "slow down"), http.StatusTooManyRequests},
+		{New(CodeInternal, "oops"), http.StatusInternalServerError},
+		{New("teapot", "unknown code"), http.StatusInternalServerError},
+		{io.EOF, http.StatusInternalServerError},
+		{fmt.Errorf("get thread: %w", New(CodeNotFound, "gone")), http.StatusNotFound},
+	} {
+		if got := HTTPStatus(tc.err); got != tc.want {
+			t.Errorf("HTTPStatus(%v) = %d, want %d", tc.err, got, tc.want)
+		}
+	}
+	// У каждого кода свой статус.
+	for code, status := range statuses {
+		if got := HTTPStatus(&Error{Code: code}); got != status {
+			t.Errorf("%s: %d, want %d", code, got, status)
+		}
+	}
+}
+
+func TestIs(t *testing.T) {
+	err := New(CodeNotFound, "user %q not found", "alice").WithField("nickname")
+	for _, wrapped := range []error{err, fmt.Errorf("load: %w", err)} {
+		if !errors.Is(wrapped, NotFound) {
+			t.Errorf("%v is not NotFound", wrapped)
+		}
+		if errors.Is(wrapped, Conflict) {
+			t.Errorf("%v matches Conflict", wrapped)
+		}
+	}
+	// Сравнение только с голым кодом: ошибка с сообщением не годится как образец.
+	if errors.Is(New(CodeNotFound, "a"), New(CodeNotFound, "b")) {
+		t.Error("errors with messages must not match each other")
+	}
+	if errors.Is(io.EOF, NotFound) {
+		t.Error("foreign error matches NotFound")
+	}
+}
+
+func TestWrap(t *testing.T) {
+	if Wrap(nil, "nothing") != nil {
+		t.Error("Wrap(nil) is not nil")
+	}
+
+	wrapped := Wrap(io.ErrUnexpectedEOF, "read %s", "post")
+	if CodeOf(wrapped) != CodeInternal || !errors.Is(wrapped, Internal) {
+		t.Errorf("Wrap of a foreign error: code %s", CodeOf(wrapped))
+	}
+	if !errors.Is(wrapped, io.ErrUnexpectedEOF) {
+		t.Error("Wrap lost the cause")
+	}
+	if got := wrapped.Error(); got != "internal: read post: unexpected EOF" {
+		t.Errorf("Error() = %q", got)
+	}
+
+	for _, err := range []error{New(CodeConflict, "exists"), fmt.Errorf("create: %w", New(CodeConflict, "exists"))} {
+		if got := Wrap(err, "create user"); got != err || CodeOf(got) != CodeConflict || !errors.Is(got, Conflict) {
+			t.Errorf("Wrap(%v) = %v, want the error unchanged", err, got)
+		}
+	}
+}
+
+func TestWithField(t *testing.T) {
+	base := New(CodeBadRequest, "invalid parent")
+	field := base.WithField("posts[3].parent")
+	if base.Field != "" {
+		t.Error("WithField changed the original error")
+	}
+	if field.Code != CodeBadRequest || field.Message != base.Message {
+		t.Errorf("WithField lost code or message: %+v", field)
+	}
+	if got := field.Error(); got != "bad_request: invalid parent (posts[3].parent)" {
+		t.Errorf("Error() = %q", got)
+	}
+}
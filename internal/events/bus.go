@@ -0,0 +1,206 @@
+package events
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Типы событий, которые публикует UseCase.
+const (
+	PostCreated   = "post.created"
+	PostUpdated   = "post.updated"
+	ThreadUpdated = "thread.updated"
+	ThreadVoted   = "thread.voted"
+)
+
+// ID события в виде "<эпоха>-<номер>". Номера начинаются с 1 при каждом запуске шины,
+// а эпоха отличает их от номеров, выданных прошлым запуском процесса.
+type ID struct {
+	Epoch string
+	Seq   uint64
+}
+
+func (id ID) String() string {
+	if id.Epoch == "" {
+		return ""
+	}
+	return id.Epoch + "-" + strconv.FormatUint(id.Seq, 10)
+}
+
+// ParseID разбирает ID из Last-Event-ID или last_event_id; пустая строка — нулевой ID.
+func ParseID(raw string) (ID, error) {
+	if raw == "" {
+		return ID{}, nil
+	}
+	epoch, seq, ok := strings.Cut(raw, "-")
+	if !ok || epoch == "" {
+		return ID{}, fmt.Errorf("event id %q: want <epoch>-<seq>", raw)
+	}
+	n, err := strconv.ParseUint(seq, 10, 64)
+	if err != nil {
+		return ID{}, fmt.Errorf("event id %q: %w", raw, err)
+	}
+	return ID{Epoch: epoch, Seq: n}, nil
+}
+
+// Event хранит уже сериализованные данные, чтобы не кодировать их для каждого подписчика.
+type Event struct {
+	ID     ID
+	Type   string
+	Forum  string
+	Thread int
+	Data   []byte
+}
+
+// Filter с пустым Forum и нулевым Thread пропускает все события.
+type Filter struct {
+	Forum  string
+	Thread int
+}
+
+func (f Filter) match(e Event) bool {
+	if f.Thread != 0 && f.Thread != e.Thread {
+		return false
+	}
+	return f.Forum == "" || strings.EqualFold(f.Forum, e.Forum)
+}
+
+// Сколько событий подписчик может не забрать, прежде чем его отключат.
+const subscriptionBuffer = 64
+
+type Subscription struct {
+	C <-chan Event
+	// Since — ID последнего события, опубликованного до подписки; Seq == 0, если их не было.
+	Since  ID
+	ch     chan Event
+	filter Filter
+}
+
+// Bus раздаёт события подписчикам внутри процесса и помнит последние size событий
+// для переподключения по Last-Event-ID. Методы nil *Bus ничего не делают.
+type Bus struct {
+	mu     sync.Mutex
+	epoch  string
+	size   int
+	replay []Event
+	nextID uint64
+	subs   map[*Subscription]struct{}
+	closed bool
+}
+
+func New(size int) *Bus {
+	return &Bus{epoch: newEpoch(), size: size, nextID: 1, subs: map[*Subscription]struct{}{}}
+}
+
+func newEpoch() string {
+	var b [4]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// Publish не блокируется: подписчик, который не успевает читать, отключается
+// и может догнать пропущенное из буфера повтора.
+func (b *Bus) Publish(typ string, forum string, thread int, payload interface{}) {
+	if b == nil {
+		return
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	e := Event{ID: ID{Epoch: b.epoch, Seq: b.nextID}, Type: typ, Forum: forum, Thread: thread, Data: data}
+	b.nextID++
+	if b.size > 0 {
+		if len(b.replay) == b.size {
+			b.replay = append(b.replay[:0], b.replay[1:]...)
+		}
+		b.replay = append(b.replay, e)
+	}
+	for sub := range b.subs {
+		if !sub.filter.match(e) {
+			continue
+		}
+		select {
+		case sub.ch <- e:
+		default:
+			b.drop(sub)
+		}
+	}
+}
+
+// Subscribe возвращает события после lastID, ещё лежащие в буфере, и подписку на новые.
+// complete == false, если часть событий после lastID уже вытеснена или lastID выдан
+// другим запуском процесса: клиенту надо перечитать состояние целиком.
+// Нулевой lastID означает подписку без повтора.
+func (b *Bus) Subscribe(filter Filter, lastID ID) (sub *Subscription, missed []Event, complete bool) {
+	ch := make(chan Event, subscriptionBuffer)
+	sub = &Subscription{C: ch, ch: ch, filter: filter}
+	resume := lastID != ID{}
+	if b == nil {
+		close(ch)
+		return sub, nil, !resume
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		close(ch)
+		return sub, nil, !resume
+	}
+	b.subs[sub] = struct{}{}
+	sub.Since = ID{Epoch: b.epoch, Seq: b.nextID - 1}
+	if !resume {
+		return sub, nil, true
+	}
+	if lastID.Epoch != b.epoch || lastID.Seq >= b.nextID {
+		return sub, nil, false
+	}
+	seq := lastID.Seq
+	complete = seq+1 == b.nextID || len(b.replay) > 0 && b.replay[0].ID.Seq <= seq+1
+	for _, e := range b.replay {
+		if e.ID.Seq > seq && filter.match(e) {
+			missed = append(missed, e)
+		}
+	}
+	return sub, missed, complete
+}
+
+func (b *Bus) Unsubscribe(sub *Subscription) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subs[sub]; ok {
+		b.drop(sub)
+	}
+}
+
+// Close закрывает все подписки, чтобы долгие SSE-запросы не задерживали остановку сервера.
+func (b *Bus) Close() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for sub := range b.subs {
+		b.drop(sub)
+	}
+}
+
+func (b *Bus) drop(sub *Subscription) {
+	delete(b.subs, sub)
+	close(sub.ch)
+}
@@ -0,0 +1,164 @@
+package events
+
+import (
+	"fmt"
+	"testing"
+)
+
+func ids(events []Event) []uint64 {
+	res := make([]uint64, 0, len(events))
+	for _, e := range events {
+		res = append(res, e.ID.Seq)
+	}
+	return res
+}
+
+func receive(t *testing.T, sub *Subscription) Event {
+	t.Helper()
+	select {
+	case e, ok := <-sub.C:
+		if !ok {
+			t.Fatal("subscription closed")
+		}
+		return e
+	default:
+		t.Fatal("no event")
+	}
+	return Event{}
+}
+
+func TestFilter(t *testing.T) {
+	b := New(10)
+	thread, _, _ := b.Subscribe(Filter{Thread: 2}, ID{})
+	forum, _, _ := b.Subscribe(Filter{Forum: "news"}, ID{})
+	all, _, _ := b.Subscribe(Filter{}, ID{})
+
+	b.Publish(PostCreated, "News", 1, map[string]int{"id": 1})
+	b.Publish(PostCreated, "other", 2, map[string]int{"id": 2})
+
+	if e := receive(t, thread); e.ID.Seq != 2 {
+		t.Errorf("thread subscriber got %s", e.ID)
+	}
+	if e := receive(t, forum); e.ID.Seq != 1 || string(e.Data) != `{"id":1}` {
+		t.Errorf("forum subscriber got %s %s", e.ID, e.Data)
+	}
+	if e1, e2 := receive(t, all), receive(t, all); e1.ID.Seq != 1 || e2.ID.Seq != 2 {
+		t.Errorf("got %s, %s", e1.ID, e2.ID)
+	}
+	if len(thread.C)+len(forum.C) != 0 {
+		t.Error("filtered subscribers got extra events")
+	}
+}
+
+func TestReplay(t *testing.T) {
+	b := New(3)
+	for i := 1; i <= 5; i++ {
+		b.Publish(ThreadVoted, "f", i%2, i)
+	}
+
+	cases := []struct {
+		name     string
+		filter   Filter
+		lastID   uint64
+		missed   []uint64
+		complete bool
+	}{
+		{"up to date", Filter{}, 5, nil, true},
+		{"from the start", Filter{}, 0, []uint64{3, 4, 5}, false},
+		{"in buffer", Filter{}, 3, []uint64{4, 5}, true},
+		{"buffer edge", Filter{}, 2, []uint64{3, 4, 5}, true},
+		{"evicted", Filter{}, 1, []uint64{3, 4, 5}, false},
+		{"filtered", Filter{Thread: 1}, 2, []uint64{3, 5}, true},
+		{"ahead", Filter{}, 100, nil, false},
+	}
+	for _, c := range cases {
+		sub, missed, complete := b.Subscribe(c.filter, ID{Epoch: b.epoch, Seq: c.lastID})
+		b.Unsubscribe(sub)
+		if sub.Since != (ID{Epoch: b.epoch, Seq: 5}) {
+			t.Errorf("%s: subscribed since %s", c.name, sub.Since)
+		}
+		if got := ids(missed); fmt.Sprint(got) != fmt.Sprint(c.missed) || complete != c.complete {
+			t.Errorf("%s: got %v %v, want %v %v", c.name, got, complete, c.missed, c.complete)
+		}
+	}
+}
+
+func TestSlowSubscriberIsDropped(t *testing.T) {
+	b := New(0)
+	slow, _, _ := b.Subscribe(Filter{}, ID{})
+	for i := 0; i <= subscriptionBuffer; i++ {
+		b.Publish(PostCreated, "f", 1, i)
+	}
+	n := 0
+	for range slow.C {
+		n++
+	}
+	if n != subscriptionBuffer {
+		t.Errorf("slow subscriber got %d events before close", n)
+	}
+	// Повторная отписка после отключения не должна паниковать.
+	b.Unsubscribe(slow)
+}
+
+func TestClose(t *testing.T) {
+	b := New(10)
+	sub, _, _ := b.Subscribe(Filter{}, ID{})
+	b.Close()
+	if _, ok := <-sub.C; ok {
+		t.Error("subscription is open after Close")
+	}
+	b.Publish(PostCreated, "f", 1, 1)
+	late, _, _ := b.Subscribe(Filter{}, ID{})
+	if _, ok := <-late.C; ok {
+		t.Error("subscription after Close is open")
+	}
+
+	var nilBus *Bus
+	nilBus.Publish(PostCreated, "f", 1, 1)
+	nilBus.Close()
+}
+
+func TestResumeNoReplay(t *testing.T) {
+	b := New(3)
+	b.Publish(ThreadVoted, "f", 1, 1)
+	sub, missed, complete := b.Subscribe(Filter{}, ID{})
+	if len(missed) != 0 || !complete || sub.Since.Seq != 1 {
+		t.Errorf("got since %s, %v %v, want no replay", sub.Since, ids(missed), complete)
+	}
+}
+
+func TestResumeFromAnotherBus(t *testing.T) {
+	old := New(10)
+	for i := 0; i < 2; i++ {
+		old.Publish(ThreadVoted, "f", 1, i)
+	}
+	lastID := old.replay[0].ID
+
+	// Новый запуск уже опубликовал больше событий, чем видел клиент: номер lastID в буфере есть,
+	// но относится к другим событиям.
+	b := New(10)
+	for i := 0; i < 5; i++ {
+		b.Publish(ThreadVoted, "f", 1, i)
+	}
+	_, missed, complete := b.Subscribe(Filter{}, lastID)
+	if len(missed) != 0 || complete {
+		t.Errorf("resume with an id of another bus: got %v %v, want reset", ids(missed), complete)
+	}
+}
+
+func TestParseID(t *testing.T) {
+	b := New(1)
+	b.Publish(PostCreated, "f", 1, 1)
+	want := b.replay[0].ID
+	if got, err := ParseID(want.String()); err != nil || got != want {
+		t.Errorf("ParseID(%q): got %+v, %v", want.String(), got, err)
+	}
+	if got, err := ParseID(""); err != nil || got != (ID{}) {
+		t.Errorf("ParseID(empty): got %+v, %v", got, err)
+	}
+	for _, raw := range []string{"5", "-5", "abc-", "abc-x"} {
+		if _, err := ParseID(raw); err == nil {
+			t.Errorf("ParseID(%q) accepted", raw)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/BigBullas/TP_DB_project/internal/auth"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	var seen string
+	h := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = RequestID(r.Context())
+	}))
+
+	for _, tc := range []struct {
+		header string
+		keep   bool
+	}{
+		{"", false},
+		{"abc-123.X_y", true},
+		{"bad id\nwith newline", false},
+		{strings.Repeat("a", 65), false},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tc.header != "" {
+			req.Header.Set(RequestIDHeader, tc.header)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		got := rec.Header().Get(RequestIDHeader)
+		if got == "" || got != seen {
+			t.Errorf("%q: response id %q, context id %q", tc.header, got, seen)
+		}
+		if (got == tc.header) != tc.keep {
+			t.Errorf("%q: got id %q, keep=%v", tc.header, got, tc.keep)
+		}
+	}
+}
+
+func TestContextAttrs(t *testing.T) {
+	var buf bytes.Buffer
+	log := New(&buf, "info", "json")
+	ctx := auth.WithCaller(WithRequestID(context.Background(), "req-1"), "alice")
+
+	log.DebugContext(ctx, "hidden")
+	log.InfoContext(ctx, "visible", "forum", "f1")
+
+	var rec map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("want one json record, got %q: %v", buf.String(), err)
+	}
+	for key, want := range map[string]string{"msg": "visible", "request_id": "req-1", "caller": "alice", "forum": "f1"} {
+		if rec[key] != want {
+			t.Errorf("%s = %v, want %s", key, rec[key], want)
+		}
+	}
+}
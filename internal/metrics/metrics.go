@@ -0,0 +1,143 @@
+package metrics
+
+import (
+	"bufio"
+	"errors"
+	"github.com/BigBullas/TP_DB_project/internal/errs"
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const namespace = "forum"
+
+// Metrics держит свой реестр, а не глобальный prometheus.DefaultRegisterer,
+// чтобы тесты и несколько серверов в одном процессе не мешали друг другу.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	queryDuration   *prometheus.HistogramVec
+	queryErrors     *prometheus.CounterVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: "http", Name: "requests_total",
+			Help: "HTTP requests by route template, method and status code.",
+		}, []string{"route", "method", "code"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace, Subsystem: "http", Name: "request_duration_seconds",
+			Help:    "HTTP request latency by route template and method.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"route", "method"}),
+		queryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace, Subsystem: "repository", Name: "duration_seconds",
+			Help:    "Repository call latency by method.",
+			Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"method"}),
+		queryErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: "repository", Name: "errors_total",
+			Help: "Repository call errors by method and error code.",
+		}, []string{"method", "code"}),
+	}
+	m.registry.MustRegister(
+		m.requests, m.requestDuration, m.queryDuration, m.queryErrors,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// Handler отдаёт метрики в текстовом формате Prometheus.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// Middleware подписывает запросы шаблоном маршрута mux, а не путём, чтобы число
+// рядов не зависело от ников и слагов. Подключается через Router.Use.
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := "unknown"
+		if current := mux.CurrentRoute(r); current != nil {
+			if tmpl, err := current.GetPathTemplate(); err == nil {
+				route = tmpl
+			}
+		}
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w}
+		next.ServeHTTP(sw, r)
+		if sw.status == 0 {
+			sw.status = http.StatusOK
+		}
+		m.requests.WithLabelValues(route, r.Method, strconv.Itoa(sw.status)).Inc()
+		m.requestDuration.WithLabelValues(route, r.Method).Observe(time.Since(start).Seconds())
+	})
+}
+
+// ObserveQuery учитывает вызов метода репозитория. NotFound и Conflict — обычные ответы
+// API, но тоже считаются: по ним видно, например, всплеск конфликтов при вставке.
+func (m *Metrics) ObserveQuery(method string, start time.Time, err error) {
+	m.queryDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	if err != nil {
+		m.queryErrors.WithLabelValues(method, string(errs.CodeOf(err))).Inc()
+	}
+}
+
+// RegisterPool добавляет показатели pgxpool.Stat, которые снимаются при каждом сборе.
+func (m *Metrics) RegisterPool(pool *pgxpool.Pool) {
+	m.registry.MustRegister(&poolCollector{pool: pool})
+}
+
+// statusWriter запоминает код ответа. Flush и Hijack нужны SSE и WebSocket,
+// Unwrap — http.ResponseController.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack: после успешного перехвата соединения для WebSocket код ответа — 101.
+func (w *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+	conn, rw, err := h.Hijack()
+	if err == nil && w.status == 0 {
+		w.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"errors"
+	"github.com/BigBullas/TP_DB_project/internal/errs"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMiddleware(t *testing.T) {
+	m := New()
+	router := mux.NewRouter()
+	router.Use(m.Middleware)
+	router.HandleFunc("/user/{nickname}/profile", func(w http.ResponseWriter, r *http.Request) {
+		if mux.Vars(r)["nickname"] == "nobody" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte("{}"))
+	})
+	router.HandleFunc("/stream", func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := w.(http.Flusher); !ok {
+			t.Error("wrapped writer is not a Flusher")
+		}
+		if err := http.NewResponseController(w).Flush(); err != nil {
+			t.Errorf("ResponseController.Flush: %v", err)
+		}
+	})
+	router.Handle("/metrics", m.Handler())
+
+	for _, path := range []string{"/user/alice/profile", "/user/bob/profile", "/user/nobody/profile", "/stream"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	route := "/user/{nickname}/profile"
+	if n := testutil.ToFloat64(m.requests.WithLabelValues(route, http.MethodGet, "200")); n != 2 {
+		t.Errorf("200 requests: %v, want 2", n)
+	}
+	if n := testutil.ToFloat64(m.requests.WithLabelValues(route, http.MethodGet, "404")); n != 1 {
+		t.Errorf("404 requests: %v, want 1", n)
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+	for _, want := range []string{
+		`forum_http_request_duration_seconds_count{method="GET",route="/user/{nickname}/profile"} 3`,
+		`forum_http_requests_total{code="200",method="GET",route="/stream"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("/metrics has no %s", want)
+		}
+	}
+}
+
+func TestObserveQuery(t *testing.T) {
+	m := New()
+	start := time.Now()
+	m.ObserveQuery("GetUser", start, nil)
+	m.ObserveQuery("GetUser", start, errs.New(errs.CodeNotFound, "no user"))
+	m.ObserveQuery("GetUser", start, errors.New("connection reset"))
+
+	if n := testutil.CollectAndCount(m.queryDuration); n != 1 {
+		t.Errorf("duration series: %d, want 1", n)
+	}
+	for code, want := range map[errs.Code]float64{errs.CodeNotFound: 1, errs.CodeInternal: 1, errs.CodeConflict: 0} {
+		if n := testutil.ToFloat64(m.queryErrors.WithLabelValues("GetUser", string(code))); n != want {
+			t.Errorf("%s errors: %v, want %v", code, n, want)
+		}
+	}
+}
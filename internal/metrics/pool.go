@@ -0,0 +1,48 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	poolAcquired = prometheus.NewDesc(namespace+"_db_pool_acquired_conns",
+		"Connections currently in use.", nil, nil)
+	poolIdle = prometheus.NewDesc(namespace+"_db_pool_idle_conns",
+		"Idle connections in the pool.", nil, nil)
+	poolTotal = prometheus.NewDesc(namespace+"_db_pool_total_conns",
+		"All connections in the pool, including ones being established.", nil, nil)
+	poolMax = prometheus.NewDesc(namespace+"_db_pool_max_conns",
+		"Maximum pool size.", nil, nil)
+	poolAcquires = prometheus.NewDesc(namespace+"_db_pool_acquires_total",
+		"Successful connection acquires.", nil, nil)
+	poolWaits = prometheus.NewDesc(namespace+"_db_pool_waits_total",
+		"Acquires that had to wait because the pool had no idle connection.", nil, nil)
+	poolCanceled = prometheus.NewDesc(namespace+"_db_pool_canceled_acquires_total",
+		"Acquires canceled by the caller's context.", nil, nil)
+	poolAcquireSeconds = prometheus.NewDesc(namespace+"_db_pool_acquire_seconds_total",
+		"Total time spent acquiring connections, waits included.", nil, nil)
+)
+
+// poolCollector читает pgxpool.Stat в момент сбора, поэтому значения не отстают от пула.
+type poolCollector struct {
+	pool *pgxpool.Pool
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{poolAcquired, poolIdle, poolTotal, poolMax, poolAcquires, poolWaits, poolCanceled, poolAcquireSeconds} {
+		ch <- d
+	}
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.pool.Stat()
+	ch <- prometheus.MustNewConstMetric(poolAcquired, prometheus.GaugeValue, float64(s.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(poolIdle, prometheus.GaugeValue, float64(s.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(poolTotal, prometheus.GaugeValue, float64(s.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(poolMax, prometheus.GaugeValue, float64(s.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(poolAcquires, prometheus.CounterValue, float64(s.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(poolWaits, prometheus.CounterValue, float64(s.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(poolCanceled, prometheus.CounterValue, float64(s.CanceledAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(poolAcquireSeconds, prometheus.CounterValue, s.AcquireDuration().Seconds())
+}
@@ -0,0 +1,275 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Ключ advisory lock, чтобы две копии сервиса не накатывали миграции одновременно.
+const lockKey = 7347201
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+type Status struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+type Migrator struct {
+	pool       *pgxpool.Pool
+	migrations []Migration
+}
+
+func New(pool *pgxpool.Pool, files fs.FS) (*Migrator, error) {
+	migrations, err := Load(files)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{pool: pool, migrations: migrations}, nil
+}
+
+// Load читает пары up/down из каталога migrations и сортирует их по версии.
+func Load(files fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(files, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", entry.Name(), err)
+		}
+		body, err := fs.ReadFile(files, path.Join("migrations", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has different names: %s and %s", version, m.Name, match[2])
+		}
+		script := &m.Down
+		if match[3] == "up" {
+			script = &m.Up
+		}
+		if *script != "" {
+			return nil, fmt.Errorf("migration %d_%s has more than one %s script", version, m.Name, match[3])
+		}
+		*script = string(body)
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+func (m *Migrator) Latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version возвращает номер последней применённой миграции, 0 если схема пуста.
+func (m *Migrator) Version(ctx context.Context) (int64, error) {
+	const GetVersion = `SELECT COALESCE(max(Version), 0) FROM schema_migrations;`
+	const TableExists = `SELECT to_regclass('schema_migrations') IS NOT NULL;`
+
+	var exists bool
+	if err := m.pool.QueryRow(ctx, TableExists).Scan(&exists); err != nil {
+		return 0, err
+	}
+	if !exists {
+		return 0, nil
+	}
+	var version int64
+	err := m.pool.QueryRow(ctx, GetVersion).Scan(&version)
+	return version, err
+}
+
+// Check возвращает ошибку, если применённая версия схемы не совпадает с последней
+// известной этой сборке: старая схема ломает новые запросы, новая — старые.
+func (m *Migrator) Check(ctx context.Context) error {
+	version, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+	if latest := m.Latest(); version != latest {
+		return fmt.Errorf("schema version %d, want %d", version, latest)
+	}
+	return nil
+}
+
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.locked(ctx, func(conn *pgxpool.Conn) error {
+		done, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			if _, ok := done[migration.Version]; ok {
+				continue
+			}
+			const InsertVersion = `INSERT INTO schema_migrations(Version, Name) VALUES ($1, $2);`
+			err := inTx(ctx, conn, func(tx pgx.Tx) error {
+				if _, err := tx.Exec(ctx, migration.Up); err != nil {
+					return err
+				}
+				_, err := tx.Exec(ctx, InsertVersion, migration.Version, migration.Name)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("apply %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down откатывает steps последних применённых миграций.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var reverted []Migration
+	err := m.locked(ctx, func(conn *pgxpool.Conn) error {
+		done, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := done[migration.Version]; !ok {
+				continue
+			}
+			if migration.Down == "" {
+				return fmt.Errorf("migration %d_%s has no down script", migration.Version, migration.Name)
+			}
+			const DeleteVersion = `DELETE FROM schema_migrations WHERE Version = $1;`
+			err := inTx(ctx, conn, func(tx pgx.Tx) error {
+				if _, err := tx.Exec(ctx, migration.Down); err != nil {
+					return err
+				}
+				_, err := tx.Exec(ctx, DeleteVersion, migration.Version)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("revert %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			reverted = append(reverted, migration)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var statuses []Status
+	err := m.locked(ctx, func(conn *pgxpool.Conn) error {
+		done, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			appliedAt, ok := done[migration.Version]
+			statuses = append(statuses, Status{
+				Version:   migration.Version,
+				Name:      migration.Name,
+				Applied:   ok,
+				AppliedAt: appliedAt,
+			})
+		}
+		return nil
+	})
+	return statuses, err
+}
+
+func (m *Migrator) locked(ctx context.Context, fn func(conn *pgxpool.Conn) error) error {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1);`, lockKey); err != nil {
+		return err
+	}
+	defer func() {
+		_, _ = conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1);`, lockKey)
+	}()
+
+	const CreateTable = `CREATE TABLE IF NOT EXISTS schema_migrations
+(
+    Version    BIGINT PRIMARY KEY,
+    Name       TEXT NOT NULL,
+    AppliedAt  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);`
+	if _, err := conn.Exec(ctx, CreateTable); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+func appliedVersions(ctx context.Context, conn *pgxpool.Conn) (map[int64]time.Time, error) {
+	rows, err := conn.Query(ctx, `SELECT Version, AppliedAt FROM schema_migrations;`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	done := make(map[int64]time.Time)
+	for rows.Next() {
+		var version int64
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		done[version] = appliedAt
+	}
+	return done, rows.Err()
+}
+
+func inTx(ctx context.Context, conn *pgxpool.Conn, fn func(tx pgx.Tx) error) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback(ctx)
+		return err
+	}
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,81 @@
+package migrate
+
+import (
+	"github.com/BigBullas/TP_DB_project/db"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func files(names ...string) fstest.MapFS {
+	fsys := fstest.MapFS{}
+	for _, name := range names {
+		fsys["migrations/"+name] = &fstest.MapFile{Data: []byte("-- " + name)}
+	}
+	return fsys
+}
+
+func TestLoad(t *testing.T) {
+	migrations, err := Load(files(
+		"0010_late.up.sql",
+		"0002_second.up.sql", "0002_second.down.sql",
+		"0001_init.up.sql", "0001_init.down.sql",
+		"README.md", "0003_draft.sql",
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Migration{
+		{Version: 1, Name: "init", Up: "-- 0001_init.up.sql", Down: "-- 0001_init.down.sql"},
+		{Version: 2, Name: "second", Up: "-- 0002_second.up.sql", Down: "-- 0002_second.down.sql"},
+		{Version: 10, Name: "late", Up: "-- 0010_late.up.sql"},
+	}
+	if len(migrations) != len(want) {
+		t.Fatalf("got %+v, want %+v", migrations, want)
+	}
+	for i := range want {
+		if migrations[i] != want[i] {
+			t.Errorf("migration %d: got %+v, want %+v", i, migrations[i], want[i])
+		}
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files fstest.MapFS
+		want  string
+	}{
+		{name: "no directory", files: fstest.MapFS{}, want: "migrations"},
+		{name: "missing up", files: files("0001_init.up.sql", "0002_second.down.sql"), want: "migration 2_second has no up script"},
+		{name: "different names", files: files("0001_init.up.sql", "0001_other.down.sql"), want: "different names"},
+		{name: "duplicate up", files: files("0001_init.up.sql", "1_init.up.sql"), want: "more than one up script"},
+		{name: "duplicate down", files: files("0001_init.up.sql", "0001_init.down.sql", "01_init.down.sql"), want: "more than one down script"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(tt.files)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Load: got %v, want error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadEmbedded(t *testing.T) {
+	migrations, err := Load(db.Migrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("no embedded migrations")
+	}
+	for i, m := range migrations {
+		if m.Version != int64(i+1) {
+			t.Errorf("migration %d_%s: versions must go 1, 2, 3... without gaps", m.Version, m.Name)
+		}
+		if m.Down == "" {
+			t.Errorf("migration %d_%s has no down script", m.Version, m.Name)
+		}
+	}
+}
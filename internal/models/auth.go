@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// easyjson -all ./internal/models/auth.go
+
+const (
+	CredentialSession = "session"
+	CredentialToken   = "token"
+)
+
+type Login struct {
+	Nickname string `json:"nickname"`
+	Password string `json:"password"`
+}
+
+// Credential — сессия или API-токен. В хранилище лежит только Hash,
+// сам Token отдаётся клиенту один раз при выдаче.
+type Credential struct {
+	Hash     string    `json:"-"`
+	Token    string    `json:"token,omitempty"`
+	Kind     string    `json:"kind"`
+	Nickname string    `json:"nickname"`
+	Name     string    `json:"name,omitempty"`
+	Created  time.Time `json:"created"`
+	Expires  time.Time `json:"expires"`
+}
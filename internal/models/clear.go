@@ -0,0 +1,15 @@
+package models
+
+// easyjson -all ./internal/models/clear.go
+
+// ClearReport — сколько строк удалено или, при DryRun, было бы удалено. Posts считает и
+// удалённые посты. При очистке одного форума (Forum не пуст) пользователи остаются.
+type ClearReport struct {
+	DryRun  bool   `json:"dryRun"`
+	Forum   string `json:"forum,omitempty"`
+	Users   int64  `json:"users"`
+	Forums  int64  `json:"forums"`
+	Threads int64  `json:"threads"`
+	Posts   int64  `json:"posts"`
+	Votes   int64  `json:"votes"`
+}
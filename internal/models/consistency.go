@@ -0,0 +1,29 @@
+package models
+
+// easyjson -all ./internal/models/consistency.go
+
+// Виды расхождений в ConsistencyMismatch.Kind.
+const (
+	MismatchForumPosts        = "forum_posts"
+	MismatchForumThreads      = "forum_threads"
+	MismatchThreadVotes       = "thread_votes"
+	MismatchForumUserMissing  = "forum_user_missing"
+	MismatchForumUserExtra    = "forum_user_extra"
+	MismatchForumUserOutdated = "forum_user_outdated"
+)
+
+// ConsistencyMismatch — агрегат, который разошёлся с пересчётом по post, thread и vote.
+// Для users_forum Stored и Actual не заполняются: важен сам факт лишней, недостающей или устаревшей строки.
+type ConsistencyMismatch struct {
+	Kind     string `json:"kind"`
+	Forum    string `json:"forum,omitempty"`
+	Thread   int    `json:"thread,omitempty"`
+	Nickname string `json:"nickname,omitempty"`
+	Stored   int64  `json:"stored"`
+	Actual   int64  `json:"actual"`
+}
+
+type ConsistencyReport struct {
+	Mismatches []ConsistencyMismatch `json:"mismatches"`
+	Repaired   bool                  `json:"repaired"`
+}
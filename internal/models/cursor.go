@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// easyjson -all ./internal/models/cursor.go
+
+// Cursor — позиция в списке, с которой продолжается выдача. Клиенту уходит только
+// подписанной строкой (см. пакет cursor), поэтому поля можно менять без заботы о совместимости.
+type Cursor struct {
+	// Список, для которого выдан курсор: "threads:<forum>", "users:<forum>", "posts:<thread id>".
+	Scope string `json:"s"`
+	Sort  string `json:"o,omitempty"`
+	Desc  bool   `json:"d,omitempty"`
+	// Backward — страница перед позицией, а не после неё.
+	Backward bool      `json:"b,omitempty"`
+	ID       int       `json:"i,omitempty"`
+	Created  time.Time `json:"c,omitempty"`
+	Nickname string    `json:"n,omitempty"`
+	// Позиция в выдаче поиска: релевантность и вид найденного.
+	Rank float32 `json:"r,omitempty"`
+	Kind string  `json:"k,omitempty"`
+}
+
+// Page — курсоры соседних страниц; nil, если в эту сторону листать некуда.
+type Page struct {
+	Next *Cursor `json:"next_cursor,omitempty"`
+	Prev *Cursor `json:"prev_cursor,omitempty"`
+}
@@ -4,4 +4,6 @@ package models
 
 type ErrorResponse struct {
 	Message string `json:"message"`
+	Code    string `json:"code,omitempty"`
+	Field   string `json:"field,omitempty"`
 }
@@ -17,7 +17,7 @@ var (
 	_ easyjson.Marshaler
 )
 
-func easyjsonF772484dDecodeAwesomeProject1InternalModels(in *jlexer.Lexer, out *ErrorResponse) {
+func easyjsonF772484dDecodeGithubComBigBullasTPDBProjectInternalModels(in *jlexer.Lexer, out *ErrorResponse) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -38,6 +38,10 @@ func easyjsonF772484dDecodeAwesomeProject1InternalModels(in *jlexer.Lexer, out *
 		switch key {
 		case "message":
 			out.Message = string(in.String())
+		case "code":
+			out.Code = string(in.String())
+		case "field":
+			out.Field = string(in.String())
 		default:
 			in.SkipRecursive()
 		}
@@ -48,7 +52,7 @@ func easyjsonF772484dDecodeAwesomeProject1InternalModels(in *jlexer.Lexer, out *
 		in.Consumed()
 	}
 }
-func easyjsonF772484dEncodeAwesomeProject1InternalModels(out *jwriter.Writer, in ErrorResponse) {
+func easyjsonF772484dEncodeGithubComBigBullasTPDBProjectInternalModels(out *jwriter.Writer, in ErrorResponse) {
 	out.RawByte('{')
 	first := true
 	_ = first
@@ -57,29 +61,39 @@ func easyjsonF772484dEncodeAwesomeProject1InternalModels(out *jwriter.Writer, in
 		out.RawString(prefix[1:])
 		out.String(string(in.Message))
 	}
+	if in.Code != "" {
+		const prefix string = ",\"code\":"
+		out.RawString(prefix)
+		out.String(string(in.Code))
+	}
+	if in.Field != "" {
+		const prefix string = ",\"field\":"
+		out.RawString(prefix)
+		out.String(string(in.Field))
+	}
 	out.RawByte('}')
 }
 
 // MarshalJSON supports json.Marshaler interface
 func (v ErrorResponse) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
-	easyjsonF772484dEncodeAwesomeProject1InternalModels(&w, v)
+	easyjsonF772484dEncodeGithubComBigBullasTPDBProjectInternalModels(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
 func (v ErrorResponse) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjsonF772484dEncodeAwesomeProject1InternalModels(w, v)
+	easyjsonF772484dEncodeGithubComBigBullasTPDBProjectInternalModels(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
 func (v *ErrorResponse) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
-	easyjsonF772484dDecodeAwesomeProject1InternalModels(&r, v)
+	easyjsonF772484dDecodeGithubComBigBullasTPDBProjectInternalModels(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
 func (v *ErrorResponse) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjsonF772484dDecodeAwesomeProject1InternalModels(l, v)
+	easyjsonF772484dDecodeGithubComBigBullasTPDBProjectInternalModels(l, v)
 }
@@ -9,4 +9,6 @@ type Forum struct {
 	Slug    string `json:"slug"`
 	Posts   int    `json:"posts,omitempty"`
 	Threads int    `json:"threads,omitempty"`
+	// Конфигурация полнотекстового поиска Postgres (russian, english, ...); пусто — simple.
+	Language string `json:"language,omitempty"`
 }
@@ -46,6 +46,8 @@ func easyjsonC8d74561DecodeGithubComBigBullasTPDBProjectInternalModels(in *jlexe
 			out.Posts = int(in.Int())
 		case "threads":
 			out.Threads = int(in.Int())
+		case "language":
+			out.Language = string(in.String())
 		default:
 			in.SkipRecursive()
 		}
@@ -90,6 +92,11 @@ func easyjsonC8d74561EncodeGithubComBigBullasTPDBProjectInternalModels(out *jwri
 		out.RawString(prefix)
 		out.Int(int(in.Threads))
 	}
+	if in.Language != "" {
+		const prefix string = ",\"language\":"
+		out.RawString(prefix)
+		out.String(string(in.Language))
+	}
 	out.RawByte('}')
 }
 
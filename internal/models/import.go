@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// easyjson -all ./internal/models/import.go
+
+// ImportPost — пост, переносимый из другого движка форума. Parent указывает на уже существующий пост,
+// ParentRef — на пост из того же пакета по его Ref; родитель должен идти в пакете раньше потомка.
+type ImportPost struct {
+	Ref       string    `json:"ref,omitempty"`
+	ParentRef string    `json:"parent_ref,omitempty"`
+	Parent    int       `json:"parent,omitempty"`
+	Author    string    `json:"author"`
+	Message   string    `json:"message"`
+	Created   time.Time `json:"created,omitempty"`
+}
+
+type ImportResult struct {
+	Imported int            `json:"imported"`
+	Refs     map[string]int `json:"refs,omitempty"`
+}
@@ -2,9 +2,20 @@ package models
 
 // easyjson -all ./internal/models/info.go
 
+// Info — сводка /service/status. Без Exact users и vote оценены по статистике
+// Postgres и могут отставать от настоящих; форумы, ветки и посты берутся из счётчиков форумов.
 type Info struct {
-	Users   int64 `json:"user"`
-	Forums  int64 `json:"forum"`
-	Threads int64 `json:"thread"`
-	Posts   int64 `json:"post"`
+	Users   int64       `json:"user"`
+	Forums  int64       `json:"forum"`
+	Threads int64       `json:"thread"`
+	Posts   int64       `json:"post"`
+	Votes   int64       `json:"vote"`
+	Exact   bool        `json:"exact"`
+	ByForum []ForumInfo `json:"byForum"`
+}
+
+type ForumInfo struct {
+	Slug    string `json:"slug"`
+	Threads int64  `json:"thread"`
+	Posts   int64  `json:"post"`
 }
@@ -44,6 +44,33 @@ func easyjsonDdc53814DecodeGithubComBigBullasTPDBProjectInternalModels(in *jlexe
 			out.Threads = int64(in.Int64())
 		case "post":
 			out.Posts = int64(in.Int64())
+		case "vote":
+			out.Votes = int64(in.Int64())
+		case "exact":
+			out.Exact = bool(in.Bool())
+		case "byForum":
+			if in.IsNull() {
+				in.Skip()
+				out.ByForum = nil
+			} else {
+				in.Delim('[')
+				if out.ByForum == nil {
+					if !in.IsDelim(']') {
+						out.ByForum = make([]ForumInfo, 0, 2)
+					} else {
+						out.ByForum = []ForumInfo{}
+					}
+				} else {
+					out.ByForum = (out.ByForum)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v1 ForumInfo
+					(v1).UnmarshalEasyJSON(in)
+					out.ByForum = append(out.ByForum, v1)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
 		default:
 			in.SkipRecursive()
 		}
@@ -78,6 +105,32 @@ func easyjsonDdc53814EncodeGithubComBigBullasTPDBProjectInternalModels(out *jwri
 		out.RawString(prefix)
 		out.Int64(int64(in.Posts))
 	}
+	{
+		const prefix string = ",\"vote\":"
+		out.RawString(prefix)
+		out.Int64(int64(in.Votes))
+	}
+	{
+		const prefix string = ",\"exact\":"
+		out.RawString(prefix)
+		out.Bool(bool(in.Exact))
+	}
+	{
+		const prefix string = ",\"byForum\":"
+		out.RawString(prefix)
+		if in.ByForum == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+			out.RawString("null")
+		} else {
+			out.RawByte('[')
+			for v2, v3 := range in.ByForum {
+				if v2 > 0 {
+					out.RawByte(',')
+				}
+				(v3).MarshalEasyJSON(out)
+			}
+			out.RawByte(']')
+		}
+	}
 	out.RawByte('}')
 }
 
@@ -104,3 +157,83 @@ func (v *Info) UnmarshalJSON(data []byte) error {
 func (v *Info) UnmarshalEasyJSON(l *jlexer.Lexer) {
 	easyjsonDdc53814DecodeGithubComBigBullasTPDBProjectInternalModels(l, v)
 }
+func easyjsonDdc53814DecodeGithubComBigBullasTPDBProjectInternalModels1(in *jlexer.Lexer, out *ForumInfo) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "slug":
+			out.Slug = string(in.String())
+		case "thread":
+			out.Threads = int64(in.Int64())
+		case "post":
+			out.Posts = int64(in.Int64())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonDdc53814EncodeGithubComBigBullasTPDBProjectInternalModels1(out *jwriter.Writer, in ForumInfo) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"slug\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.Slug))
+	}
+	{
+		const prefix string = ",\"thread\":"
+		out.RawString(prefix)
+		out.Int64(int64(in.Threads))
+	}
+	{
+		const prefix string = ",\"post\":"
+		out.RawString(prefix)
+		out.Int64(int64(in.Posts))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v ForumInfo) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonDdc53814EncodeGithubComBigBullasTPDBProjectInternalModels1(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v ForumInfo) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonDdc53814EncodeGithubComBigBullasTPDBProjectInternalModels1(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *ForumInfo) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonDdc53814DecodeGithubComBigBullasTPDBProjectInternalModels1(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *ForumInfo) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonDdc53814DecodeGithubComBigBullasTPDBProjectInternalModels1(l, v)
+}
@@ -0,0 +1,39 @@
+package models
+
+// easyjson -all ./internal/models/live.go
+
+// LiveProtocolVersion — версия протокола /thread/{slug_or_id}/live.
+// Несовместимые изменения меняют её и подпротокол forum.live.v<N>.
+const LiveProtocolVersion = 2
+
+// Типы сообщений живого треда.
+const (
+	// Клиент -> сервер.
+	LivePost = "post"
+	LiveVote = "vote"
+	LivePing = "ping"
+	// Сервер -> клиент.
+	LiveHello   = "hello"
+	LiveAck     = "ack"
+	LiveError   = "error"
+	LivePong    = "pong"
+	LiveNewPost = "new_post"
+	LiveVotes   = "votes"
+	// Часть событий потеряна, состояние треда надо перечитать.
+	LiveReset = "reset"
+)
+
+// LiveMessage — единый конверт для обоих направлений.
+// ID задаёт клиент, сервер повторяет его в ack или error на этот запрос.
+// Event — ID события шины вида "<эпоха>-<номер>", его можно передать в last_event_id
+// при переподключении. В hello это последнее событие до подключения.
+type LiveMessage struct {
+	V      int            `json:"v,omitempty"`
+	Type   string         `json:"type"`
+	ID     string         `json:"id,omitempty"`
+	Event  string         `json:"event,omitempty"`
+	Posts  []Post         `json:"posts,omitempty"`
+	Vote   *Vote          `json:"vote,omitempty"`
+	Thread *Thread        `json:"thread,omitempty"`
+	Error  *ErrorResponse `json:"error,omitempty"`
+}
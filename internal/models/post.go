@@ -8,13 +8,15 @@ import (
 // easyjson -all ./internal/models/post.go
 
 type Post struct {
-	ID       int              `json:"id,omitempty"`
-	Parent   int              `json:"parent,omitempty"`
-	Author   string           `json:"author"`
-	Message  string           `json:"message"`
-	IsEdited bool             `json:"isEdited,omitempty"`
-	Forum    string           `json:"forum,omitempty"`
-	Thread   int              `json:"thread,omitempty"`
-	Created  time.Time        `json:"created,omitempty"`
-	Path     pgtype.Int4Array `json:"path,omitempty"`
+	ID       int    `json:"id,omitempty"`
+	Parent   int    `json:"parent,omitempty"`
+	Author   string `json:"author"`
+	Message  string `json:"message"`
+	IsEdited bool   `json:"isEdited,omitempty"`
+	// Удалённый пост остаётся в дереве, но без текста и автора.
+	IsDeleted bool             `json:"isDeleted,omitempty"`
+	Forum     string           `json:"forum,omitempty"`
+	Thread    int              `json:"thread,omitempty"`
+	Created   time.Time        `json:"created,omitempty"`
+	Path      pgtype.Int4Array `json:"path,omitempty"`
 }
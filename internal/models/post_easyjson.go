@@ -47,6 +47,8 @@ func easyjson5a72dc82DecodeGithubComBigBullasTPDBProjectInternalModels(in *jlexe
 			out.Message = string(in.String())
 		case "isEdited":
 			out.IsEdited = bool(in.Bool())
+		case "isDeleted":
+			out.IsDeleted = bool(in.Bool())
 		case "forum":
 			out.Forum = string(in.String())
 		case "thread":
@@ -107,6 +109,11 @@ func easyjson5a72dc82EncodeGithubComBigBullasTPDBProjectInternalModels(out *jwri
 		out.RawString(prefix)
 		out.Bool(bool(in.IsEdited))
 	}
+	if in.IsDeleted {
+		const prefix string = ",\"isDeleted\":"
+		out.RawString(prefix)
+		out.Bool(bool(in.IsDeleted))
+	}
 	if in.Forum != "" {
 		const prefix string = ",\"forum\":"
 		out.RawString(prefix)
@@ -8,4 +8,8 @@ type RequestParameters struct {
 	Since    string `json:"since"`
 	Sort     string `json:"sort"`
 	SinceInt int    `json:"sinceInt"`
+	// Для веток вместе с Since задаёт строгую позицию (created, id) вместо нестрогой по created.
+	SinceID int `json:"sinceId,omitempty"`
+	// Если задан, Since, SinceInt, SinceID, Sort и Desc берутся из него.
+	Cursor *Cursor `json:"cursor,omitempty"`
 }
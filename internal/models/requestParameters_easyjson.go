@@ -46,6 +46,18 @@ func easyjson410d6225DecodeGithubComBigBullasTPDBProjectInternalModels(in *jlexe
 			out.Sort = string(in.String())
 		case "sinceInt":
 			out.SinceInt = int(in.Int())
+		case "sinceId":
+			out.SinceID = int(in.Int())
+		case "cursor":
+			if in.IsNull() {
+				in.Skip()
+				out.Cursor = nil
+			} else {
+				if out.Cursor == nil {
+					out.Cursor = new(Cursor)
+				}
+				(*out.Cursor).UnmarshalEasyJSON(in)
+			}
 		default:
 			in.SkipRecursive()
 		}
@@ -85,6 +97,16 @@ func easyjson410d6225EncodeGithubComBigBullasTPDBProjectInternalModels(out *jwri
 		out.RawString(prefix)
 		out.Int(int(in.SinceInt))
 	}
+	if in.SinceID != 0 {
+		const prefix string = ",\"sinceId\":"
+		out.RawString(prefix)
+		out.Int(int(in.SinceID))
+	}
+	if in.Cursor != nil {
+		const prefix string = ",\"cursor\":"
+		out.RawString(prefix)
+		(*in.Cursor).MarshalEasyJSON(out)
+	}
 	out.RawByte('}')
 }
 
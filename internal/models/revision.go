@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// easyjson -all ./internal/models/revision.go
+
+// PostRevision — одна версия сообщения поста. Ревизия 1 — исходный текст от автора поста.
+type PostRevision struct {
+	Revision int       `json:"revision"`
+	Message  string    `json:"message"`
+	Editor   string    `json:"editor"`
+	Created  time.Time `json:"created"`
+}
+
+type PostDiff struct {
+	From int `json:"from"`
+	To   int `json:"to"`
+	// Diff — unified diff сообщения, пустой для одинаковых ревизий.
+	Diff string `json:"diff"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// easyjson -all ./internal/models/role.go
+
+// Роли по возрастанию прав. В хранилище лежат только moderator и banned:
+// admin задаётся конфигурацией, owner — поле Forum.User, остальные — member.
+const (
+	RoleBanned    = "banned"
+	RoleMember    = "member"
+	RoleModerator = "moderator"
+	RoleOwner     = "owner"
+	RoleAdmin     = "admin"
+)
+
+type ForumRole struct {
+	Forum     string    `json:"forum"`
+	Nickname  string    `json:"nickname"`
+	Role      string    `json:"role"`
+	GrantedBy string    `json:"grantedBy,omitempty"`
+	Granted   time.Time `json:"granted"`
+}
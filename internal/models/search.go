@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// easyjson -all ./internal/models/search.go
+
+const (
+	SearchKindPost   = "post"
+	SearchKindThread = "thread"
+)
+
+// SearchQuery — запрос в синтаксисе websearch_to_tsquery и фильтры; пустые поля не фильтруют.
+type SearchQuery struct {
+	Query  string    `json:"query"`
+	Forum  string    `json:"forum,omitempty"`
+	Thread int       `json:"thread,omitempty"`
+	Author string    `json:"author,omitempty"`
+	Kind   string    `json:"kind,omitempty"`
+	From   time.Time `json:"from,omitempty"`
+	To     time.Time `json:"to,omitempty"`
+	// Выдача идёт по убыванию релевантности после позиции After; Reverse разворачивает порядок.
+	After   *Cursor `json:"-"`
+	Reverse bool    `json:"-"`
+	Limit   int     `json:"-"`
+}
+
+// SearchHit — найденный пост или ветка. Snippet — готовый HTML: текст экранирован,
+// совпадения обёрнуты в <b></b>.
+type SearchHit struct {
+	Kind    string  `json:"kind"`
+	Rank    float32 `json:"rank"`
+	Snippet string  `json:"snippet"`
+	Post    *Post   `json:"post,omitempty"`
+	Thread  *Thread `json:"thread,omitempty"`
+}
@@ -8,4 +8,6 @@ type User struct {
 	FullName string `json:"fullname"`
 	About    string `json:"about,omitempty"`
 	Email    string `json:"email"`
+	// Password приходит только в запросах на создание и изменение профиля и никогда не отдаётся.
+	Password string `json:"password,omitempty"`
 }
@@ -17,7 +17,7 @@ var (
 	_ easyjson.Marshaler
 )
 
-func easyjson9e1087fdDecodeAwesomeProject1InternalModels(in *jlexer.Lexer, out *User) {
+func easyjson9e1087fdDecodeGithubComBigBullasTPDBProjectInternalModels(in *jlexer.Lexer, out *User) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -44,6 +44,8 @@ func easyjson9e1087fdDecodeAwesomeProject1InternalModels(in *jlexer.Lexer, out *
 			out.About = string(in.String())
 		case "email":
 			out.Email = string(in.String())
+		case "password":
+			out.Password = string(in.String())
 		default:
 			in.SkipRecursive()
 		}
@@ -54,7 +56,7 @@ func easyjson9e1087fdDecodeAwesomeProject1InternalModels(in *jlexer.Lexer, out *
 		in.Consumed()
 	}
 }
-func easyjson9e1087fdEncodeAwesomeProject1InternalModels(out *jwriter.Writer, in User) {
+func easyjson9e1087fdEncodeGithubComBigBullasTPDBProjectInternalModels(out *jwriter.Writer, in User) {
 	out.RawByte('{')
 	first := true
 	_ = first
@@ -88,29 +90,34 @@ func easyjson9e1087fdEncodeAwesomeProject1InternalModels(out *jwriter.Writer, in
 		out.RawString(prefix)
 		out.String(string(in.Email))
 	}
+	if in.Password != "" {
+		const prefix string = ",\"password\":"
+		out.RawString(prefix)
+		out.String(string(in.Password))
+	}
 	out.RawByte('}')
 }
 
 // MarshalJSON supports json.Marshaler interface
 func (v User) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
-	easyjson9e1087fdEncodeAwesomeProject1InternalModels(&w, v)
+	easyjson9e1087fdEncodeGithubComBigBullasTPDBProjectInternalModels(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
 func (v User) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjson9e1087fdEncodeAwesomeProject1InternalModels(w, v)
+	easyjson9e1087fdEncodeGithubComBigBullasTPDBProjectInternalModels(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
 func (v *User) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
-	easyjson9e1087fdDecodeAwesomeProject1InternalModels(&r, v)
+	easyjson9e1087fdDecodeGithubComBigBullasTPDBProjectInternalModels(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
 func (v *User) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjson9e1087fdDecodeAwesomeProject1InternalModels(l, v)
+	easyjson9e1087fdDecodeGithubComBigBullasTPDBProjectInternalModels(l, v)
 }
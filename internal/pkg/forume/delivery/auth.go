@@ -0,0 +1,112 @@
+package delivery
+
+import (
+	"errors"
+	"github.com/BigBullas/TP_DB_project/internal/auth"
+	"github.com/BigBullas/TP_DB_project/internal/errs"
+	"github.com/BigBullas/TP_DB_project/internal/models"
+	"github.com/mailru/easyjson"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SameSite=Lax не даёт чужим сайтам отправлять изменяющие запросы с cookie сессии.
+const sessionCookie = "forum_session"
+
+// Authenticate привязывает к контексту ник владельца токена из заголовка
+// Authorization: Bearer или из cookie сессии. Запрос без них идёт дальше анонимно.
+// Неверный Bearer-токен — 401, а устаревшая cookie просто стирается, чтобы не мешать входу.
+func (h *Handler) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if header := r.Header.Get("Authorization"); header != "" {
+			token, ok := strings.CutPrefix(header, "Bearer ")
+			if !ok {
+				h.fail(w, r, errs.New(errs.CodeUnauthorized, "unsupported authorization scheme"))
+				return
+			}
+			nickname, err := h.uc.Authenticate(r.Context(), token)
+			if err != nil {
+				h.fail(w, r, err)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(auth.WithCaller(r.Context(), nickname)))
+			return
+		}
+		if cookie, err := r.Cookie(sessionCookie); err == nil {
+			nickname, err := h.uc.Authenticate(r.Context(), cookie.Value)
+			if err == nil {
+				next.ServeHTTP(w, r.WithContext(auth.WithCaller(r.Context(), nickname)))
+				return
+			}
+			if !errors.Is(err, errs.Unauthorized) {
+				h.fail(w, r, err)
+				return
+			}
+			h.setSessionCookie(w, "", time.Unix(0, 0))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Login отвечает только cookie сессии: в теле токен не отдаётся, чтобы скрипты страницы его не видели.
+func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
+	var login models.Login
+	if err := easyjson.UnmarshalFromReader(r.Body, &login); err != nil {
+		h.fail(w, r, errs.New(errs.CodeBadRequest, "invalid login body"))
+		return
+	}
+	cred, err := h.uc.Login(r.Context(), login)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	h.setSessionCookie(w, cred.Token, cred.Expires)
+	cred.Token = ""
+	h.respond(w, r, http.StatusOK, cred)
+}
+
+// Logout отзывает токен, с которым пришёл запрос: Bearer или cookie сессии.
+func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
+	token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if cookie, err := r.Cookie(sessionCookie); err == nil && token == "" {
+		token = cookie.Value
+	}
+	if token == "" || auth.Caller(r.Context()) == "" {
+		h.fail(w, r, errs.New(errs.CodeUnauthorized, "not logged in"))
+		return
+	}
+	if err := h.uc.Logout(r.Context(), token); err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	h.setSessionCookie(w, "", time.Unix(0, 0))
+	h.respond(w, r, http.StatusOK, nil)
+}
+
+// CreateToken выдаёт API-токен для заголовка Authorization: Bearer. Токен показывается один раз.
+func (h *Handler) CreateToken(w http.ResponseWriter, r *http.Request) {
+	var req models.Credential
+	if err := easyjson.UnmarshalFromReader(r.Body, &req); err != nil {
+		h.fail(w, r, errs.New(errs.CodeBadRequest, "invalid token body"))
+		return
+	}
+	cred, err := h.uc.CreateToken(r.Context(), req.Name)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	h.respond(w, r, http.StatusCreated, cred)
+}
+
+func (h *Handler) setSessionCookie(w http.ResponseWriter, token string, expires time.Time) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    token,
+		Path:     "/",
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   h.cfg.Auth.SecureCookie,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
@@ -1,155 +1,203 @@
 package delivery
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/BigBullas/TP_DB_project/internal/config"
+	"github.com/BigBullas/TP_DB_project/internal/cursor"
+	"github.com/BigBullas/TP_DB_project/internal/errs"
+	"github.com/BigBullas/TP_DB_project/internal/events"
+	"github.com/BigBullas/TP_DB_project/internal/logging"
 	"github.com/BigBullas/TP_DB_project/internal/models"
 	User "github.com/BigBullas/TP_DB_project/internal/pkg/forume"
+	"github.com/BigBullas/TP_DB_project/internal/ratelimit"
+	"github.com/BigBullas/TP_DB_project/internal/tracing"
 	"github.com/BigBullas/TP_DB_project/internal/utils"
 	"github.com/gorilla/mux"
 	"github.com/mailru/easyjson"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type Handler struct {
-	uc User.UseCase
+	uc       User.UseCase
+	cursors  *cursor.Codec
+	bus      *events.Bus
+	cfg      config.Config
+	limiters map[string]*ratelimit.Limiter
+	log      *slog.Logger
+	// liveWriteWait — таймаут записи в /live; тесты его уменьшают.
+	liveWriteWait time.Duration
 }
 
-func NewForumHandler(useCase User.UseCase) *Handler {
-	return &Handler{uc: useCase}
+// log может быть nil, тогда обработчики ничего не пишут.
+func NewForumHandler(useCase User.UseCase, cursors *cursor.Codec, bus *events.Bus, cfg config.Config, log *slog.Logger) *Handler {
+	if log == nil {
+		log = logging.Discard()
+	}
+	return &Handler{uc: useCase, cursors: cursors, bus: bus, cfg: cfg, limiters: newLimiters(cfg.RateLimit), log: log, liveWriteWait: liveWriteWait}
+}
+
+// fail отвечает ошибкой в формате utils.ErrorBody. Причину внутренней ошибки клиент не видит, поэтому она пишется в лог.
+func (h *Handler) fail(w http.ResponseWriter, r *http.Request, err error) {
+	tracing.RecordError(trace.SpanFromContext(r.Context()), err)
+	if errs.CodeOf(err) == errs.CodeInternal {
+		h.log.ErrorContext(r.Context(), "request failed", "method", r.Method, "path", r.URL.Path, "err", err)
+	}
+	h.respond(w, r, errs.HTTPStatus(err), utils.ErrorBody(err))
+}
+
+// respond пишет ответ через utils.Response в отдельном спане: так в трассе видно,
+// что время ушло на сериализацию и отправку большого ответа, а не на запросы к базе.
+func (h *Handler) respond(w http.ResponseWriter, r *http.Request, status int, body interface{}) {
+	_, span := tracing.Start(r.Context(), "encode response", semconv.HTTPResponseStatusCode(status))
+	defer span.End()
+	utils.Response(w, status, body)
 }
 
 func (h *Handler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	nickname, flag := vars["nickname"]
 	if !flag {
-		utils.Response(w, http.StatusNotFound, nil, false)
+		h.respond(w, r, http.StatusNotFound, nil)
 		return
 	}
 
 	user := models.User{}
 	err := easyjson.UnmarshalFromReader(r.Body, &user)
 	if err != nil {
-		utils.Response(w, http.StatusInternalServerError, nil, false) // почему здесь StatusInternalServerError
+		h.fail(w, r, errs.New(errs.CodeBadRequest, "invalid user body"))
 		return
 	}
 	user.NickName = nickname
 
 	finalUser, err := h.uc.CreateUser(r.Context(), user)
-	if err == nil {
-		newUser := finalUser[0]
-		utils.Response(w, http.StatusCreated, newUser, false)
+	if errors.Is(err, errs.Conflict) && len(finalUser) > 0 {
+		h.respond(w, r, http.StatusConflict, finalUser)
 		return
 	}
-	utils.Response(w, http.StatusConflict, finalUser, false)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	h.respond(w, r, http.StatusCreated, finalUser[0])
 }
 
 func (h *Handler) GetUser(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	nickname, flag := vars["nickname"]
 	if !flag {
-		utils.Response(w, http.StatusNotFound, nil, false)
+		h.respond(w, r, http.StatusNotFound, nil)
 		return
 	}
 
 	foundUser, err := h.uc.GetUser(r.Context(), nickname)
-	if err == nil && foundUser == (models.User{}) {
-		utils.Response(w, http.StatusNotFound, nickname, false)
-		return
-	}
-	if err == nil {
-		utils.Response(w, http.StatusOK, foundUser, false)
+	if err != nil {
+		h.fail(w, r, err)
 		return
 	}
-	utils.Response(w, http.StatusNotFound, nickname, false)
+	h.respond(w, r, http.StatusOK, foundUser)
 }
 
 func (h *Handler) ChangeUserInfo(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	nickname, flag := vars["nickname"]
 	if !flag {
-		utils.Response(w, http.StatusBadRequest, nil, false)
+		h.respond(w, r, http.StatusBadRequest, nil)
 		return
 	}
 
 	user := models.User{}
 	err := easyjson.UnmarshalFromReader(r.Body, &user)
 	if err != nil {
-		utils.Response(w, http.StatusBadRequest, nil, false) // почему здесь StatusInternalServerError
+		h.fail(w, r, errs.New(errs.CodeBadRequest, "invalid user body"))
 		return
 	}
 	user.NickName = nickname
-	changedUser, status := h.uc.ChangeUserInfo(r.Context(), user)
-	utils.Response(w, status, changedUser, false)
+	changedUser, err := h.uc.ChangeUserInfo(r.Context(), user)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	h.respond(w, r, http.StatusOK, changedUser)
 }
 
 func (h *Handler) CreateForum(w http.ResponseWriter, r *http.Request) {
 	forum := models.Forum{}
 	err := easyjson.UnmarshalFromReader(r.Body, &forum)
 	if err != nil {
-		utils.Response(w, http.StatusBadRequest, nil, false)
+		h.fail(w, r, errs.New(errs.CodeBadRequest, "invalid forum body"))
 		return
 	}
 
-	createdForums, status := h.uc.CreateForum(r.Context(), forum)
-	if len(createdForums) > 0 {
-		utils.Response(w, status, createdForums[0], false)
+	createdForum, err := h.uc.CreateForum(r.Context(), forum)
+	if errors.Is(err, errs.Conflict) && createdForum.Slug != "" {
+		h.respond(w, r, http.StatusConflict, createdForum)
 		return
 	}
-	utils.Response(w, status, forum.Title, false)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	h.respond(w, r, http.StatusCreated, createdForum)
 }
 
 func (h *Handler) GetForumDetails(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	slug, flag := vars["slug"]
 	if !flag {
-		utils.Response(w, http.StatusBadRequest, nil, false)
+		h.respond(w, r, http.StatusBadRequest, nil)
 		return
 	}
 
 	foundForum, err := h.uc.GetForumDetails(r.Context(), slug)
-	if err == nil && foundForum == (models.Forum{}) {
-		utils.Response(w, http.StatusNotFound, slug, false)
-		return
-	}
-	if err == nil {
-		utils.Response(w, http.StatusOK, foundForum, false)
+	if err != nil {
+		h.fail(w, r, err)
 		return
 	}
-	utils.Response(w, http.StatusNotFound, slug, false)
+	h.respond(w, r, http.StatusOK, foundForum)
 }
 
 func (h *Handler) CreateThread(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	slug, flag := vars["slug"]
 	if !flag {
-		utils.Response(w, http.StatusBadRequest, nil, false)
+		h.respond(w, r, http.StatusBadRequest, nil)
 		return
 	}
 
 	thread := models.Thread{}
 	err := easyjson.UnmarshalFromReader(r.Body, &thread)
 	if err != nil {
-		utils.Response(w, http.StatusBadRequest, nil, false)
+		h.fail(w, r, errs.New(errs.CodeBadRequest, "invalid thread body"))
 		return
 	}
 	thread.Forum = slug
 
-	createdThreads, status := h.uc.CreateThread(r.Context(), thread)
-	if len(createdThreads) > 0 {
-		utils.Response(w, status, createdThreads[0], false)
+	createdThread, err := h.uc.CreateThread(r.Context(), thread)
+	if errors.Is(err, errs.Conflict) && createdThread.ID != 0 {
+		h.respond(w, r, http.StatusConflict, createdThread)
 		return
 	}
-	utils.Response(w, status, thread.Title, false)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	h.respond(w, r, http.StatusCreated, createdThread)
 }
 
 func (h *Handler) GetThreads(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	slug, flag := vars["slug"]
 	if !flag {
-		utils.Response(w, http.StatusBadRequest, nil, false)
+		h.respond(w, r, http.StatusBadRequest, nil)
 		return
 	}
 	limitInput := r.URL.Query().Get("limit")
@@ -162,7 +210,7 @@ func (h *Handler) GetThreads(w http.ResponseWriter, r *http.Request) {
 	} else {
 		limit, errLimit := strconv.Atoi(limitInput)
 		if errLimit != nil {
-			utils.Response(w, http.StatusNotFound, slug, false)
+			h.fail(w, r, errs.New(errs.CodeBadRequest, "invalid limit %q", limitInput).WithField("limit"))
 			return
 		}
 		params.Limit = limit
@@ -175,43 +223,41 @@ func (h *Handler) GetThreads(w http.ResponseWriter, r *http.Request) {
 	} else {
 		desc, errDesc := strconv.ParseBool(descInput)
 		if errDesc != nil {
-			utils.Response(w, http.StatusNotFound, slug, false)
+			h.fail(w, r, errs.New(errs.CodeBadRequest, "invalid desc %q", descInput).WithField("desc"))
 			return
 		}
 		params.Desc = desc
 	}
 
-	foundThreads, err := h.uc.GetThreads(r.Context(), slug, params)
-	if err == models.NotFound {
-		utils.Response(w, http.StatusNotFound, slug, false)
+	if err := h.parseCursor(r, &params); err != nil {
+		h.fail(w, r, err)
 		return
 	}
-	if err == nil && len(foundThreads) == 0 {
-		utils.Response(w, http.StatusOK, []models.Thread{}, false)
+
+	foundThreads, page, err := h.uc.GetThreads(r.Context(), slug, params)
+	if err != nil {
+		h.fail(w, r, err)
 		return
 	}
-	if err == nil {
-		utils.Response(w, http.StatusOK, foundThreads, false)
+	h.setPageLinks(w, r, page)
+	if len(foundThreads) == 0 {
+		h.respond(w, r, http.StatusOK, []models.Thread{})
 		return
 	}
-	utils.Response(w, http.StatusNotFound, slug, false)
+	h.respond(w, r, http.StatusOK, foundThreads)
 }
 
 func (h *Handler) CreatePosts(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	slugOrId, flag := vars["slug_or_id"]
 	if !flag {
-		utils.Response(w, http.StatusBadRequest, nil, false)
+		h.respond(w, r, http.StatusBadRequest, nil)
 		return
 	}
 
 	thisThread, errThread := h.uc.GetThreadBySlugOrId(r.Context(), slugOrId)
-	if errThread == models.InternalError {
-		utils.Response(w, http.StatusInternalServerError, nil, false)
-		return
-	}
-	if errThread == models.NotFound {
-		utils.Response(w, http.StatusNotFound, slugOrId, false)
+	if errThread != nil {
+		h.fail(w, r, errThread)
 		return
 	}
 
@@ -219,123 +265,127 @@ func (h *Handler) CreatePosts(w http.ResponseWriter, r *http.Request) {
 	decoder := json.NewDecoder(r.Body)
 	errDec := decoder.Decode(&posts)
 	if errDec != nil {
-		utils.Response(w, http.StatusBadRequest, nil, false)
+		h.fail(w, r, errs.New(errs.CodeBadRequest, "invalid posts body"))
 		return
 	}
 
 	if len(posts) == 0 {
-		utils.Response(w, http.StatusCreated, []models.Post{}, false)
+		h.respond(w, r, http.StatusCreated, []models.Post{})
 		return
 	}
 
-	createdPosts, status := h.uc.CreatePosts(r.Context(), posts, thisThread)
-	if status == http.StatusConflict {
-		utils.Response(w, status, slugOrId, true)
+	createdPosts, err := h.uc.CreatePosts(r.Context(), posts, thisThread)
+	if err != nil {
+		h.fail(w, r, err)
 		return
 	}
-	utils.Response(w, status, createdPosts, false)
+	h.respond(w, r, http.StatusCreated, createdPosts)
 }
 
-func (h *Handler) ChangeVote(w http.ResponseWriter, r *http.Request) {
+// ImportPosts принимает большие пакеты постов при переносе данных из других движков форума.
+func (h *Handler) ImportPosts(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	slugOrId, flag := vars["slug_or_id"]
 	if !flag {
-		utils.Response(w, http.StatusBadRequest, nil, false)
+		h.respond(w, r, http.StatusBadRequest, nil)
 		return
 	}
 
 	thisThread, errThread := h.uc.GetThreadBySlugOrId(r.Context(), slugOrId)
-	if errThread == models.InternalError {
-		utils.Response(w, http.StatusInternalServerError, nil, false)
+	if errThread != nil {
+		h.fail(w, r, errThread)
 		return
 	}
-	if errThread == models.NotFound {
-		utils.Response(w, http.StatusNotFound, slugOrId, false)
+
+	var posts []models.ImportPost
+	decoder := json.NewDecoder(r.Body)
+	errDec := decoder.Decode(&posts)
+	if errDec != nil {
+		h.fail(w, r, errs.New(errs.CodeBadRequest, "invalid posts body"))
 		return
 	}
 
-	vote := models.Vote{}
-	err := easyjson.UnmarshalFromReader(r.Body, &vote)
+	result, err := h.uc.ImportPosts(r.Context(), posts, thisThread)
 	if err != nil {
-		utils.Response(w, http.StatusBadRequest, nil, false)
+		h.fail(w, r, err)
 		return
 	}
-	vote.Thread = thisThread.ID
+	h.respond(w, r, http.StatusCreated, result)
+}
 
-	thisUser, err := h.uc.GetUser(r.Context(), vote.Nickname)
-	if err != nil {
-		utils.Response(w, http.StatusInternalServerError, nil, false)
+func (h *Handler) ChangeVote(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	slugOrId, flag := vars["slug_or_id"]
+	if !flag {
+		h.respond(w, r, http.StatusBadRequest, nil)
 		return
 	}
-	if thisUser == (models.User{}) {
-		utils.Response(w, http.StatusNotFound, thisUser, false)
+
+	thisThread, errThread := h.uc.GetThreadBySlugOrId(r.Context(), slugOrId)
+	if errThread != nil {
+		h.fail(w, r, errThread)
 		return
 	}
 
-	changedThread, err := h.uc.ChangeVote(r.Context(), vote, thisThread)
+	vote := models.Vote{}
+	err := easyjson.UnmarshalFromReader(r.Body, &vote)
 	if err != nil {
-		utils.Response(w, http.StatusInternalServerError, nil, false)
-		return
-	}
-	if changedThread != (models.Thread{}) {
-		utils.Response(w, http.StatusOK, changedThread, false)
+		h.fail(w, r, errs.New(errs.CodeBadRequest, "invalid vote body"))
 		return
 	}
-	finalThread, errThread := h.uc.GetThreadBySlugOrId(r.Context(), slugOrId)
-	if errThread == models.InternalError {
-		utils.Response(w, http.StatusInternalServerError, nil, false)
+	vote.Thread = thisThread.ID
+
+	changedThread, err := h.uc.ChangeVote(r.Context(), vote, thisThread)
+	if err != nil {
+		h.fail(w, r, err)
 		return
 	}
-	utils.Response(w, http.StatusOK, finalThread, false)
+	h.respond(w, r, http.StatusOK, changedThread)
 }
 
 func (h *Handler) GetThreadDetails(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	slugOrId, flag := vars["slug_or_id"]
 	if !flag {
-		utils.Response(w, http.StatusBadRequest, nil, false)
+		h.respond(w, r, http.StatusBadRequest, nil)
 		return
 	}
 
 	foundThread, errThread := h.uc.GetThreadBySlugOrId(r.Context(), slugOrId)
-	if errThread == models.InternalError {
-		utils.Response(w, http.StatusInternalServerError, nil, false)
+	if errThread != nil {
+		h.fail(w, r, errThread)
 		return
 	}
-	if errThread == models.NotFound {
-		utils.Response(w, http.StatusNotFound, slugOrId, false)
-		return
-	}
-	utils.Response(w, http.StatusOK, foundThread, false)
+	h.respond(w, r, http.StatusOK, foundThread)
 }
 
 func (h *Handler) ChangeThreadInfo(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	slugOrId, flag := vars["slug_or_id"]
 	if !flag {
-		utils.Response(w, http.StatusBadRequest, nil, false)
+		h.respond(w, r, http.StatusBadRequest, nil)
 		return
 	}
 
 	thread := models.Thread{}
 	err := easyjson.UnmarshalFromReader(r.Body, &thread)
 	if err != nil {
-		utils.Response(w, http.StatusBadRequest, nil, false)
+		h.fail(w, r, errs.New(errs.CodeBadRequest, "invalid thread body"))
 		return
 	}
 
 	foundThread, errThread := h.uc.GetThreadBySlugOrId(r.Context(), slugOrId)
-	if errThread == models.InternalError {
-		utils.Response(w, http.StatusInternalServerError, nil, false)
+	if errThread != nil {
+		h.fail(w, r, errThread)
 		return
 	}
-	if errThread == models.NotFound {
-		utils.Response(w, http.StatusNotFound, slugOrId, false)
+
+	changedThread, err := h.uc.ChangeThreadInfo(r.Context(), thread, foundThread)
+	if err != nil {
+		h.fail(w, r, err)
 		return
 	}
-
-	changedThread, status := h.uc.ChangeThreadInfo(r.Context(), thread, foundThread)
-	utils.Response(w, status, changedThread, false)
+	h.respond(w, r, http.StatusOK, changedThread)
 
 }
 
@@ -343,7 +393,7 @@ func (h *Handler) GetUsers(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	slug, flag := vars["slug"]
 	if !flag {
-		utils.Response(w, http.StatusBadRequest, nil, false)
+		h.respond(w, r, http.StatusBadRequest, nil)
 		return
 	}
 	limitInput := r.URL.Query().Get("limit")
@@ -356,7 +406,7 @@ func (h *Handler) GetUsers(w http.ResponseWriter, r *http.Request) {
 	} else {
 		limit, errLimit := strconv.Atoi(limitInput)
 		if errLimit != nil {
-			utils.Response(w, http.StatusNotFound, slug, false)
+			h.fail(w, r, errs.New(errs.CodeBadRequest, "invalid limit %q", limitInput).WithField("limit"))
 			return
 		}
 		params.Limit = limit
@@ -369,38 +419,40 @@ func (h *Handler) GetUsers(w http.ResponseWriter, r *http.Request) {
 	} else {
 		desc, errDesc := strconv.ParseBool(descInput)
 		if errDesc != nil {
-			utils.Response(w, http.StatusNotFound, slug, false)
+			h.fail(w, r, errs.New(errs.CodeBadRequest, "invalid desc %q", descInput).WithField("desc"))
 			return
 		}
 		params.Desc = desc
 	}
 
-	foundUsers, err := h.uc.GetUsers(r.Context(), slug, params)
-	if err == models.NotFound {
-		utils.Response(w, http.StatusNotFound, slug, false)
+	if err := h.parseCursor(r, &params); err != nil {
+		h.fail(w, r, err)
 		return
 	}
-	if err == nil && len(foundUsers) == 0 {
-		utils.Response(w, http.StatusOK, []models.User{}, false)
+
+	foundUsers, page, err := h.uc.GetUsers(r.Context(), slug, params)
+	if err != nil {
+		h.fail(w, r, err)
 		return
 	}
-	if err == nil {
-		utils.Response(w, http.StatusOK, foundUsers, false)
+	h.setPageLinks(w, r, page)
+	if len(foundUsers) == 0 {
+		h.respond(w, r, http.StatusOK, []models.User{})
 		return
 	}
-	utils.Response(w, http.StatusNotFound, slug, false)
+	h.respond(w, r, http.StatusOK, foundUsers)
 }
 
 func (h *Handler) GetPostDetails(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	sId, flag := vars["id"]
 	if !flag {
-		utils.Response(w, http.StatusBadRequest, nil, false)
+		h.respond(w, r, http.StatusBadRequest, nil)
 		return
 	}
 	id, err := strconv.Atoi(sId)
 	if err != nil {
-		utils.Response(w, http.StatusBadRequest, nil, false)
+		h.fail(w, r, errs.New(errs.CodeBadRequest, "invalid post id %q", sId).WithField("id"))
 		return
 	}
 
@@ -408,94 +460,181 @@ func (h *Handler) GetPostDetails(w http.ResponseWriter, r *http.Request) {
 	related := strings.Split(sRelated, ",")
 
 	foundPostDetailed, err := h.uc.GetPostDetails(r.Context(), id, related)
-
-	if err == nil && foundPostDetailed.Post.Author == "" {
-		utils.Response(w, http.StatusNotFound, id, false)
-		return
-	}
-	if err == nil && foundPostDetailed.Author.NickName == "" {
-		foundPostDetailed.Author = nil
-	}
-	if err == nil && foundPostDetailed.Forum.Slug == "" {
-		foundPostDetailed.Forum = nil
-	}
-	if err == nil && foundPostDetailed.Thread.Title == "" {
-		foundPostDetailed.Thread = nil
-	}
-	if err == nil {
-		utils.Response(w, http.StatusOK, foundPostDetailed, false)
+	if err != nil {
+		h.fail(w, r, err)
 		return
 	}
-	utils.Response(w, http.StatusNotFound, id, false)
+	h.respond(w, r, http.StatusOK, foundPostDetailed)
 }
 
 func (h *Handler) ChangePostInfo(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	sId, flag := vars["id"]
 	if !flag {
-		utils.Response(w, http.StatusBadRequest, nil, false)
+		h.respond(w, r, http.StatusBadRequest, nil)
 		return
 	}
 	id, err := strconv.Atoi(sId)
 	if err != nil {
-		utils.Response(w, http.StatusBadRequest, nil, false)
+		h.fail(w, r, errs.New(errs.CodeBadRequest, "invalid post id %q", sId).WithField("id"))
 		return
 	}
 
 	post := models.Post{}
 	err = easyjson.UnmarshalFromReader(r.Body, &post)
 	if err != nil {
-		utils.Response(w, http.StatusBadRequest, nil, false)
+		h.fail(w, r, errs.New(errs.CodeBadRequest, "invalid post body"))
 		return
 	}
 
 	foundPost, errPost := h.uc.GetPostDetails(r.Context(), id, []string{})
-	if errPost == models.InternalError {
-		utils.Response(w, http.StatusInternalServerError, nil, false)
+	if errPost != nil {
+		h.fail(w, r, errPost)
+		return
+	}
+
+	changedPost, err := h.uc.ChangePostInfo(r.Context(), post, foundPost.Post)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	h.respond(w, r, http.StatusOK, changedPost)
+}
+
+// DeletePost — DELETE /post/{id}/details: пост остаётся в дереве без текста и автора.
+func (h *Handler) DeletePost(w http.ResponseWriter, r *http.Request) {
+	h.setPostDeleted(w, r, h.uc.DeletePost)
+}
+
+// RestorePost — POST /post/{id}/restore, возвращает посту текст и автора.
+func (h *Handler) RestorePost(w http.ResponseWriter, r *http.Request) {
+	h.setPostDeleted(w, r, h.uc.RestorePost)
+}
+
+func (h *Handler) setPostDeleted(w http.ResponseWriter, r *http.Request, apply func(ctx context.Context, id int) (models.Post, error)) {
+	sId := mux.Vars(r)["id"]
+	id, err := strconv.Atoi(sId)
+	if err != nil {
+		h.fail(w, r, errs.New(errs.CodeBadRequest, "invalid post id %q", sId).WithField("id"))
+		return
+	}
+	post, err := apply(r.Context(), id)
+	if err != nil {
+		h.fail(w, r, err)
 		return
 	}
-	if errPost == models.NotFound {
-		utils.Response(w, http.StatusNotFound, id, false)
+	h.respond(w, r, http.StatusOK, post)
+}
+
+// GetPostHistory — GET /post/{id}/history: все ревизии сообщения, начиная с исходной.
+func (h *Handler) GetPostHistory(w http.ResponseWriter, r *http.Request) {
+	sId := mux.Vars(r)["id"]
+	id, err := strconv.Atoi(sId)
+	if err != nil {
+		h.fail(w, r, errs.New(errs.CodeBadRequest, "invalid post id %q", sId).WithField("id"))
 		return
 	}
-	if err == nil && foundPost.Post.Author == "" {
-		utils.Response(w, http.StatusNotFound, id, false)
+	revisions, err := h.uc.GetPostHistory(r.Context(), id)
+	if err != nil {
+		h.fail(w, r, err)
 		return
 	}
+	h.respond(w, r, http.StatusOK, revisions)
+}
 
-	changedPost, status := h.uc.ChangePostInfo(r.Context(), post, foundPost.Post)
-	utils.Response(w, status, changedPost, false)
+// GetPostDiff — GET /post/{id}/diff?from=&to=: unified diff между ревизиями, по умолчанию двумя последними.
+func (h *Handler) GetPostDiff(w http.ResponseWriter, r *http.Request) {
+	sId := mux.Vars(r)["id"]
+	id, err := strconv.Atoi(sId)
+	if err != nil {
+		h.fail(w, r, errs.New(errs.CodeBadRequest, "invalid post id %q", sId).WithField("id"))
+		return
+	}
+	bounds := map[string]int{"from": 0, "to": 0}
+	for name := range bounds {
+		input := r.URL.Query().Get(name)
+		if input == "" {
+			continue
+		}
+		n, err := strconv.Atoi(input)
+		if err != nil {
+			h.fail(w, r, errs.New(errs.CodeBadRequest, "invalid %s %q", name, input).WithField(name))
+			return
+		}
+		bounds[name] = n
+	}
+	postDiff, err := h.uc.GetPostDiff(r.Context(), id, bounds["from"], bounds["to"])
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	h.respond(w, r, http.StatusOK, postDiff)
 }
 
+// GetStatus — GET /service/status?exact=: по умолчанию приблизительная сводка,
+// exact=true пересчитывает строки. Умолчание меняет service.exact_status.
 func (h *Handler) GetStatus(w http.ResponseWriter, r *http.Request) {
-	info, status := h.uc.GetStatus(r.Context())
-	utils.Response(w, status, info, false)
+	exact := h.cfg.Service.ExactStatus
+	if input := r.URL.Query().Get("exact"); input != "" {
+		var err error
+		if exact, err = strconv.ParseBool(input); err != nil {
+			h.fail(w, r, errs.New(errs.CodeBadRequest, "invalid exact %q", input).WithField("exact"))
+			return
+		}
+	}
+	info, err := h.uc.GetStatus(r.Context(), exact)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	h.respond(w, r, http.StatusOK, info)
 }
 
+// Clear — POST /service/clear?forum=&dry_run=: удаляет всё или один форум и отвечает числом
+// удалённых строк. С dry_run=true только считает их. Работает, только если включён service.allow_clear.
 func (h *Handler) Clear(w http.ResponseWriter, r *http.Request) {
-	status := h.uc.Clear(r.Context())
-	utils.Response(w, status, nil, false)
+	if !h.cfg.Service.AllowClear {
+		h.fail(w, r, errs.New(errs.CodeForbidden, "clear is disabled by config"))
+		return
+	}
+	var dryRun bool
+	if input := r.URL.Query().Get("dry_run"); input != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(input); err != nil {
+			h.fail(w, r, errs.New(errs.CodeBadRequest, "invalid dry_run %q", input).WithField("dry_run"))
+			return
+		}
+	}
+	report, err := h.uc.Clear(r.Context(), r.URL.Query().Get("forum"), dryRun)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	h.respond(w, r, http.StatusOK, report)
+}
+
+// CheckConsistency: GET только сообщает о расхождениях счётчиков, POST ещё и исправляет их.
+func (h *Handler) CheckConsistency(w http.ResponseWriter, r *http.Request) {
+	report, err := h.uc.CheckConsistency(r.Context(), r.Method == http.MethodPost)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	h.respond(w, r, http.StatusOK, report)
 }
 
 func (h *Handler) GetPosts(w http.ResponseWriter, r *http.Request) {
-	fmt.Println("!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!")
 	vars := mux.Vars(r)
 	slugOrId, flag := vars["slug_or_id"]
 	if !flag {
-		utils.Response(w, http.StatusBadRequest, nil, false)
+		h.respond(w, r, http.StatusBadRequest, nil)
 		return
 	}
 	foundThread, errThread := h.uc.GetThreadBySlugOrId(r.Context(), slugOrId)
-	if errThread == models.InternalError {
-		fmt.Println("delivery get thread error ", errThread, foundThread)
-		utils.Response(w, http.StatusInternalServerError, nil, false)
-		return
-	}
-	if errThread == models.NotFound {
-		utils.Response(w, http.StatusNotFound, slugOrId, false)
+	if errThread != nil {
+		h.fail(w, r, errThread)
 		return
 	}
-	fmt.Println("delivery start ", foundThread)
 
 	limitInput := r.URL.Query().Get("limit")
 	sinceInput := r.URL.Query().Get("since")
@@ -507,12 +646,7 @@ func (h *Handler) GetPosts(w http.ResponseWriter, r *http.Request) {
 	if sortInput == "" {
 		params.Sort = "flat"
 	} else {
-		if sortInput == "tree" || sortInput == "parent_tree" || sortInput == "flat" {
-			params.Sort = sortInput
-		} else {
-			utils.Response(w, http.StatusNotFound, slugOrId, false)
-			return
-		}
+		params.Sort = sortInput
 	}
 
 	if limitInput == "" {
@@ -520,7 +654,7 @@ func (h *Handler) GetPosts(w http.ResponseWriter, r *http.Request) {
 	} else {
 		limit, errLimit := strconv.Atoi(limitInput)
 		if errLimit != nil {
-			utils.Response(w, http.StatusNotFound, slugOrId, false)
+			h.fail(w, r, errs.New(errs.CodeBadRequest, "invalid limit %q", limitInput).WithField("limit"))
 			return
 		}
 		params.Limit = limit
@@ -531,7 +665,7 @@ func (h *Handler) GetPosts(w http.ResponseWriter, r *http.Request) {
 	} else {
 		since, errSince := strconv.Atoi(sinceInput)
 		if errSince != nil {
-			utils.Response(w, http.StatusNotFound, slugOrId, false)
+			h.fail(w, r, errs.New(errs.CodeBadRequest, "invalid since %q", sinceInput).WithField("since"))
 			return
 		}
 		params.SinceInt = since
@@ -542,27 +676,121 @@ func (h *Handler) GetPosts(w http.ResponseWriter, r *http.Request) {
 	} else {
 		desc, errDesc := strconv.ParseBool(descInput)
 		if errDesc != nil {
-			utils.Response(w, http.StatusNotFound, slugOrId, false)
+			h.fail(w, r, errs.New(errs.CodeBadRequest, "invalid desc %q", descInput).WithField("desc"))
 			return
 		}
 		params.Desc = desc
 	}
 
-	fmt.Println("delivery params ", limitInput, descInput, sortInput, sinceInput)
-	fmt.Println("delivery params ", params)
+	if err := h.parseCursor(r, &params); err != nil {
+		h.fail(w, r, err)
+		return
+	}
 
-	foundPosts, err := h.uc.GetPosts(r.Context(), foundThread.ID, params)
-	if err == models.NotFound {
-		utils.Response(w, http.StatusNotFound, slugOrId, false)
+	foundPosts, page, err := h.uc.GetPosts(r.Context(), foundThread.ID, params)
+	if err != nil {
+		h.fail(w, r, err)
 		return
 	}
-	if err == nil && len(foundPosts) == 0 {
-		utils.Response(w, http.StatusOK, []models.Post{}, false)
+	h.setPageLinks(w, r, page)
+	if len(foundPosts) == 0 {
+		h.respond(w, r, http.StatusOK, []models.Post{})
 		return
 	}
-	if err == nil {
-		utils.Response(w, http.StatusOK, foundPosts, false)
+	h.respond(w, r, http.StatusOK, foundPosts)
+}
+
+// Search — GET /api/search?q=...&forum=&thread=&author=&type=post|thread&from=&to=&limit=&cursor=.
+// from и to в RFC 3339, to не включается; thread — slug или id.
+// snippet в ответе — экранированный HTML, его можно вставлять в страницу как есть.
+func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	query := models.SearchQuery{
+		Query:  values.Get("q"),
+		Forum:  values.Get("forum"),
+		Author: values.Get("author"),
+		Kind:   values.Get("type"),
+	}
+	if slugOrId := values.Get("thread"); slugOrId != "" {
+		thread, err := h.uc.GetThreadBySlugOrId(r.Context(), slugOrId)
+		if err != nil {
+			h.fail(w, r, err)
+			return
+		}
+		query.Thread = thread.ID
+	}
+	for _, bound := range []struct {
+		name string
+		dst  *time.Time
+	}{{"from", &query.From}, {"to", &query.To}} {
+		input := values.Get(bound.name)
+		if input == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339Nano, input)
+		if err != nil {
+			h.fail(w, r, errs.New(errs.CodeBadRequest, "invalid %s %q", bound.name, input).WithField(bound.name))
+			return
+		}
+		*bound.dst = t
+	}
+
+	params := models.RequestParameters{Limit: 20}
+	if limitInput := values.Get("limit"); limitInput != "" {
+		limit, errLimit := strconv.Atoi(limitInput)
+		if errLimit != nil || limit <= 0 {
+			h.fail(w, r, errs.New(errs.CodeBadRequest, "invalid limit %q", limitInput).WithField("limit"))
+			return
+		}
+		params.Limit = limit
+	}
+	if err := h.parseCursor(r, &params); err != nil {
+		h.fail(w, r, err)
 		return
 	}
-	utils.Response(w, http.StatusNotFound, slugOrId, false)
+
+	hits, page, err := h.uc.Search(r.Context(), query, params)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	h.setPageLinks(w, r, page)
+	h.respond(w, r, http.StatusOK, hits)
+}
+
+// parseCursor подставляет в params курсор из параметра cursor; since, sort и desc при этом не действуют.
+func (h *Handler) parseCursor(r *http.Request, params *models.RequestParameters) error {
+	raw := r.URL.Query().Get("cursor")
+	if raw == "" {
+		return nil
+	}
+	c, err := h.cursors.Decode(raw)
+	if err != nil {
+		return err
+	}
+	params.Cursor = &c
+	return nil
+}
+
+// setPageLinks отдаёт соседние страницы в заголовке Link (RFC 8288), тело ответа остаётся массивом.
+func (h *Handler) setPageLinks(w http.ResponseWriter, r *http.Request, page models.Page) {
+	var links []string
+	for _, l := range []struct {
+		rel    string
+		cursor *models.Cursor
+	}{{"next", page.Next}, {"prev", page.Prev}} {
+		if l.cursor == nil {
+			continue
+		}
+		// Остальные параметры (limit, фильтры поиска) сохраняются: курсор выдан под них.
+		query := r.URL.Query()
+		query.Del("since")
+		query.Del("sort")
+		query.Del("desc")
+		query.Set("cursor", h.cursors.Encode(*l.cursor))
+		links = append(links, fmt.Sprintf(`<%s?%s>; rel="%s"`, r.URL.Path, query.Encode(), l.rel))
+	}
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
 }
@@ -0,0 +1,172 @@
+package delivery
+
+import (
+	"context"
+	"github.com/BigBullas/TP_DB_project/internal/config"
+	"github.com/BigBullas/TP_DB_project/internal/cursor"
+	"github.com/BigBullas/TP_DB_project/internal/events"
+	"github.com/BigBullas/TP_DB_project/internal/models"
+	"github.com/BigBullas/TP_DB_project/internal/pkg/forume"
+	"github.com/BigBullas/TP_DB_project/internal/pkg/forume/repo"
+	"github.com/BigBullas/TP_DB_project/internal/pkg/forume/usecase"
+	"github.com/BigBullas/TP_DB_project/internal/tracing"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var ctx = context.Background()
+
+// testEnv — сервер с хранилищем в памяти: пользователь alice, форумы forum и other
+// с ветками thread и side.
+type testEnv struct {
+	srv    *httptest.Server
+	h      *Handler
+	uc     forume.UseCase
+	bus    *events.Bus
+	thread models.Thread
+	side   models.Thread
+}
+
+// testConfig — анонимный режим, как в Dockerfile: вход не обязателен, ограничение частоты выключено.
+func testConfig() config.Config {
+	cfg := config.Default()
+	cfg.Auth.Required = false
+	return cfg
+}
+
+func newTestEnv(t *testing.T, cfg config.Config) *testEnv {
+	t.Helper()
+	bus := events.New(cfg.Events.ReplaySize)
+	uc := usecase.NewRepoUseCase(repo.NewRepoMemory(), bus, cfg.Auth, nil)
+	h := NewForumHandler(uc, cursor.New([]byte("secret")), bus, cfg, nil)
+
+	router := mux.NewRouter()
+	router.Use(tracing.Middleware)
+	api := router.PathPrefix("/api").Subrouter()
+	api.Use(h.Authenticate, h.RateLimit)
+	api.HandleFunc("/forum/{slug}/events", h.ForumEvents).Methods(http.MethodGet)
+	api.HandleFunc("/thread/{slug_or_id}/create", h.CreatePosts).Methods(http.MethodPost)
+	api.HandleFunc("/thread/{slug_or_id}/vote", h.ChangeVote).Methods(http.MethodPost)
+	api.HandleFunc("/thread/{slug_or_id}/details", h.GetThreadDetails).Methods(http.MethodGet)
+	api.HandleFunc("/thread/{slug_or_id}/events", h.ThreadEvents).Methods(http.MethodGet)
+	api.HandleFunc("/thread/{slug_or_id}/live", h.ThreadLive).Methods(http.MethodGet)
+	api.HandleFunc("/service/clear", h.Clear).Methods(http.MethodPost)
+	api.HandleFunc("/service/consistency", h.CheckConsistency).Methods(http.MethodGet, http.MethodPost)
+
+	env := &testEnv{srv: httptest.NewServer(router), h: h, uc: uc, bus: bus}
+	t.Cleanup(func() {
+		bus.Close()
+		env.srv.Close()
+	})
+
+	if _, err := uc.CreateUser(ctx, models.User{NickName: "alice", FullName: "Alice", Email: "alice@mail.ru"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, slug := range []string{"forum", "other"} {
+		if _, err := uc.CreateForum(ctx, models.Forum{Title: slug, User: "alice", Slug: slug}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var err error
+	if env.thread, err = uc.CreateThread(ctx, models.Thread{Title: "t", Author: "alice", Forum: "forum", Message: "m", Slug: "thread"}); err != nil {
+		t.Fatal(err)
+	}
+	if env.side, err = uc.CreateThread(ctx, models.Thread{Title: "s", Author: "alice", Forum: "other", Message: "m", Slug: "side"}); err != nil {
+		t.Fatal(err)
+	}
+	return env
+}
+
+func (env *testEnv) post(t *testing.T, thread models.Thread, message string) models.Post {
+	t.Helper()
+	posts, err := env.uc.CreatePosts(ctx, []models.Post{{Author: "alice", Message: message}}, thread)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return posts[0]
+}
+
+func TestRespondSpan(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+	env := newTestEnv(t, testConfig())
+
+	for path, status := range map[string]int{
+		"/api/thread/thread/details": http.StatusOK,
+		"/api/thread/nope/details":   http.StatusNotFound,
+	} {
+		seen := len(rec.Ended())
+		resp, err := http.Get(env.srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		// Серверный span закрывается уже после того, как клиент получил ответ.
+		var server, encode sdktrace.ReadOnlySpan
+		for deadline := time.Now().Add(time.Second); server == nil && time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+			for _, span := range rec.Ended()[seen:] {
+				switch span.Name() {
+				case "GET /api/thread/{slug_or_id}/details":
+					server = span
+				case "encode response":
+					encode = span
+				}
+			}
+		}
+		if server == nil || encode == nil {
+			t.Fatalf("%s: no server or encode span", path)
+		}
+		if encode.Parent().SpanID() != server.SpanContext().SpanID() {
+			t.Errorf("%s: encode span is not a child of the server span", path)
+		}
+		for _, kv := range encode.Attributes() {
+			if kv.Key == "http.response.status_code" && kv.Value.AsInt64() != int64(status) {
+				t.Errorf("%s: encode span status %d, want %d", path, kv.Value.AsInt64(), status)
+			}
+		}
+	}
+}
+
+func TestConsistencyAnonymous(t *testing.T) {
+	env := newTestEnv(t, testConfig())
+
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		req, err := http.NewRequest(method, env.srv.URL+"/api/service/consistency", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("anonymous %s /service/consistency: %d, want 401", method, resp.StatusCode)
+		}
+	}
+}
+
+func TestClearDisabledByDefault(t *testing.T) {
+	// Даже в анонимном режиме clear работает, только если его включили явно.
+	env := newTestEnv(t, testConfig())
+	resp, err := http.Post(env.srv.URL+"/api/service/clear", "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("clear: %d, want 403", resp.StatusCode)
+	}
+	if info, err := env.uc.GetStatus(ctx, true); err != nil || info.Forums != 2 {
+		t.Errorf("status after refused clear: %+v, %v", info, err)
+	}
+}
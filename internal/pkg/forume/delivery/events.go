@@ -0,0 +1,91 @@
+package delivery
+
+import (
+	"fmt"
+	"github.com/BigBullas/TP_DB_project/internal/errs"
+	"github.com/BigBullas/TP_DB_project/internal/events"
+	"github.com/gorilla/mux"
+	"net/http"
+	"time"
+)
+
+// eventReset говорит клиенту, что часть событий потеряна и состояние надо перечитать.
+const eventReset = "reset"
+
+// Через сколько миллисекунд EventSource переподключается после обрыва.
+const eventRetry = 3000
+
+func (h *Handler) ThreadEvents(w http.ResponseWriter, r *http.Request) {
+	thread, err := h.uc.GetThreadBySlugOrId(r.Context(), mux.Vars(r)["slug_or_id"])
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	h.streamEvents(w, r, events.Filter{Thread: thread.ID})
+}
+
+func (h *Handler) ForumEvents(w http.ResponseWriter, r *http.Request) {
+	forum, err := h.uc.GetForumDetails(r.Context(), mux.Vars(r)["slug"])
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	h.streamEvents(w, r, events.Filter{Forum: forum.Slug})
+}
+
+// streamEvents отдаёт события в формате text/event-stream, начиная с пропущенных
+// после Last-Event-ID, пока клиент не отключится или шина не закроется.
+func (h *Handler) streamEvents(w http.ResponseWriter, r *http.Request, filter events.Filter) {
+	raw := r.Header.Get("Last-Event-ID")
+	lastID, err := events.ParseID(raw)
+	if err != nil {
+		h.fail(w, r, errs.New(errs.CodeBadRequest, "invalid Last-Event-ID %q", raw).WithField("Last-Event-ID"))
+		return
+	}
+
+	sub, missed, complete := h.bus.Subscribe(filter, lastID)
+	defer h.bus.Unsubscribe(sub)
+
+	rc := http.NewResponseController(w)
+	// Поток живёт дольше http.write_timeout.
+	_ = rc.SetWriteDeadline(time.Time{})
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", eventRetry)
+	if !complete {
+		fmt.Fprintf(w, "event: %s\ndata: {}\n\n", eventReset)
+	}
+	for _, e := range missed {
+		writeEvent(w, e)
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(h.cfg.Events.Heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			writeEvent(w, e)
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// Данные событий — однострочный JSON, так что хватает одного поля data.
+func writeEvent(w http.ResponseWriter, e events.Event) {
+	fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", e.ID, e.Type, e.Data)
+}
@@ -0,0 +1,227 @@
+package delivery
+
+import (
+	"bufio"
+	"encoding/json"
+	"github.com/BigBullas/TP_DB_project/internal/events"
+	"github.com/BigBullas/TP_DB_project/internal/models"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sseFrame — одно сообщение потока; комментарии попадают в comment.
+type sseFrame struct {
+	id, event, data, retry, comment string
+}
+
+type sseStream struct {
+	resp   *http.Response
+	frames chan sseFrame
+}
+
+func openEvents(t *testing.T, env *testEnv, path string, lastID string) *sseStream {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, env.srv.URL+"/api"+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lastID != "" {
+		req.Header.Set("Last-Event-ID", lastID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+
+	s := &sseStream{resp: resp, frames: make(chan sseFrame, 100)}
+	go func() {
+		defer close(s.frames)
+		var f sseFrame
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if line == "" {
+				s.frames <- f
+				f = sseFrame{}
+				continue
+			}
+			if strings.HasPrefix(line, ":") {
+				f.comment = strings.TrimSpace(line[1:])
+				continue
+			}
+			name, value, _ := strings.Cut(line, ": ")
+			switch name {
+			case "id":
+				f.id = value
+			case "event":
+				f.event = value
+			case "data":
+				f.data = value
+			case "retry":
+				f.retry = value
+			}
+		}
+	}()
+	return s
+}
+
+func (s *sseStream) next(t *testing.T) sseFrame {
+	t.Helper()
+	select {
+	case f, ok := <-s.frames:
+		if !ok {
+			t.Fatal("stream closed")
+		}
+		return f
+	case <-time.After(2 * time.Second):
+		t.Fatal("no event within 2s")
+	}
+	return sseFrame{}
+}
+
+// nextPost пропускает всё, кроме post.created, и возвращает пост из события.
+func (s *sseStream) nextPost(t *testing.T) (sseFrame, models.Post) {
+	t.Helper()
+	for {
+		f := s.next(t)
+		if f.event != events.PostCreated {
+			continue
+		}
+		var post models.Post
+		if err := json.Unmarshal([]byte(f.data), &post); err != nil {
+			t.Fatalf("bad post data %q: %v", f.data, err)
+		}
+		return f, post
+	}
+}
+
+func TestEventsHeaders(t *testing.T) {
+	env := newTestEnv(t, testConfig())
+	s := openEvents(t, env, "/forum/forum/events", "")
+
+	if s.resp.StatusCode != http.StatusOK {
+		t.Fatalf("status %d", s.resp.StatusCode)
+	}
+	for name, want := range map[string]string{
+		"Content-Type":      "text/event-stream",
+		"Cache-Control":     "no-cache",
+		"X-Accel-Buffering": "no",
+	} {
+		if got := s.resp.Header.Get(name); got != want {
+			t.Errorf("%s: %q, want %q", name, got, want)
+		}
+	}
+	if f := s.next(t); f.retry != strconv.Itoa(eventRetry) {
+		t.Errorf("first frame %+v, want retry", f)
+	}
+}
+
+func TestEventsHeartbeat(t *testing.T) {
+	cfg := testConfig()
+	cfg.Events.Heartbeat = 50 * time.Millisecond
+	env := newTestEnv(t, cfg)
+	s := openEvents(t, env, "/thread/thread/events", "")
+
+	s.next(t)
+	if f := s.next(t); f.comment != "ping" || f.event != "" {
+		t.Errorf("got %+v, want a ping comment", f)
+	}
+}
+
+func TestEventsFilters(t *testing.T) {
+	env := newTestEnv(t, testConfig())
+	forum := openEvents(t, env, "/forum/forum/events", "")
+	side := openEvents(t, env, "/thread/side/events", "")
+
+	a := env.post(t, env.side, "a")
+	b := env.post(t, env.thread, "b")
+	c := env.post(t, env.side, "c")
+
+	if _, post := forum.nextPost(t); post.ID != b.ID {
+		t.Errorf("forum stream got post %d from %s, want %d: other forums must be filtered out", post.ID, post.Forum, b.ID)
+	}
+	for _, want := range []models.Post{a, c} {
+		if _, post := side.nextPost(t); post.ID != want.ID {
+			t.Errorf("thread stream got post %d from thread %d, want %d", post.ID, post.Thread, want.ID)
+		}
+	}
+}
+
+func TestEventsReplay(t *testing.T) {
+	env := newTestEnv(t, testConfig())
+	s := openEvents(t, env, "/thread/thread/events", "")
+	env.post(t, env.thread, "first")
+	first, _ := s.nextPost(t)
+	second := env.post(t, env.thread, "second")
+	s.nextPost(t)
+	s.resp.Body.Close()
+
+	// Переподключение с Last-Event-ID догоняет пропущенное без reset.
+	env.post(t, env.side, "elsewhere")
+	again := openEvents(t, env, "/thread/thread/events", first.id)
+	if f := again.next(t); f.retry == "" {
+		t.Errorf("first frame %+v, want retry", f)
+	}
+	f, post := again.nextPost(t)
+	if post.ID != second.ID {
+		t.Errorf("replayed %+v, want post %d", f, second.ID)
+	}
+	if id, prev := mustID(t, f.id), mustID(t, first.id); id.Epoch != prev.Epoch || id.Seq <= prev.Seq {
+		t.Errorf("replayed event id %s is not after %s", f.id, first.id)
+	}
+	live := env.post(t, env.thread, "live")
+	if _, post := again.nextPost(t); post.ID != live.ID {
+		t.Errorf("after replay got post %d, want %d", post.ID, live.ID)
+	}
+}
+
+func TestEventsReplayGap(t *testing.T) {
+	cfg := testConfig()
+	cfg.Events.ReplaySize = 2
+	env := newTestEnv(t, cfg)
+	for i := 0; i < 5; i++ {
+		env.post(t, env.thread, "p")
+	}
+
+	s := openEvents(t, env, "/thread/thread/events", busEventID(env.bus, 1))
+	s.next(t)
+	if f := s.next(t); f.event != eventReset {
+		t.Errorf("got %+v, want reset when the buffer no longer holds the event", f)
+	}
+
+	// Номер из прошлого запуска меньше текущего, но события за ним другие.
+	foreign := openEvents(t, env, "/thread/thread/events", busEventID(events.New(1), 4))
+	foreign.next(t)
+	if f := foreign.next(t); f.event != eventReset {
+		t.Errorf("got %+v, want reset for an id issued by another process", f)
+	}
+
+	bad := openEvents(t, env, "/thread/thread/events", "yesterday")
+	if bad.resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("invalid Last-Event-ID: status %d", bad.resp.StatusCode)
+	}
+	missing := openEvents(t, env, "/forum/nope/events", "")
+	if missing.resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown forum: status %d", missing.resp.StatusCode)
+	}
+}
+
+func mustID(t *testing.T, raw string) events.ID {
+	t.Helper()
+	id, err := events.ParseID(raw)
+	if err != nil {
+		t.Fatalf("bad event id %q", raw)
+	}
+	return id
+}
+
+// busEventID — ID события seq, которое выдала бы шина b.
+func busEventID(b *events.Bus, seq uint64) string {
+	sub, _, _ := b.Subscribe(events.Filter{}, events.ID{})
+	b.Unsubscribe(sub)
+	return events.ID{Epoch: sub.Since.Epoch, Seq: seq}.String()
+}
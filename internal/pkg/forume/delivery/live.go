@@ -0,0 +1,233 @@
+package delivery
+
+import (
+	"context"
+	"github.com/BigBullas/TP_DB_project/internal/errs"
+	"github.com/BigBullas/TP_DB_project/internal/events"
+	"github.com/BigBullas/TP_DB_project/internal/models"
+	"github.com/BigBullas/TP_DB_project/internal/utils"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/mailru/easyjson"
+	"net/http"
+	"time"
+)
+
+// Протокол /thread/{slug_or_id}/live описан в models.LiveMessage.
+// После подключения сервер шлёт hello с тредом, затем new_post и votes по событиям шины.
+// Запросы клиента (post, vote, ping) обрабатываются по одному в порядке поступления,
+// на каждый приходит ack, pong или error с тем же id.
+
+const (
+	liveSubprotocol = "forum.live.v2"
+	// Ответы, которые ещё не ушли клиенту; пока очередь полна, новые запросы не читаются.
+	liveSendBuffer = 16
+	liveReadLimit  = 1 << 20
+	// Сколько ждать записи одного сообщения; клиент, который не читает дольше, отключается.
+	liveWriteWait = 10 * time.Second
+)
+
+var liveUpgrader = websocket.Upgrader{
+	Subprotocols: []string{liveSubprotocol},
+}
+
+type liveConn struct {
+	h      *Handler
+	conn   *websocket.Conn
+	thread models.Thread
+	// Ключ клиента для лимитов: сообщения post и vote тратят те же бюджеты, что и HTTP-запросы.
+	client string
+	send   chan models.LiveMessage
+	done   chan struct{}
+}
+
+// ThreadLive принимает last_event_id из прошлого подключения, чтобы получить пропущенные события.
+func (h *Handler) ThreadLive(w http.ResponseWriter, r *http.Request) {
+	thread, err := h.uc.GetThreadBySlugOrId(r.Context(), mux.Vars(r)["slug_or_id"])
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	raw := r.URL.Query().Get("last_event_id")
+	lastID, err := events.ParseID(raw)
+	if err != nil {
+		h.fail(w, r, errs.New(errs.CodeBadRequest, "invalid last_event_id %q", raw).WithField("last_event_id"))
+		return
+	}
+
+	// При ошибке Upgrade сам отвечает клиенту.
+	conn, err := liveUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	l := &liveConn{h: h, conn: conn, thread: thread, client: h.clientKey(r), send: make(chan models.LiveMessage, liveSendBuffer), done: make(chan struct{})}
+	l.run(r.Context(), lastID)
+}
+
+// run пишет в соединение из одной горутины, чтение идёт в отдельной.
+// Клиент, не ответивший на два пинга подряд, отключается.
+func (l *liveConn) run(ctx context.Context, lastID events.ID) {
+	defer l.conn.Close()
+	defer close(l.done)
+
+	sub, missed, complete := l.h.bus.Subscribe(events.Filter{Thread: l.thread.ID}, lastID)
+	defer l.h.bus.Unsubscribe(sub)
+
+	l.conn.SetReadLimit(liveReadLimit)
+	_ = l.conn.SetReadDeadline(time.Now().Add(2 * l.h.cfg.Events.Heartbeat))
+	l.conn.SetPongHandler(func(string) error {
+		return l.conn.SetReadDeadline(time.Now().Add(2 * l.h.cfg.Events.Heartbeat))
+	})
+
+	thread := l.thread
+	if !l.write(models.LiveMessage{V: models.LiveProtocolVersion, Type: models.LiveHello, Event: sub.Since.String(), Thread: &thread}) {
+		return
+	}
+	if !complete && !l.write(models.LiveMessage{Type: models.LiveReset}) {
+		return
+	}
+	for _, e := range missed {
+		if msg, ok := liveEvent(e); ok && !l.write(msg) {
+			return
+		}
+	}
+
+	readErr := make(chan error, 1)
+	go func() {
+		readErr <- l.read(ctx)
+	}()
+
+	ping := time.NewTicker(l.h.cfg.Events.Heartbeat)
+	defer ping.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			l.close(websocket.CloseGoingAway, "server is shutting down")
+			return
+		case <-readErr:
+			return
+		case msg := <-l.send:
+			if !l.write(msg) {
+				return
+			}
+		case e, ok := <-sub.C:
+			if !ok {
+				// Клиент не успевал читать или сервер останавливается: пусть переподключится с last_event_id.
+				l.close(websocket.CloseTryAgainLater, "event stream interrupted")
+				return
+			}
+			if msg, ok := liveEvent(e); ok && !l.write(msg) {
+				return
+			}
+		case <-ping.C:
+			if err := l.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(l.h.liveWriteWait)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (l *liveConn) read(ctx context.Context) error {
+	for {
+		_, data, err := l.conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		_ = l.conn.SetReadDeadline(time.Now().Add(2 * l.h.cfg.Events.Heartbeat))
+
+		var req models.LiveMessage
+		var resp models.LiveMessage
+		if err := easyjson.Unmarshal(data, &req); err != nil {
+			resp = liveError("", errs.New(errs.CodeBadRequest, "invalid message"))
+		} else {
+			resp = l.handle(ctx, req)
+		}
+		select {
+		case l.send <- resp:
+		case <-l.done:
+			return nil
+		}
+	}
+}
+
+func (l *liveConn) handle(ctx context.Context, req models.LiveMessage) models.LiveMessage {
+	if req.V != 0 && req.V != models.LiveProtocolVersion {
+		return liveError(req.ID, errs.New(errs.CodeBadRequest, "unsupported protocol version %d", req.V).WithField("v"))
+	}
+	switch req.Type {
+	case models.LivePing:
+		return models.LiveMessage{Type: models.LivePong, ID: req.ID}
+	case models.LivePost:
+		if len(req.Posts) == 0 {
+			return liveError(req.ID, errs.New(errs.CodeBadRequest, "no posts to create").WithField("posts"))
+		}
+		if _, err := l.h.limit(l.client, budgetPost); err != nil {
+			return l.fail(ctx, req.ID, err)
+		}
+		posts, err := l.h.uc.CreatePosts(ctx, req.Posts, l.thread)
+		if err != nil {
+			return l.fail(ctx, req.ID, err)
+		}
+		return models.LiveMessage{Type: models.LiveAck, ID: req.ID, Posts: posts}
+	case models.LiveVote:
+		if req.Vote == nil {
+			return liveError(req.ID, errs.New(errs.CodeBadRequest, "vote is required").WithField("vote"))
+		}
+		if _, err := l.h.limit(l.client, budgetVote); err != nil {
+			return l.fail(ctx, req.ID, err)
+		}
+		vote := *req.Vote
+		vote.Thread = l.thread.ID
+		thread, err := l.h.uc.ChangeVote(ctx, vote, l.thread)
+		if err != nil {
+			return l.fail(ctx, req.ID, err)
+		}
+		return models.LiveMessage{Type: models.LiveAck, ID: req.ID, Thread: &thread}
+	default:
+		return liveError(req.ID, errs.New(errs.CodeBadRequest, "unknown message type %q", req.Type).WithField("type"))
+	}
+}
+
+func (l *liveConn) write(msg models.LiveMessage) bool {
+	data, err := easyjson.Marshal(msg)
+	if err != nil {
+		return false
+	}
+	_ = l.conn.SetWriteDeadline(time.Now().Add(l.h.liveWriteWait))
+	return l.conn.WriteMessage(websocket.TextMessage, data) == nil
+}
+
+func (l *liveConn) close(code int, text string) {
+	_ = l.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, text), time.Now().Add(l.h.liveWriteWait))
+}
+
+func (l *liveConn) fail(ctx context.Context, id string, err error) models.LiveMessage {
+	if errs.CodeOf(err) == errs.CodeInternal {
+		l.h.log.ErrorContext(ctx, "live request failed", "thread", l.thread.ID, "err", err)
+	}
+	return liveError(id, err)
+}
+
+func liveError(id string, err error) models.LiveMessage {
+	body := utils.ErrorBody(err)
+	return models.LiveMessage{Type: models.LiveError, ID: id, Error: &body}
+}
+
+// liveEvent переводит событие шины в сообщение протокола; правки тредов и постов сюда не попадают.
+func liveEvent(e events.Event) (models.LiveMessage, bool) {
+	switch e.Type {
+	case events.PostCreated:
+		var post models.Post
+		if easyjson.Unmarshal(e.Data, &post) != nil {
+			return models.LiveMessage{}, false
+		}
+		return models.LiveMessage{Type: models.LiveNewPost, Event: e.ID.String(), Posts: []models.Post{post}}, true
+	case events.ThreadVoted:
+		var thread models.Thread
+		if easyjson.Unmarshal(e.Data, &thread) != nil {
+			return models.LiveMessage{}, false
+		}
+		return models.LiveMessage{Type: models.LiveVotes, Event: e.ID.String(), Thread: &thread}, true
+	}
+	return models.LiveMessage{}, false
+}
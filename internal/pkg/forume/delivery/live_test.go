@@ -0,0 +1,221 @@
+package delivery
+
+import (
+	"errors"
+	"github.com/BigBullas/TP_DB_project/internal/config"
+	"github.com/BigBullas/TP_DB_project/internal/errs"
+	"github.com/BigBullas/TP_DB_project/internal/events"
+	"github.com/BigBullas/TP_DB_project/internal/models"
+	"github.com/gorilla/websocket"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func dialLive(t *testing.T, env *testEnv, thread string) *websocket.Conn {
+	t.Helper()
+	dialer := websocket.Dialer{Subprotocols: []string{liveSubprotocol}}
+	url := "ws" + strings.TrimPrefix(env.srv.URL, "http") + "/api/thread/" + thread + "/live"
+	conn, resp, err := dialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("dial %s: %v", url, err)
+	}
+	if resp.Header.Get("Sec-WebSocket-Protocol") != liveSubprotocol {
+		t.Errorf("subprotocol %q", resp.Header.Get("Sec-WebSocket-Protocol"))
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func readLive(t *testing.T, conn *websocket.Conn) models.LiveMessage {
+	t.Helper()
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var msg models.LiveMessage
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	return msg
+}
+
+// readAckAndEvent читает ответ на запрос id и событие шины, которые могут прийти в любом порядке.
+func readAckAndEvent(t *testing.T, conn *websocket.Conn, id string, event string) (ack models.LiveMessage, e models.LiveMessage) {
+	t.Helper()
+	for ack.Type == "" || e.Type == "" {
+		msg := readLive(t, conn)
+		switch {
+		case msg.Type == models.LiveAck && msg.ID == id:
+			ack = msg
+		case msg.Type == event:
+			e = msg
+		default:
+			t.Fatalf("unexpected message %+v", msg)
+		}
+	}
+	return ack, e
+}
+
+func expectLiveError(t *testing.T, msg models.LiveMessage, id string, code errs.Code) {
+	t.Helper()
+	if msg.Type != models.LiveError || msg.ID != id || msg.Error == nil || msg.Error.Code != string(code) {
+		t.Errorf("got %+v (error %+v), want error %s for id %q", msg, msg.Error, code, id)
+	}
+}
+
+func TestLivePostAndVote(t *testing.T) {
+	env := newTestEnv(t, testConfig())
+	conn := dialLive(t, env, "thread")
+
+	hello := readLive(t, conn)
+	if hello.Type != models.LiveHello || hello.V != models.LiveProtocolVersion || hello.Thread == nil || hello.Thread.ID != env.thread.ID {
+		t.Fatalf("hello: %+v", hello)
+	}
+
+	if err := conn.WriteJSON(models.LiveMessage{Type: models.LivePost, ID: "1", Posts: []models.Post{{Author: "alice", Message: "hi"}}}); err != nil {
+		t.Fatal(err)
+	}
+	ack, e := readAckAndEvent(t, conn, "1", models.LiveNewPost)
+	if len(ack.Posts) != 1 || ack.Posts[0].ID == 0 || ack.Posts[0].Thread != env.thread.ID {
+		t.Errorf("post ack: %+v", ack.Posts)
+	}
+	if len(e.Posts) != 1 || e.Posts[0].Message != "hi" || e.Event == "" {
+		t.Errorf("new_post: %+v", e)
+	}
+
+	if err := conn.WriteJSON(models.LiveMessage{Type: models.LiveVote, ID: "2", Vote: &models.Vote{Nickname: "alice", Voice: 1}}); err != nil {
+		t.Fatal(err)
+	}
+	ack, e = readAckAndEvent(t, conn, "2", models.LiveVotes)
+	if ack.Thread == nil || ack.Thread.Votes != 1 {
+		t.Errorf("vote ack: %+v", ack.Thread)
+	}
+	if e.Thread == nil || e.Thread.Votes != 1 {
+		t.Errorf("votes event: %+v", e.Thread)
+	}
+
+	if err := conn.WriteJSON(models.LiveMessage{Type: models.LivePing, ID: "3"}); err != nil {
+		t.Fatal(err)
+	}
+	if msg := readLive(t, conn); msg.Type != models.LivePong || msg.ID != "3" {
+		t.Errorf("ping: %+v", msg)
+	}
+}
+
+func TestLiveErrors(t *testing.T) {
+	env := newTestEnv(t, testConfig())
+	conn := dialLive(t, env, "thread")
+	readLive(t, conn)
+
+	if err := conn.WriteMessage(websocket.TextMessage, []byte("{not json")); err != nil {
+		t.Fatal(err)
+	}
+	expectLiveError(t, readLive(t, conn), "", errs.CodeBadRequest)
+
+	for _, req := range []models.LiveMessage{
+		{Type: "shout", ID: "a"},
+		{Type: models.LivePost, ID: "b"},
+		{Type: models.LiveVote, ID: "c"},
+		{Type: models.LivePing, ID: "d", V: models.LiveProtocolVersion + 1},
+	} {
+		if err := conn.WriteJSON(req); err != nil {
+			t.Fatal(err)
+		}
+		expectLiveError(t, readLive(t, conn), req.ID, errs.CodeBadRequest)
+	}
+
+	// Ошибка сценария приходит тем же кадром, соединение остаётся открытым.
+	if err := conn.WriteJSON(models.LiveMessage{Type: models.LiveVote, ID: "e", Vote: &models.Vote{Nickname: "nobody", Voice: 1}}); err != nil {
+		t.Fatal(err)
+	}
+	expectLiveError(t, readLive(t, conn), "e", errs.CodeNotFound)
+	if err := conn.WriteJSON(models.LiveMessage{Type: models.LivePing, ID: "f"}); err != nil {
+		t.Fatal(err)
+	}
+	if msg := readLive(t, conn); msg.Type != models.LivePong {
+		t.Errorf("connection is unusable after an error: %+v", msg)
+	}
+}
+
+func TestLiveRateLimit(t *testing.T) {
+	cfg := testConfig()
+	cfg.RateLimit.Enabled = true
+	cfg.RateLimit.Budgets["post"] = config.Limit{Rate: 0.001, Burst: 1}
+	env := newTestEnv(t, cfg)
+	conn := dialLive(t, env, "thread")
+	readLive(t, conn)
+
+	post := models.LiveMessage{Type: models.LivePost, Posts: []models.Post{{Author: "alice", Message: "hi"}}}
+	post.ID = "1"
+	if err := conn.WriteJSON(post); err != nil {
+		t.Fatal(err)
+	}
+	readAckAndEvent(t, conn, "1", models.LiveNewPost)
+
+	post.ID = "2"
+	if err := conn.WriteJSON(post); err != nil {
+		t.Fatal(err)
+	}
+	expectLiveError(t, readLive(t, conn), "2", errs.CodeTooMany)
+
+	// У голосов свой бюджет.
+	if err := conn.WriteJSON(models.LiveMessage{Type: models.LiveVote, ID: "3", Vote: &models.Vote{Nickname: "alice", Voice: 1}}); err != nil {
+		t.Fatal(err)
+	}
+	readAckAndEvent(t, conn, "3", models.LiveVotes)
+}
+
+func TestLiveHeartbeat(t *testing.T) {
+	cfg := testConfig()
+	cfg.Events.Heartbeat = 50 * time.Millisecond
+	env := newTestEnv(t, cfg)
+	conn := dialLive(t, env, "thread")
+
+	pings := make(chan struct{}, 100)
+	conn.SetPingHandler(func(data string) error {
+		pings <- struct{}{}
+		return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+	})
+	readLive(t, conn)
+
+	// Пинги обрабатываются только во время чтения, а ответы на них продлевают соединение
+	// дольше двух интервалов.
+	_ = conn.SetReadDeadline(time.Now().Add(300 * time.Millisecond))
+	if _, _, err := conn.ReadMessage(); !isTimeout(err) {
+		t.Fatalf("connection closed while answering pings: %v", err)
+	}
+	if len(pings) < 3 {
+		t.Errorf("got %d pings in 300ms with a 50ms heartbeat", len(pings))
+	}
+}
+
+func TestLiveSlowClient(t *testing.T) {
+	env := newTestEnv(t, testConfig())
+	env.h.liveWriteWait = 200 * time.Millisecond
+	conn := dialLive(t, env, "thread")
+
+	// Клиент не читает: буферы сокета заполняются, запись на сервере упирается в таймаут,
+	// а шина отключает подписку.
+	big := models.Post{Author: "alice", Thread: env.thread.ID, Message: strings.Repeat("x", 32<<10)}
+	for i := 0; i < 2000; i++ {
+		env.bus.Publish(events.PostCreated, "forum", env.thread.ID, big)
+	}
+	time.Sleep(500 * time.Millisecond)
+
+	deadline := time.Now().Add(5 * time.Second)
+	_ = conn.SetReadDeadline(deadline)
+	for {
+		_, _, err := conn.ReadMessage()
+		if err == nil {
+			continue
+		}
+		if isTimeout(err) {
+			t.Fatal("slow client was not disconnected")
+		}
+		break
+	}
+}
+
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
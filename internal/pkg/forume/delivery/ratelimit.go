@@ -0,0 +1,99 @@
+package delivery
+
+import (
+	"github.com/BigBullas/TP_DB_project/internal/auth"
+	"github.com/BigBullas/TP_DB_project/internal/config"
+	"github.com/BigBullas/TP_DB_project/internal/errs"
+	"github.com/BigBullas/TP_DB_project/internal/ratelimit"
+	"github.com/gorilla/mux"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Бюджеты по умолчанию; остальные задаются в config.RateLimit.Routes.
+const (
+	budgetRead  = "read"
+	budgetWrite = "write"
+	budgetPost  = "post"
+	budgetVote  = "vote"
+)
+
+func newLimiters(cfg config.RateLimit) map[string]*ratelimit.Limiter {
+	if !cfg.Enabled {
+		return nil
+	}
+	limiters := make(map[string]*ratelimit.Limiter, len(cfg.Budgets))
+	for name, limit := range cfg.Budgets {
+		limiters[name] = ratelimit.New(limit.Rate, limit.Burst)
+	}
+	return limiters
+}
+
+// RateLimit отвечает 429 с Retry-After, когда клиент исчерпал бюджет маршрута.
+// Должен стоять после Authenticate: вошедшие клиенты считаются по нику, остальные по адресу.
+func (h *Handler) RateLimit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if retry, err := h.limit(h.clientKey(r), h.routeBudget(r)); err != nil {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retry.Seconds()))))
+			h.fail(w, r, err)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// limit списывает запрос с бюджета клиента. Если бюджет исчерпан, возвращает errs.TooMany
+// и время до следующей попытки.
+func (h *Handler) limit(client string, budget string) (time.Duration, error) {
+	limiter, ok := h.limiters[budget]
+	if !ok {
+		return 0, nil
+	}
+	if ok, retry := limiter.Allow(client); !ok {
+		return retry, errs.New(errs.CodeTooMany, "rate limit of %s requests exceeded, retry in %s", budget, retry.Round(time.Millisecond))
+	}
+	return 0, nil
+}
+
+func (h *Handler) routeBudget(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if tmpl, err := route.GetPathTemplate(); err == nil {
+			if budget, ok := h.cfg.RateLimit.Routes[r.Method+" "+tmpl]; ok {
+				return budget
+			}
+		}
+	}
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return budgetRead
+	}
+	return budgetWrite
+}
+
+// clientKey: у всех сессий и API-токенов пользователя общий бюджет.
+// Из X-Forwarded-For берётся последний адрес — его дописал наш прокси. Левые адреса
+// присылает сам клиент, по ним он мог бы получать новый бюджет на каждый запрос.
+func (h *Handler) clientKey(r *http.Request) string {
+	if caller := auth.Caller(r.Context()); caller != "" {
+		return "user:" + strings.ToLower(caller)
+	}
+	if h.cfg.RateLimit.TrustProxy {
+		if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+			last := forwarded[len(forwarded)-1]
+			if i := strings.LastIndexByte(last, ','); i >= 0 {
+				last = last[i+1:]
+			}
+			if last = strings.TrimSpace(last); last != "" {
+				return "ip:" + last
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
@@ -0,0 +1,109 @@
+package delivery
+
+import (
+	"encoding/json"
+	"github.com/BigBullas/TP_DB_project/internal/config"
+	"github.com/BigBullas/TP_DB_project/internal/errs"
+	"github.com/BigBullas/TP_DB_project/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func vote(t *testing.T, env *testEnv, forwarded ...string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, env.srv.URL+"/api/thread/thread/vote", strings.NewReader(`{"nickname":"alice","voice":1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range forwarded {
+		req.Header.Add("X-Forwarded-For", f)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestRateLimit(t *testing.T) {
+	cfg := testConfig()
+	cfg.RateLimit.Enabled = true
+	cfg.RateLimit.Budgets["vote"] = config.Limit{Rate: 0.5, Burst: 1}
+	env := newTestEnv(t, cfg)
+
+	if resp := vote(t, env); resp.StatusCode != http.StatusOK {
+		t.Fatalf("first vote: %d", resp.StatusCode)
+	}
+	resp := vote(t, env)
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("second vote: %d, want 429", resp.StatusCode)
+	}
+	if retry, err := strconv.Atoi(resp.Header.Get("Retry-After")); err != nil || retry < 1 || retry > 2 {
+		t.Errorf("Retry-After %q, want 1-2 seconds at 0.5 rps", resp.Header.Get("Retry-After"))
+	}
+	var body models.ErrorResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || body.Code != string(errs.CodeTooMany) {
+		t.Errorf("body %+v, %v", body, err)
+	}
+
+	// У чтения свой бюджет.
+	details, err := http.Get(env.srv.URL + "/api/thread/thread/details")
+	if err != nil {
+		t.Fatal(err)
+	}
+	details.Body.Close()
+	if details.StatusCode != http.StatusOK {
+		t.Errorf("details after the vote budget ran out: %d", details.StatusCode)
+	}
+}
+
+func TestRateLimitTrustProxy(t *testing.T) {
+	cfg := testConfig()
+	cfg.RateLimit.Enabled = true
+	cfg.RateLimit.TrustProxy = true
+	cfg.RateLimit.Budgets["vote"] = config.Limit{Rate: 0.001, Burst: 1}
+	env := newTestEnv(t, cfg)
+
+	if resp := vote(t, env, "1.1.1.1, 10.0.0.5"); resp.StatusCode != http.StatusOK {
+		t.Fatalf("first vote: %d", resp.StatusCode)
+	}
+	// Подставленный клиентом левый адрес не даёт нового бюджета.
+	if resp := vote(t, env, "2.2.2.2, 10.0.0.5"); resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("spoofed X-Forwarded-For: %d, want 429", resp.StatusCode)
+	}
+	if resp := vote(t, env, "2.2.2.2", "10.0.0.5"); resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("spoofed X-Forwarded-For in a separate header: %d, want 429", resp.StatusCode)
+	}
+	if resp := vote(t, env, "10.0.0.6"); resp.StatusCode != http.StatusOK {
+		t.Errorf("another client behind the proxy: %d", resp.StatusCode)
+	}
+}
+
+func TestClientKey(t *testing.T) {
+	for _, tc := range []struct {
+		trust     bool
+		forwarded []string
+		want      string
+	}{
+		{false, nil, "ip:192.0.2.1"},
+		{false, []string{"10.0.0.5"}, "ip:192.0.2.1"},
+		{true, nil, "ip:192.0.2.1"},
+		{true, []string{"10.0.0.5"}, "ip:10.0.0.5"},
+		{true, []string{"1.1.1.1, 10.0.0.5"}, "ip:10.0.0.5"},
+		{true, []string{"1.1.1.1", "10.0.0.5 "}, "ip:10.0.0.5"},
+		{true, []string{"1.1.1.1,"}, "ip:192.0.2.1"},
+	} {
+		h := &Handler{cfg: config.Config{RateLimit: config.RateLimit{TrustProxy: tc.trust}}}
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		for _, f := range tc.forwarded {
+			r.Header.Add("X-Forwarded-For", f)
+		}
+		if got := h.clientKey(r); got != tc.want {
+			t.Errorf("trust=%v %q: got %q, want %q", tc.trust, tc.forwarded, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package delivery
+
+import (
+	"github.com/BigBullas/TP_DB_project/internal/errs"
+	"github.com/BigBullas/TP_DB_project/internal/models"
+	"github.com/gorilla/mux"
+	"github.com/mailru/easyjson"
+	"net/http"
+)
+
+// ForumRoles возвращает обработчики списка, назначения и снятия role на
+// /forum/{slug}/moderators и /forum/{slug}/bans.
+func (h *Handler) ForumRoles(role string) (list, grant, revoke http.HandlerFunc) {
+	list = func(w http.ResponseWriter, r *http.Request) {
+		roles, err := h.uc.GetForumRoles(r.Context(), mux.Vars(r)["slug"], role)
+		if err != nil {
+			h.fail(w, r, err)
+			return
+		}
+		h.respond(w, r, http.StatusOK, roles)
+	}
+	grant = func(w http.ResponseWriter, r *http.Request) {
+		var req models.ForumRole
+		if err := easyjson.UnmarshalFromReader(r.Body, &req); err != nil {
+			h.fail(w, r, errs.New(errs.CodeBadRequest, "invalid role body"))
+			return
+		}
+		if req.Nickname == "" {
+			h.fail(w, r, errs.New(errs.CodeBadRequest, "nickname is required").WithField("nickname"))
+			return
+		}
+		granted, err := h.uc.GrantForumRole(r.Context(), mux.Vars(r)["slug"], req.Nickname, role)
+		if err != nil {
+			h.fail(w, r, err)
+			return
+		}
+		h.respond(w, r, http.StatusOK, granted)
+	}
+	revoke = func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		if err := h.uc.RevokeForumRole(r.Context(), vars["slug"], vars["nickname"], role); err != nil {
+			h.fail(w, r, err)
+			return
+		}
+		h.respond(w, r, http.StatusNoContent, nil)
+	}
+	return list, grant, revoke
+}
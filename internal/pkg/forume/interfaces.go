@@ -5,48 +5,97 @@ import (
 	"github.com/BigBullas/TP_DB_project/internal/models"
 )
 
+// Все методы возвращают ошибки из пакета errs; отсутствующая сущность — errs.NotFound.
 type Repository interface {
-	CreateUser(ctx context.Context, user models.User) error
+	// passwordHash записывается вместе с пользователем; пустой — пользователь без пароля.
+	CreateUser(ctx context.Context, user models.User, passwordHash string) error
 	CheckUserForUniq(ctx context.Context, user models.User) ([]models.User, error)
 	GetUser(ctx context.Context, nickname string) (models.User, error)
-	ChangeUserInfo(ctx context.Context, user models.User) (models.User, int)
-	CreateForum(ctx context.Context, forum models.Forum) ([]models.Forum, int)
-	CheckForumForUniq(ctx context.Context, forum models.Forum) ([]models.Forum, int)
+	ChangeUserInfo(ctx context.Context, user models.User) (models.User, error)
+	CreateForum(ctx context.Context, forum models.Forum) (models.Forum, error)
+	CheckForumForUniq(ctx context.Context, forum models.Forum) ([]models.Forum, error)
 	GetForumDetails(ctx context.Context, slug string) (models.Forum, error)
-	CreateThread(ctx context.Context, thread models.Thread) ([]models.Thread, int)
-	CheckThreadForUniq(ctx context.Context, thread models.Thread) ([]models.Thread, int)
+	CreateThread(ctx context.Context, thread models.Thread) (models.Thread, error)
+	CheckThreadForUniq(ctx context.Context, thread models.Thread) ([]models.Thread, error)
 	GetThreads(ctx context.Context, slug string, params models.RequestParameters) ([]models.Thread, error)
 	GetThreadBySlug(ctx context.Context, slug string) (models.Thread, error)
 	GetThreadById(ctx context.Context, id int) (models.Thread, error)
-	CreatePosts(ctx context.Context, posts []models.Post, thread models.Thread) ([]models.Post, int)
+	CreatePosts(ctx context.Context, posts []models.Post, thread models.Thread) ([]models.Post, error)
+	ImportPosts(ctx context.Context, posts []models.ImportPost, thread models.Thread) (models.ImportResult, error)
 	ChangeVote(ctx context.Context, vote models.Vote, thread models.Thread) (models.Thread, error)
-	ChangeThreadInfo(ctx context.Context, thread models.Thread) (models.Thread, int)
+	ChangeThreadInfo(ctx context.Context, thread models.Thread) (models.Thread, error)
 	GetUsers(ctx context.Context, slug string, params models.RequestParameters) ([]models.User, error)
 	GetPostDetails(ctx context.Context, id int, related []string) (models.PostDetailed, error)
-	ChangePostInfo(ctx context.Context, post models.Post) (models.Post, int)
-	GetStatus(ctx context.Context) (models.Info, int)
-	Clear(ctx context.Context) int
+	// ChangePostInfo сохраняет прежние версии сообщения, их отдаёт GetPostHistory.
+	ChangePostInfo(ctx context.Context, post models.Post, editor string) (models.Post, error)
+	GetPostHistory(ctx context.Context, id int) ([]models.PostRevision, error)
+	// Удалённые посты возвращаются с IsDeleted, без текста и автора.
+	DeletePost(ctx context.Context, id int) (models.Post, error)
+	RestorePost(ctx context.Context, id int) (models.Post, error)
+	// GetStatus с exact считает строки заново, без него — по счётчикам и статистике базы.
+	GetStatus(ctx context.Context, exact bool) (models.Info, error)
+	// Clear удаляет всё и возвращает, сколько строк было удалено.
+	Clear(ctx context.Context) (models.ClearReport, error)
+	// CountClear считает строки, которые удалит Clear (forum == "") или ClearForum.
+	CountClear(ctx context.Context, forum string) (models.ClearReport, error)
+	// ClearForum удаляет форум со всем содержимым, но не пользователей.
+	ClearForum(ctx context.Context, forum string) (models.ClearReport, error)
+	CheckConsistency(ctx context.Context, repair bool) (models.ConsistencyReport, error)
+	Search(ctx context.Context, query models.SearchQuery) ([]models.SearchHit, error)
 	GetPostsFlat(ctx context.Context, params models.RequestParameters, threadID int) ([]models.Post, error)
 	GetPostsTree(ctx context.Context, params models.RequestParameters, threadID int) ([]models.Post, error)
 	GetPostsParent(ctx context.Context, params models.RequestParameters, threadID int) ([]models.Post, error)
+	// Хранилище держит только хеши паролей и токенов; считает и сверяет их UseCase.
+	SetPassword(ctx context.Context, nickname string, hash string) error
+	GetPassword(ctx context.Context, nickname string) (string, error)
+	CreateCredential(ctx context.Context, cred models.Credential) error
+	GetCredential(ctx context.Context, hash string) (models.Credential, error)
+	DeleteCredential(ctx context.Context, hash string) error
+	// Назначенные роли в форуме: moderator и banned. Отсутствие роли — errs.NotFound.
+	SetForumRole(ctx context.Context, role models.ForumRole) (models.ForumRole, error)
+	GetForumRole(ctx context.Context, forum string, nickname string) (models.ForumRole, error)
+	GetForumRoles(ctx context.Context, forum string, role string) ([]models.ForumRole, error)
+	DeleteForumRole(ctx context.Context, forum string, nickname string, role string) error
 }
 
+// При errs.Conflict методы создания возвращают уже существующие сущности.
 type UseCase interface {
 	CreateUser(ctx context.Context, user models.User) ([]models.User, error)
 	GetUser(ctx context.Context, nickname string) (models.User, error)
-	ChangeUserInfo(ctx context.Context, user models.User) (models.User, int)
-	CreateForum(ctx context.Context, forum models.Forum) ([]models.Forum, int)
+	ChangeUserInfo(ctx context.Context, user models.User) (models.User, error)
+	CreateForum(ctx context.Context, forum models.Forum) (models.Forum, error)
 	GetForumDetails(ctx context.Context, slug string) (models.Forum, error)
-	CreateThread(ctx context.Context, thread models.Thread) ([]models.Thread, int)
-	GetThreads(ctx context.Context, slug string, params models.RequestParameters) ([]models.Thread, error)
+	CreateThread(ctx context.Context, thread models.Thread) (models.Thread, error)
+	// Списки возвращают курсоры соседних страниц; params.Cursor должен быть выдан тем же списком.
+	GetThreads(ctx context.Context, slug string, params models.RequestParameters) ([]models.Thread, models.Page, error)
 	GetThreadBySlugOrId(ctx context.Context, slugOrId string) (models.Thread, error)
-	CreatePosts(ctx context.Context, posts []models.Post, thread models.Thread) ([]models.Post, int)
+	CreatePosts(ctx context.Context, posts []models.Post, thread models.Thread) ([]models.Post, error)
+	ImportPosts(ctx context.Context, posts []models.ImportPost, thread models.Thread) (models.ImportResult, error)
 	ChangeVote(ctx context.Context, vote models.Vote, thread models.Thread) (models.Thread, error)
-	ChangeThreadInfo(ctx context.Context, newThread models.Thread, oldThread models.Thread) (models.Thread, int)
-	GetUsers(ctx context.Context, slug string, params models.RequestParameters) ([]models.User, error)
+	ChangeThreadInfo(ctx context.Context, newThread models.Thread, oldThread models.Thread) (models.Thread, error)
+	GetUsers(ctx context.Context, slug string, params models.RequestParameters) ([]models.User, models.Page, error)
 	GetPostDetails(ctx context.Context, id int, related []string) (models.PostDetailed, error)
-	ChangePostInfo(ctx context.Context, newPost models.Post, oldPost models.Post) (models.Post, int)
-	GetStatus(ctx context.Context) (models.Info, int)
-	Clear(ctx context.Context) int
-	GetPosts(ctx context.Context, idPost int, params models.RequestParameters) ([]models.Post, error)
+	ChangePostInfo(ctx context.Context, newPost models.Post, oldPost models.Post) (models.Post, error)
+	DeletePost(ctx context.Context, id int) (models.Post, error)
+	RestorePost(ctx context.Context, id int) (models.Post, error)
+	GetPostHistory(ctx context.Context, id int) ([]models.PostRevision, error)
+	// GetPostDiff сравнивает ревизии from и to; 0 означает предпоследнюю и последнюю.
+	GetPostDiff(ctx context.Context, id int, from int, to int) (models.PostDiff, error)
+	// GetStatus с exact считает строки заново, без него — по счётчикам и статистике базы.
+	GetStatus(ctx context.Context, exact bool) (models.Info, error)
+	// Clear удаляет всё или, если forum не пуст, один форум. При dryRun только считает строки.
+	Clear(ctx context.Context, forum string, dryRun bool) (models.ClearReport, error)
+	CheckConsistency(ctx context.Context, repair bool) (models.ConsistencyReport, error)
+	GetPosts(ctx context.Context, idPost int, params models.RequestParameters) ([]models.Post, models.Page, error)
+	Search(ctx context.Context, query models.SearchQuery, params models.RequestParameters) ([]models.SearchHit, models.Page, error)
+	// Login выдаёт сессию, CreateToken — API-токен вызывающему; Token в ответе больше нигде не хранится.
+	Login(ctx context.Context, login models.Login) (models.Credential, error)
+	CreateToken(ctx context.Context, name string) (models.Credential, error)
+	Logout(ctx context.Context, token string) error
+	// Authenticate возвращает ник владельца действующего токена или errs.Unauthorized.
+	Authenticate(ctx context.Context, token string) (string, error)
+	// Модераторов назначают владелец форума и администраторы, банят модераторы и выше.
+	GetForumRoles(ctx context.Context, slug string, role string) ([]models.ForumRole, error)
+	GrantForumRole(ctx context.Context, slug string, nickname string, role string) (models.ForumRole, error)
+	RevokeForumRole(ctx context.Context, slug string, nickname string, role string) error
 }
@@ -0,0 +1,72 @@
+package repo
+
+import (
+	"context"
+	"github.com/BigBullas/TP_DB_project/internal/errs"
+	"github.com/BigBullas/TP_DB_project/internal/models"
+	"github.com/jackc/pgx/v4"
+)
+
+func (r *repoPostgres) SetPassword(ctx context.Context, nickname string, hash string) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	// Ник берётся из users, чтобы в user_password он был записан в том же регистре.
+	const SetPassword = `INSERT INTO user_password(Nickname, Hash) SELECT Nickname, $2 FROM users WHERE Nickname = $1
+ON CONFLICT (Nickname) DO UPDATE SET Hash = EXCLUDED.Hash;`
+	tag, err := r.Conn.Exec(ctx, SetPassword, nickname, hash)
+	if err != nil {
+		return errs.Wrap(err, "set password of %s", nickname)
+	}
+	if tag.RowsAffected() == 0 {
+		return errs.New(errs.CodeNotFound, "Can't find user by nickname: %s", nickname)
+	}
+	return nil
+}
+
+func (r *repoPostgres) GetPassword(ctx context.Context, nickname string) (string, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	const GetPassword = `SELECT Hash FROM user_password WHERE Nickname = $1;`
+	var hash string
+	err := r.Conn.QueryRow(ctx, GetPassword, nickname).Scan(&hash)
+	if err == pgx.ErrNoRows {
+		return "", errs.New(errs.CodeNotFound, "user %s has no password", nickname)
+	}
+	return hash, errs.Wrap(err, "get password of %s", nickname)
+}
+
+func (r *repoPostgres) CreateCredential(ctx context.Context, cred models.Credential) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	const CreateCredential = `INSERT INTO credential(Hash, Kind, Nickname, Name, Created, Expires) VALUES ($1, $2, $3, $4, $5, $6);`
+	_, err := r.Conn.Exec(ctx, CreateCredential, cred.Hash, cred.Kind, cred.Nickname, cred.Name, cred.Created, cred.Expires)
+	return errs.Wrap(err, "create %s for %s", cred.Kind, cred.Nickname)
+}
+
+func (r *repoPostgres) GetCredential(ctx context.Context, hash string) (models.Credential, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	const GetCredential = `SELECT Kind, Nickname, Name, Created, Expires FROM credential WHERE Hash = $1;`
+	cred := models.Credential{Hash: hash}
+	err := r.Conn.QueryRow(ctx, GetCredential, hash).Scan(&cred.Kind, &cred.Nickname, &cred.Name, &cred.Created, &cred.Expires)
+	if err == pgx.ErrNoRows {
+		return models.Credential{}, errs.New(errs.CodeNotFound, "unknown credential")
+	}
+	if err != nil {
+		return models.Credential{}, errs.Wrap(err, "get credential")
+	}
+	return cred, nil
+}
+
+func (r *repoPostgres) DeleteCredential(ctx context.Context, hash string) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	const DeleteCredential = `DELETE FROM credential WHERE Hash = $1;`
+	_, err := r.Conn.Exec(ctx, DeleteCredential, hash)
+	return errs.Wrap(err, "delete credential")
+}
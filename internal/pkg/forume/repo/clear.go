@@ -0,0 +1,80 @@
+package repo
+
+import (
+	"context"
+	"github.com/BigBullas/TP_DB_project/internal/errs"
+	"github.com/BigBullas/TP_DB_project/internal/models"
+	"github.com/jackc/pgx/v4"
+)
+
+// CountClear считает строки, которые удалит Clear (forum == "") или ClearForum.
+func (r *repoPostgres) CountClear(ctx context.Context, forum string) (models.ClearReport, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var report models.ClearReport
+	err := r.inTx(ctx, func(tx pgx.Tx) error {
+		var err error
+		report, err = countClear(ctx, tx, forum)
+		return err
+	})
+	return report, err
+}
+
+// ClearForum удаляет форум вместе с ветками, постами, их ревизиями, голосами и ролями.
+// Пользователи остаются, даже если писали только в этот форум.
+func (r *repoPostgres) ClearForum(ctx context.Context, forum string) (models.ClearReport, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	// post_revisions, vote и users_forum ссылаются на посты, ветки и форум без ON DELETE CASCADE.
+	queries := []string{
+		`DELETE FROM post_revisions WHERE Post IN (SELECT Id FROM post WHERE Forum = $1);`,
+		`DELETE FROM vote WHERE Thread IN (SELECT Id FROM thread WHERE Forum = $1);`,
+		`DELETE FROM users_forum WHERE Slug = $1;`,
+		`DELETE FROM post WHERE Forum = $1;`,
+		`DELETE FROM thread WHERE Forum = $1;`,
+		`DELETE FROM forum WHERE Slug = $1;`,
+	}
+
+	var report models.ClearReport
+	err := r.inTx(ctx, func(tx pgx.Tx) error {
+		var err error
+		if report, err = countClear(ctx, tx, forum); err != nil {
+			return err
+		}
+		for _, query := range queries {
+			if _, err := tx.Exec(ctx, query, report.Forum); err != nil {
+				return errs.Wrap(err, "clear forum %s", forum)
+			}
+		}
+		return nil
+	})
+	return report, err
+}
+
+// countClear блокирует строку форума: новые ветки в нём ждут конца транзакции.
+func countClear(ctx context.Context, tx pgx.Tx, forum string) (models.ClearReport, error) {
+	const CountAll = `SELECT (SELECT count(*) FROM users), (SELECT count(*) FROM forum), (SELECT count(*) FROM thread),
+(SELECT count(*) FROM post), (SELECT count(*) FROM vote);`
+	const CountForum = `SELECT f.Slug, 0, 1, (SELECT count(*) FROM thread WHERE Forum = f.Slug),
+(SELECT count(*) FROM post WHERE Forum = f.Slug),
+(SELECT count(*) FROM vote WHERE Thread IN (SELECT Id FROM thread WHERE Forum = f.Slug))
+FROM forum f WHERE f.Slug = $1 FOR UPDATE;`
+
+	var report models.ClearReport
+	var err error
+	if forum == "" {
+		err = tx.QueryRow(ctx, CountAll).Scan(&report.Users, &report.Forums, &report.Threads, &report.Posts, &report.Votes)
+	} else {
+		err = tx.QueryRow(ctx, CountForum, forum).
+			Scan(&report.Forum, &report.Users, &report.Forums, &report.Threads, &report.Posts, &report.Votes)
+	}
+	if err == pgx.ErrNoRows {
+		return models.ClearReport{}, errs.New(errs.CodeNotFound, "Can't find forum with slug: %s", forum)
+	}
+	if err != nil {
+		return models.ClearReport{}, errs.Wrap(err, "count rows to clear")
+	}
+	return report, nil
+}
@@ -0,0 +1,119 @@
+package repo
+
+import (
+	"context"
+	"github.com/BigBullas/TP_DB_project/internal/errs"
+	"github.com/BigBullas/TP_DB_project/internal/models"
+	"github.com/jackc/pgx/v4"
+)
+
+// Авторы веток и постов каждого форума — то, что должно лежать в users_forum.
+const forumMembers = `WITH members AS (SELECT Forum AS Slug, Author AS Nickname FROM thread UNION SELECT Forum, Author FROM post) `
+
+// Каждый запрос возвращает расхождения в виде (forum, thread, nickname, stored, actual).
+var consistencyChecks = []struct {
+	kind  string
+	query string
+}{
+	{models.MismatchForumPosts, `SELECT f.Slug::text, 0, '', f.Posts::bigint, COALESCE(c.n, 0)
+FROM forum f LEFT JOIN (SELECT Forum, count(*) AS n FROM post WHERE NOT IsDeleted GROUP BY Forum) c ON c.Forum = f.Slug
+WHERE f.Posts IS DISTINCT FROM COALESCE(c.n, 0) ORDER BY f.Slug;`},
+	{models.MismatchForumThreads, `SELECT f.Slug::text, 0, '', f.Threads::bigint, COALESCE(c.n, 0)
+FROM forum f LEFT JOIN (SELECT Forum, count(*) AS n FROM thread GROUP BY Forum) c ON c.Forum = f.Slug
+WHERE f.Threads IS DISTINCT FROM COALESCE(c.n, 0) ORDER BY f.Slug;`},
+	{models.MismatchThreadVotes, `SELECT t.Forum::text, t.Id, '', t.Votes::bigint, COALESCE(v.s, 0)
+FROM thread t LEFT JOIN (SELECT Thread, sum(Voice) AS s FROM vote GROUP BY Thread) v ON v.Thread = t.Id
+WHERE t.Votes IS DISTINCT FROM COALESCE(v.s, 0) ORDER BY t.Id;`},
+	{models.MismatchForumUserMissing, forumMembers + `SELECT m.Slug::text, 0, m.Nickname::text, 0::bigint, 0::bigint
+FROM members m WHERE NOT EXISTS (SELECT 1 FROM users_forum uf WHERE uf.Slug = m.Slug AND uf.Nickname = m.Nickname)
+ORDER BY 1, 3;`},
+	{models.MismatchForumUserExtra, forumMembers + `SELECT uf.Slug::text, 0, uf.Nickname::text, 0::bigint, 0::bigint
+FROM users_forum uf WHERE NOT EXISTS (SELECT 1 FROM members m WHERE m.Slug = uf.Slug AND m.Nickname = uf.Nickname)
+ORDER BY 1, 3;`},
+	{models.MismatchForumUserOutdated, `SELECT uf.Slug::text, 0, uf.Nickname::text, 0::bigint, 0::bigint
+FROM users_forum uf JOIN users u ON u.Nickname = uf.Nickname
+WHERE (uf.FullName, uf.About, uf.Email) IS DISTINCT FROM (u.FullName, u.About, u.Email)
+ORDER BY 1, 3;`},
+}
+
+const repairConsistency = `UPDATE forum f SET Posts = c.n
+FROM (SELECT f2.Slug, count(p.Id) AS n FROM forum f2 LEFT JOIN post p ON p.Forum = f2.Slug AND NOT p.IsDeleted GROUP BY f2.Slug) c
+WHERE c.Slug = f.Slug AND f.Posts IS DISTINCT FROM c.n;
+
+UPDATE forum f SET Threads = c.n
+FROM (SELECT f2.Slug, count(t.Id) AS n FROM forum f2 LEFT JOIN thread t ON t.Forum = f2.Slug GROUP BY f2.Slug) c
+WHERE c.Slug = f.Slug AND f.Threads IS DISTINCT FROM c.n;
+
+UPDATE thread t SET Votes = v.s
+FROM (SELECT t2.Id, COALESCE(sum(vo.Voice), 0) AS s FROM thread t2 LEFT JOIN vote vo ON vo.Thread = t2.Id GROUP BY t2.Id) v
+WHERE v.Id = t.Id AND t.Votes IS DISTINCT FROM v.s;
+
+` + forumMembers + `DELETE FROM users_forum uf
+WHERE NOT EXISTS (SELECT 1 FROM members m WHERE m.Slug = uf.Slug AND m.Nickname = uf.Nickname);
+
+` + forumMembers + `INSERT INTO users_forum (Nickname, FullName, About, Email, Slug)
+SELECT u.Nickname, u.FullName, u.About, u.Email, m.Slug FROM members m JOIN users u ON u.Nickname = m.Nickname
+ON CONFLICT DO NOTHING;
+
+UPDATE users_forum uf SET FullName = u.FullName, About = u.About, Email = u.Email
+FROM users u
+WHERE u.Nickname = uf.Nickname AND (uf.FullName, uf.About, uf.Email) IS DISTINCT FROM (u.FullName, u.About, u.Email);`
+
+// CheckConsistency пересчитывает счётчики форумов, суммы голосов и users_forum по исходным таблицам.
+// Проверка идёт в снимке REPEATABLE READ; при repair исходные таблицы блокируются от записи,
+// и найденные расхождения исправляются в той же транзакции.
+func (r *repoPostgres) CheckConsistency(ctx context.Context, repair bool) (models.ConsistencyReport, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	opts := pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly}
+	if repair {
+		opts = pgx.TxOptions{}
+	}
+	tx, err := r.Conn.BeginTx(ctx, opts)
+	if err != nil {
+		return models.ConsistencyReport{}, errs.Wrap(err, "check consistency")
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if repair {
+		const Lock = `LOCK TABLE users, thread, post, vote IN SHARE MODE;`
+		if _, err := tx.Exec(ctx, Lock); err != nil {
+			return models.ConsistencyReport{}, errs.Wrap(err, "lock tables")
+		}
+	}
+
+	report := models.ConsistencyReport{Mismatches: []models.ConsistencyMismatch{}}
+	for _, check := range consistencyChecks {
+		rows, err := tx.Query(ctx, check.query)
+		if err != nil {
+			return models.ConsistencyReport{}, errs.Wrap(err, "check %s", check.kind)
+		}
+		for rows.Next() {
+			m := models.ConsistencyMismatch{Kind: check.kind}
+			if err := rows.Scan(&m.Forum, &m.Thread, &m.Nickname, &m.Stored, &m.Actual); err != nil {
+				rows.Close()
+				return models.ConsistencyReport{}, errs.Wrap(err, "check %s", check.kind)
+			}
+			report.Mismatches = append(report.Mismatches, m)
+		}
+		rows.Close()
+		if rows.Err() != nil {
+			return models.ConsistencyReport{}, errs.Wrap(rows.Err(), "check %s", check.kind)
+		}
+	}
+
+	if !repair || len(report.Mismatches) == 0 {
+		return report, nil
+	}
+	if _, err := tx.Exec(ctx, repairConsistency); err != nil {
+		return models.ConsistencyReport{}, errs.Wrap(err, "repair consistency")
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return models.ConsistencyReport{}, errs.Wrap(err, "repair consistency")
+	}
+	report.Repaired = true
+	return report, nil
+}
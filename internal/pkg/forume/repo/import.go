@@ -0,0 +1,200 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"github.com/BigBullas/TP_DB_project/internal/errs"
+	"github.com/BigBullas/TP_DB_project/internal/models"
+	"github.com/jackc/pgx/v4"
+	"time"
+)
+
+// importOrder проверяет пакет импорта и для каждого поста возвращает номер родителя внутри пакета,
+// -1 если родитель не из пакета.
+func importOrder(posts []models.ImportPost) ([]int, error) {
+	refs := make(map[string]int, len(posts))
+	parents := make([]int, len(posts))
+	for k, post := range posts {
+		parents[k] = -1
+		if post.Author == "" {
+			return nil, errs.New(errs.CodeBadRequest, "post author is required").WithField(fmt.Sprintf("posts[%d].author", k))
+		}
+		if post.ParentRef != "" {
+			if post.Parent != 0 {
+				return nil, errs.New(errs.CodeBadRequest, "parent and parent_ref are mutually exclusive").
+					WithField(fmt.Sprintf("posts[%d].parent_ref", k))
+			}
+			parent, ok := refs[post.ParentRef]
+			if !ok {
+				return nil, errs.New(errs.CodeBadRequest, "parent_ref %q must refer to an earlier post of the batch", post.ParentRef).
+					WithField(fmt.Sprintf("posts[%d].parent_ref", k))
+			}
+			parents[k] = parent
+		}
+		if post.Ref != "" {
+			if _, ok := refs[post.Ref]; ok {
+				return nil, errs.New(errs.CodeBadRequest, "duplicate ref %q", post.Ref).WithField(fmt.Sprintf("posts[%d].ref", k))
+			}
+			refs[post.Ref] = k
+		}
+	}
+	return parents, nil
+}
+
+// ImportPosts загружает пакет через COPY во временную таблицу и переносит его в post одним INSERT ... SELECT.
+// Построчные триггеры на время транзакции отключаются через forum.bulk_import, а пути, счётчик постов
+// форума и users_forum считаются для всего пакета сразу.
+func (r *repoPostgres) ImportPosts(ctx context.Context, posts []models.ImportPost, thread models.Thread) (models.ImportResult, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	parents, err := importOrder(posts)
+	if err != nil {
+		return models.ImportResult{}, err
+	}
+
+	tx, err := r.Conn.Begin(ctx)
+	if err != nil {
+		return models.ImportResult{}, errs.Wrap(err, "import posts")
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	const Prepare = `SELECT set_config('forum.bulk_import', 'on', true);
+CREATE TEMP TABLE import_post
+(
+    Ord        INT PRIMARY KEY,
+    Id         INT,
+    Ref        TEXT,
+    ParentOrd  INT,
+    Parent     INT,
+    Author     CITEXT,
+    Message    TEXT,
+    Created    TIMESTAMP WITH TIME ZONE
+) ON COMMIT DROP;`
+	if _, err := tx.Exec(ctx, Prepare); err != nil {
+		return models.ImportResult{}, errs.Wrap(err, "prepare import")
+	}
+
+	created := time.Now()
+	rows := make([][]interface{}, len(posts))
+	for k, post := range posts {
+		var parentOrd interface{}
+		if parents[k] >= 0 {
+			parentOrd = parents[k]
+		}
+		if post.Created.IsZero() {
+			post.Created = created
+		}
+		rows[k] = []interface{}{k, post.Ref, parentOrd, post.Parent, post.Author, post.Message, post.Created}
+	}
+	_, err = tx.CopyFrom(ctx, pgx.Identifier{"import_post"},
+		[]string{"ord", "ref", "parentord", "parent", "author", "message", "created"}, pgx.CopyFromRows(rows))
+	if err != nil {
+		return models.ImportResult{}, errs.Wrap(err, "copy import batch")
+	}
+
+	// Как и в CreatePosts, сначала блокируем авторов и родителей и только потом проверяем их:
+	// удалённые между проверкой и вставкой, они дали бы ошибку внешнего ключа вместо 404 и 409.
+	const Lock = `SELECT 1 FROM users WHERE Nickname IN (SELECT Author FROM import_post) FOR SHARE;
+SELECT 1 FROM post WHERE Id IN (SELECT Parent FROM import_post WHERE Parent <> 0) FOR SHARE;`
+	if _, err := tx.Exec(ctx, Lock); err != nil {
+		return models.ImportResult{}, errs.Wrap(err, "lock import authors and parents")
+	}
+
+	const MissingAuthor = `SELECT i.Ord, i.Author FROM import_post i
+WHERE NOT EXISTS (SELECT 1 FROM users u WHERE u.Nickname = i.Author) ORDER BY i.Ord LIMIT 1;`
+	var ord int
+	var author string
+	err = tx.QueryRow(ctx, MissingAuthor).Scan(&ord, &author)
+	if err == nil {
+		return models.ImportResult{}, errs.New(errs.CodeNotFound, "Can't find post author by nickname: %s", author).
+			WithField(fmt.Sprintf("posts[%d].author", ord))
+	}
+	if err != pgx.ErrNoRows {
+		return models.ImportResult{}, errs.Wrap(err, "check import authors")
+	}
+
+	const BadParent = `SELECT i.Ord, i.Parent, p.Id IS NOT NULL FROM import_post i LEFT JOIN post p ON p.Id = i.Parent
+WHERE i.Parent <> 0 AND (p.Id IS NULL OR p.Thread <> $1) ORDER BY i.Ord LIMIT 1;`
+	var parent int
+	var exists bool
+	err = tx.QueryRow(ctx, BadParent, thread.ID).Scan(&ord, &parent, &exists)
+	if err == nil {
+		if exists {
+			return models.ImportResult{}, errs.New(errs.CodeConflict, "Parent post %d was created in another thread", parent).
+				WithField(fmt.Sprintf("posts[%d].parent", ord))
+		}
+		return models.ImportResult{}, errs.New(errs.CodeConflict, "Parent post %d does not exist", parent).
+			WithField(fmt.Sprintf("posts[%d].parent", ord))
+	}
+	if err != pgx.ErrNoRows {
+		return models.ImportResult{}, errs.Wrap(err, "check import parents")
+	}
+
+	// Id раздаются заранее в порядке пакета, чтобы пути потомков можно было посчитать до вставки.
+	const AssignIds = `UPDATE import_post i SET Id = s.Id
+FROM (SELECT Ord, nextval(pg_get_serial_sequence('post', 'id'))::INT AS Id FROM import_post ORDER BY Ord) s
+WHERE i.Ord = s.Ord;`
+	if _, err := tx.Exec(ctx, AssignIds); err != nil {
+		return models.ImportResult{}, errs.Wrap(err, "assign imported post ids")
+	}
+
+	const Insert = `WITH RECURSIVE tree AS (
+    SELECT i.Ord, CASE WHEN i.Parent = 0 THEN ARRAY[i.Id] ELSE p.Path || i.Id END AS Path
+    FROM import_post i LEFT JOIN post p ON p.Id = i.Parent
+    WHERE i.ParentOrd IS NULL
+    UNION ALL
+    SELECT c.Ord, t.Path || c.Id
+    FROM import_post c JOIN tree t ON c.ParentOrd = t.Ord
+)
+INSERT INTO post (Id, Author, Created, Forum, IsEdited, Message, Parent, Thread, Path)
+SELECT i.Id, i.Author, i.Created, $1, false, i.Message, COALESCE(po.Id, i.Parent), $2, t.Path
+FROM import_post i
+JOIN tree t ON t.Ord = i.Ord
+LEFT JOIN import_post po ON po.Ord = i.ParentOrd
+ORDER BY i.Ord;`
+	if _, err := tx.Exec(ctx, Insert, thread.Forum, thread.ID); err != nil {
+		return models.ImportResult{}, errs.Wrap(err, "insert imported posts")
+	}
+
+	const Counters = `UPDATE forum SET Posts = Posts + $2 WHERE Slug = $1;`
+	if _, err := tx.Exec(ctx, Counters, thread.Forum, len(posts)); err != nil {
+		return models.ImportResult{}, errs.Wrap(err, "update forum counters")
+	}
+	const ForumUsers = `INSERT INTO users_forum (Nickname, FullName, About, Email, Slug)
+SELECT u.Nickname, u.FullName, u.About, u.Email, $1 FROM users u
+WHERE u.Nickname IN (SELECT Author FROM import_post)
+ON CONFLICT DO NOTHING;`
+	if _, err := tx.Exec(ctx, ForumUsers, thread.Forum); err != nil {
+		return models.ImportResult{}, errs.Wrap(err, "update forum users")
+	}
+
+	result := models.ImportResult{Imported: len(posts)}
+	refRows, err := tx.Query(ctx, `SELECT Ref, Id FROM import_post WHERE Ref <> '';`)
+	if err != nil {
+		return models.ImportResult{}, errs.Wrap(err, "read import refs")
+	}
+	for refRows.Next() {
+		var ref string
+		var id int
+		if err := refRows.Scan(&ref, &id); err != nil {
+			refRows.Close()
+			return models.ImportResult{}, errs.Wrap(err, "read import refs")
+		}
+		if result.Refs == nil {
+			result.Refs = make(map[string]int)
+		}
+		result.Refs[ref] = id
+	}
+	refRows.Close()
+	if refRows.Err() != nil {
+		return models.ImportResult{}, errs.Wrap(refRows.Err(), "read import refs")
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return models.ImportResult{}, errs.Wrap(err, "import posts")
+	}
+	return result, nil
+}
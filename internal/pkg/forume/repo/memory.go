@@ -0,0 +1,1179 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"github.com/BigBullas/TP_DB_project/internal/errs"
+	"github.com/BigBullas/TP_DB_project/internal/models"
+	"github.com/BigBullas/TP_DB_project/internal/pkg/forume"
+	"github.com/jackc/pgtype"
+	"html"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// repoMemory хранит все данные в памяти и повторяет поведение repoPostgres вместе с триггерами из
+// db/migrations: пути постов, счётчики Posts/Threads у форума, users_forum и сумму голосов в ветке.
+// Строки CITEXT сравниваются без учёта регистра через ключ strings.ToLower.
+type repoMemory struct {
+	mu sync.RWMutex
+
+	users      map[string]*models.User
+	userOrder  []string
+	emails     map[string]string
+	forums     map[string]*models.Forum
+	forumUsers map[string]map[string]struct{}
+
+	threads      map[int]*models.Thread
+	threadSlugs  map[string]int
+	threadPosts  map[int][]int
+	lastThreadID int
+
+	posts      map[int]*memoryPost
+	lastPostID int
+	revisions  map[int][]models.PostRevision
+
+	votes map[memoryVoteKey]int
+
+	passwords   map[string]string
+	credentials map[string]models.Credential
+	// Роли по форуму и нику, оба ключа через key.
+	roles map[string]map[string]models.ForumRole
+}
+
+type memoryPost struct {
+	post models.Post
+	path []int32
+}
+
+type memoryVoteKey struct {
+	nickname string
+	thread   int
+}
+
+func NewRepoMemory() forume.Repository {
+	r := &repoMemory{}
+	r.reset()
+	return r
+}
+
+func key(s string) string {
+	return strings.ToLower(s)
+}
+
+func (r *repoMemory) reset() {
+	r.users = make(map[string]*models.User)
+	r.userOrder = nil
+	r.emails = make(map[string]string)
+	r.forums = make(map[string]*models.Forum)
+	r.forumUsers = make(map[string]map[string]struct{})
+	r.threads = make(map[int]*models.Thread)
+	r.threadSlugs = make(map[string]int)
+	r.threadPosts = make(map[int][]int)
+	r.posts = make(map[int]*memoryPost)
+	r.revisions = make(map[int][]models.PostRevision)
+	r.votes = make(map[memoryVoteKey]int)
+	r.passwords = make(map[string]string)
+	r.credentials = make(map[string]models.Credential)
+	r.roles = make(map[string]map[string]models.ForumRole)
+}
+
+// addForumUser повторяет триггеры PostUpdateUserForum и ThreadUpdateUserForum.
+func (r *repoMemory) addForumUser(forumSlug string, nickname string) {
+	members, ok := r.forumUsers[key(forumSlug)]
+	if !ok {
+		members = make(map[string]struct{})
+		r.forumUsers[key(forumSlug)] = members
+	}
+	members[key(nickname)] = struct{}{}
+}
+
+func (r *repoMemory) CreateUser(ctx context.Context, user models.User, passwordHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[key(user.NickName)]; ok {
+		return errs.New(errs.CodeConflict, "user with nickname %s or email %s already exists", user.NickName, user.Email)
+	}
+	if _, ok := r.emails[key(user.Email)]; ok {
+		return errs.New(errs.CodeConflict, "user with nickname %s or email %s already exists", user.NickName, user.Email)
+	}
+	u := user
+	r.users[key(user.NickName)] = &u
+	r.userOrder = append(r.userOrder, key(user.NickName))
+	r.emails[key(user.Email)] = key(user.NickName)
+	if passwordHash != "" {
+		r.passwords[key(user.NickName)] = passwordHash
+	}
+	return nil
+}
+
+func (r *repoMemory) CheckUserForUniq(ctx context.Context, user models.User) ([]models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var users []models.User
+	for _, nickname := range r.userOrder {
+		u := r.users[nickname]
+		if nickname == key(user.NickName) || key(u.Email) == key(user.Email) {
+			users = append(users, *u)
+		}
+	}
+	return users, nil
+}
+
+func (r *repoMemory) GetUser(ctx context.Context, nickname string) (models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	u, ok := r.users[key(nickname)]
+	if !ok {
+		return models.User{}, errs.New(errs.CodeNotFound, "Can't find user by nickname: %s", nickname)
+	}
+	return *u, nil
+}
+
+func (r *repoMemory) ChangeUserInfo(ctx context.Context, user models.User) (models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[key(user.NickName)]
+	if !ok {
+		return models.User{}, errs.New(errs.CodeNotFound, "Can't find user by nickname: %s", user.NickName)
+	}
+	if owner, ok := r.emails[key(user.Email)]; ok && owner != key(user.NickName) {
+		return models.User{}, errs.New(errs.CodeConflict, "email %s is already taken", user.Email).WithField("email")
+	}
+	delete(r.emails, key(u.Email))
+	r.emails[key(user.Email)] = key(user.NickName)
+	u.FullName = user.FullName
+	u.About = user.About
+	u.Email = user.Email
+	return user, nil
+}
+
+func (r *repoMemory) CreateForum(ctx context.Context, forum models.Forum) (models.Forum, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.forums[key(forum.Slug)]; ok {
+		return models.Forum{}, errs.New(errs.CodeConflict, "forum %s already exists", forum.Slug)
+	}
+	if forum.Language != "" && !searchConfigs[key(forum.Language)] {
+		return models.Forum{}, errs.New(errs.CodeBadRequest, "unknown text search configuration %q", forum.Language).WithField("language")
+	}
+	f := forum
+	r.forums[key(forum.Slug)] = &f
+	return forum, nil
+}
+
+func (r *repoMemory) CheckForumForUniq(ctx context.Context, forum models.Forum) ([]models.Forum, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	f, ok := r.forums[key(forum.Slug)]
+	if !ok {
+		return nil, nil
+	}
+	return []models.Forum{*f}, nil
+}
+
+func (r *repoMemory) GetForumDetails(ctx context.Context, slug string) (models.Forum, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	f, ok := r.forums[key(slug)]
+	if !ok {
+		return models.Forum{}, errs.New(errs.CodeNotFound, "Can't find forum by slug: %s", slug)
+	}
+	return *f, nil
+}
+
+func (r *repoMemory) CreateThread(ctx context.Context, thread models.Thread) (models.Thread, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	forum, ok := r.forums[key(thread.Forum)]
+	if !ok {
+		return models.Thread{}, errs.New(errs.CodeNotFound, "Can't find forum by slug: %s", thread.Forum)
+	}
+	if _, ok := r.users[key(thread.Author)]; !ok {
+		return models.Thread{}, errs.New(errs.CodeNotFound, "Can't find user by nickname: %s", thread.Author)
+	}
+	if _, ok := r.threadSlugs[key(thread.Slug)]; ok && thread.Slug != "" {
+		return models.Thread{}, errs.New(errs.CodeConflict, "thread %s already exists", thread.Slug)
+	}
+
+	r.lastThreadID++
+	thread.ID = r.lastThreadID
+	t := thread
+	r.threads[thread.ID] = &t
+	if thread.Slug != "" {
+		r.threadSlugs[key(thread.Slug)] = thread.ID
+	}
+
+	forum.Threads++
+	r.addForumUser(thread.Forum, thread.Author)
+	return thread, nil
+}
+
+func (r *repoMemory) CheckThreadForUniq(ctx context.Context, thread models.Thread) ([]models.Thread, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	id, ok := r.threadSlugs[key(thread.Slug)]
+	if !ok {
+		return nil, nil
+	}
+	return []models.Thread{*r.threads[id]}, nil
+}
+
+func (r *repoMemory) GetThreads(ctx context.Context, slug string, params models.RequestParameters) ([]models.Thread, error) {
+	var since time.Time
+	if params.Since != "" {
+		var err error
+		since, err = time.Parse(time.RFC3339Nano, params.Since)
+		if err != nil {
+			return nil, errs.New(errs.CodeBadRequest, "invalid since %q", params.Since).WithField("since")
+		}
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var fThreads []models.Thread
+	for _, t := range r.threads {
+		if key(t.Forum) != key(slug) {
+			continue
+		}
+		if params.SinceID != 0 {
+			if cmp := compareThreadPosition(t, since, params.SinceID); params.Desc && cmp >= 0 || !params.Desc && cmp <= 0 {
+				continue
+			}
+		} else if params.Since != "" {
+			if params.Desc && t.Created.After(since) || !params.Desc && t.Created.Before(since) {
+				continue
+			}
+		}
+		fThreads = append(fThreads, *t)
+	}
+
+	sort.Slice(fThreads, func(i, j int) bool {
+		a, b := fThreads[i], fThreads[j]
+		if !a.Created.Equal(b.Created) {
+			return a.Created.Before(b.Created) != params.Desc
+		}
+		return a.ID < b.ID != params.Desc
+	})
+	return limitSlice(fThreads, params.Limit), nil
+}
+
+func (r *repoMemory) GetThreadBySlug(ctx context.Context, slug string) (models.Thread, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	id, ok := r.threadSlugs[key(slug)]
+	if !ok {
+		return models.Thread{}, errs.New(errs.CodeNotFound, "Can't find thread by slug: %s", slug)
+	}
+	return *r.threads[id], nil
+}
+
+func (r *repoMemory) GetThreadById(ctx context.Context, id int) (models.Thread, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	t, ok := r.threads[id]
+	if !ok {
+		return models.Thread{}, errs.New(errs.CodeNotFound, "Can't find thread by id: %d", id)
+	}
+	return *t, nil
+}
+
+func (r *repoMemory) CreatePosts(ctx context.Context, posts []models.Post, thread models.Thread) ([]models.Post, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	created := time.Now()
+	for k := range posts {
+		post := &posts[k]
+		post.Forum = thread.Forum
+		post.Thread = thread.ID
+		post.Created = created
+
+		if post.Author == "" {
+			return nil, errs.New(errs.CodeBadRequest, "post author is required").WithField(fmt.Sprintf("posts[%d].author", k))
+		}
+		if _, ok := r.users[key(post.Author)]; !ok {
+			return nil, errs.New(errs.CodeNotFound, "Can't find post author by nickname: %s", post.Author).
+				WithField(fmt.Sprintf("posts[%d].author", k))
+		}
+		if post.Parent != 0 {
+			parent, ok := r.posts[post.Parent]
+			if !ok {
+				return nil, errs.New(errs.CodeConflict, "Parent post %d does not exist", post.Parent).
+					WithField(fmt.Sprintf("posts[%d].parent", k))
+			}
+			if parent.post.Thread != thread.ID {
+				return nil, errs.New(errs.CodeConflict, "Parent post %d was created in another thread", post.Parent).
+					WithField(fmt.Sprintf("posts[%d].parent", k))
+			}
+		}
+	}
+
+	forum := r.forums[key(thread.Forum)]
+	for k := range posts {
+		post := &posts[k]
+		r.lastPostID++
+		post.ID = r.lastPostID
+
+		var path []int32
+		if post.Parent != 0 {
+			path = append(path, r.posts[post.Parent].path...)
+		}
+		path = append(path, int32(post.ID))
+
+		r.posts[post.ID] = &memoryPost{post: *post, path: path}
+		r.threadPosts[thread.ID] = append(r.threadPosts[thread.ID], post.ID)
+		if forum != nil {
+			forum.Posts++
+		}
+		r.addForumUser(thread.Forum, post.Author)
+	}
+	return posts, nil
+}
+
+func (r *repoMemory) ImportPosts(ctx context.Context, posts []models.ImportPost, thread models.Thread) (models.ImportResult, error) {
+	parents, err := importOrder(posts)
+	if err != nil {
+		return models.ImportResult{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for k, post := range posts {
+		if _, ok := r.users[key(post.Author)]; !ok {
+			return models.ImportResult{}, errs.New(errs.CodeNotFound, "Can't find post author by nickname: %s", post.Author).
+				WithField(fmt.Sprintf("posts[%d].author", k))
+		}
+		if post.Parent != 0 {
+			parent, ok := r.posts[post.Parent]
+			if !ok {
+				return models.ImportResult{}, errs.New(errs.CodeConflict, "Parent post %d does not exist", post.Parent).
+					WithField(fmt.Sprintf("posts[%d].parent", k))
+			}
+			if parent.post.Thread != thread.ID {
+				return models.ImportResult{}, errs.New(errs.CodeConflict, "Parent post %d was created in another thread", post.Parent).
+					WithField(fmt.Sprintf("posts[%d].parent", k))
+			}
+		}
+	}
+
+	created := time.Now()
+	forum := r.forums[key(thread.Forum)]
+	ids := make([]int, len(posts))
+	result := models.ImportResult{Imported: len(posts)}
+	for k, post := range posts {
+		r.lastPostID++
+		ids[k] = r.lastPostID
+
+		p := models.Post{ID: ids[k], Parent: post.Parent, Author: post.Author, Message: post.Message,
+			Forum: thread.Forum, Thread: thread.ID, Created: post.Created}
+		if p.Created.IsZero() {
+			p.Created = created
+		}
+		if parents[k] >= 0 {
+			p.Parent = ids[parents[k]]
+		}
+		var path []int32
+		if p.Parent != 0 {
+			path = append(path, r.posts[p.Parent].path...)
+		}
+		path = append(path, int32(p.ID))
+
+		r.posts[p.ID] = &memoryPost{post: p, path: path}
+		r.threadPosts[thread.ID] = append(r.threadPosts[thread.ID], p.ID)
+		if forum != nil {
+			forum.Posts++
+		}
+		r.addForumUser(thread.Forum, post.Author)
+		if post.Ref != "" {
+			if result.Refs == nil {
+				result.Refs = make(map[string]int)
+			}
+			result.Refs[post.Ref] = p.ID
+		}
+	}
+	return result, nil
+}
+
+func (r *repoMemory) ChangeVote(ctx context.Context, vote models.Vote, thread models.Thread) (models.Thread, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, ok := r.threads[vote.Thread]
+	if !ok {
+		return models.Thread{}, errs.New(errs.CodeNotFound, "Can't find thread by id: %d", vote.Thread)
+	}
+	voteKey := memoryVoteKey{nickname: key(vote.Nickname), thread: vote.Thread}
+	voice, ok := r.votes[voteKey]
+	if !ok {
+		r.votes[voteKey] = vote.Voice
+		t.Votes += vote.Voice
+		return *t, nil
+	}
+	if voice == vote.Voice {
+		return thread, nil
+	}
+	r.votes[voteKey] = vote.Voice
+	t.Votes += 2 * vote.Voice
+	return *t, nil
+}
+
+func (r *repoMemory) ChangeThreadInfo(ctx context.Context, thread models.Thread) (models.Thread, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if t, ok := r.threads[thread.ID]; ok {
+		t.Title = thread.Title
+		t.Message = thread.Message
+	}
+	return thread, nil
+}
+
+func (r *repoMemory) GetUsers(ctx context.Context, slug string, params models.RequestParameters) ([]models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var fUsers []models.User
+	for nickname := range r.forumUsers[key(slug)] {
+		if params.Since != "" {
+			if params.Desc && nickname >= key(params.Since) || !params.Desc && nickname <= key(params.Since) {
+				continue
+			}
+		}
+		fUsers = append(fUsers, *r.users[nickname])
+	}
+
+	sort.Slice(fUsers, func(i, j int) bool {
+		return key(fUsers[i].NickName) < key(fUsers[j].NickName) != params.Desc
+	})
+	return limitSlice(fUsers, params.Limit), nil
+}
+
+func (r *repoMemory) GetPostDetails(ctx context.Context, id int, related []string) (models.PostDetailed, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	p, ok := r.posts[id]
+	if !ok {
+		return models.PostDetailed{}, errs.New(errs.CodeNotFound, "Can't find post with id: %d", id)
+	}
+
+	fPost := models.PostDetailed{Post: tombstone(p.post)}
+	for _, param := range related {
+		switch param {
+		case "user":
+			if p.post.IsDeleted {
+				continue
+			}
+			u := *r.users[key(p.post.Author)]
+			fPost.Author = &u
+		case "forum":
+			f := *r.forums[key(p.post.Forum)]
+			fPost.Forum = &f
+		case "thread":
+			t := *r.threads[p.post.Thread]
+			fPost.Thread = &t
+		}
+	}
+	return fPost, nil
+}
+
+func (r *repoMemory) ChangePostInfo(ctx context.Context, post models.Post, editor string) (models.Post, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.posts[post.ID]
+	if !ok {
+		return models.Post{}, errs.New(errs.CodeNotFound, "Can't find post with id: %d", post.ID)
+	}
+	if len(r.revisions[post.ID]) == 0 {
+		r.revisions[post.ID] = []models.PostRevision{p.original()}
+	}
+	r.revisions[post.ID] = append(r.revisions[post.ID], models.PostRevision{
+		Revision: len(r.revisions[post.ID]) + 1, Message: post.Message, Editor: editor, Created: time.Now(),
+	})
+	p.post.Message = post.Message
+	p.post.IsEdited = true
+	return post, nil
+}
+
+func (r *repoMemory) GetPostHistory(ctx context.Context, id int) ([]models.PostRevision, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	p, ok := r.posts[id]
+	if !ok {
+		return nil, errs.New(errs.CodeNotFound, "Can't find post with id: %d", id)
+	}
+	if revisions := r.revisions[id]; len(revisions) > 0 {
+		return append([]models.PostRevision(nil), revisions...), nil
+	}
+	return []models.PostRevision{p.original()}, nil
+}
+
+// original — ревизия 1: текст поста до первой правки.
+func (p *memoryPost) original() models.PostRevision {
+	return models.PostRevision{Revision: 1, Message: p.post.Message, Editor: p.post.Author, Created: p.post.Created}
+}
+
+func (r *repoMemory) DeletePost(ctx context.Context, id int) (models.Post, error) {
+	return r.setPostDeleted(id, true)
+}
+
+func (r *repoMemory) RestorePost(ctx context.Context, id int) (models.Post, error) {
+	return r.setPostDeleted(id, false)
+}
+
+func (r *repoMemory) setPostDeleted(id int, deleted bool) (models.Post, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.posts[id]
+	if !ok {
+		return models.Post{}, errs.New(errs.CodeNotFound, "Can't find post with id: %d", id)
+	}
+	if p.post.IsDeleted != deleted {
+		p.post.IsDeleted = deleted
+		if deleted {
+			r.forums[key(p.post.Forum)].Posts--
+		} else {
+			r.forums[key(p.post.Forum)].Posts++
+		}
+	}
+	return tombstone(p.post), nil
+}
+
+// GetStatus в памяти всегда точный: счётчики форумов и так поддерживаются при записи.
+func (r *repoMemory) GetStatus(ctx context.Context, exact bool) (models.Info, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	info := models.Info{
+		Users:   int64(len(r.users)),
+		Forums:  int64(len(r.forums)),
+		Votes:   int64(len(r.votes)),
+		Exact:   true,
+		ByForum: make([]models.ForumInfo, 0, len(r.forums)),
+	}
+	for _, forum := range r.forums {
+		info.ByForum = append(info.ByForum, models.ForumInfo{Slug: forum.Slug, Threads: int64(forum.Threads), Posts: int64(forum.Posts)})
+		info.Threads += int64(forum.Threads)
+		info.Posts += int64(forum.Posts)
+	}
+	sort.Slice(info.ByForum, func(i, j int) bool { return key(info.ByForum[i].Slug) < key(info.ByForum[j].Slug) })
+	return info, nil
+}
+
+func (r *repoMemory) Clear(ctx context.Context) (models.ClearReport, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	report := r.allRows()
+	r.reset()
+	return report, nil
+}
+
+func (r *repoMemory) CountClear(ctx context.Context, forum string) (models.ClearReport, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if forum == "" {
+		return r.allRows(), nil
+	}
+	report, _, err := r.forumRows(forum)
+	return report, err
+}
+
+func (r *repoMemory) allRows() models.ClearReport {
+	return models.ClearReport{
+		Users:   int64(len(r.users)),
+		Forums:  int64(len(r.forums)),
+		Threads: int64(len(r.threads)),
+		Posts:   int64(len(r.posts)),
+		Votes:   int64(len(r.votes)),
+	}
+}
+
+func (r *repoMemory) ClearForum(ctx context.Context, forum string) (models.ClearReport, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	report, threads, err := r.forumRows(forum)
+	if err != nil {
+		return models.ClearReport{}, err
+	}
+	for _, thread := range threads {
+		for _, id := range r.threadPosts[thread.ID] {
+			delete(r.posts, id)
+			delete(r.revisions, id)
+		}
+		delete(r.threadPosts, thread.ID)
+		if thread.Slug != "" {
+			delete(r.threadSlugs, key(thread.Slug))
+		}
+		delete(r.threads, thread.ID)
+	}
+	for vote := range r.votes {
+		if _, ok := r.threads[vote.thread]; !ok {
+			delete(r.votes, vote)
+		}
+	}
+	delete(r.forums, key(forum))
+	delete(r.forumUsers, key(forum))
+	delete(r.roles, key(forum))
+	return report, nil
+}
+
+// forumRows считает строки форума для CountClear и ClearForum и возвращает его ветки.
+func (r *repoMemory) forumRows(forum string) (models.ClearReport, []*models.Thread, error) {
+	f, ok := r.forums[key(forum)]
+	if !ok {
+		return models.ClearReport{}, nil, errs.New(errs.CodeNotFound, "Can't find forum with slug: %s", forum)
+	}
+	report := models.ClearReport{Forum: f.Slug, Forums: 1}
+	var threads []*models.Thread
+	for _, thread := range r.threads {
+		if key(thread.Forum) == key(forum) {
+			threads = append(threads, thread)
+			report.Posts += int64(len(r.threadPosts[thread.ID]))
+		}
+	}
+	report.Threads = int64(len(threads))
+	for vote := range r.votes {
+		if t, ok := r.threads[vote.thread]; ok && key(t.Forum) == key(forum) {
+			report.Votes++
+		}
+	}
+	return report, threads, nil
+}
+
+// CheckConsistency сверяет счётчики с данными так же, как repoPostgres. Профили в users_forum
+// здесь не копируются, поэтому расхождений forum_user_outdated не бывает.
+func (r *repoMemory) CheckConsistency(ctx context.Context, repair bool) (models.ConsistencyReport, error) {
+	if repair {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+	} else {
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+	}
+
+	posts := make(map[string]int64)
+	threads := make(map[string]int64)
+	votes := make(map[int]int64)
+	members := make(map[string]map[string]struct{})
+	addMember := func(forum string, nickname string) {
+		if members[key(forum)] == nil {
+			members[key(forum)] = make(map[string]struct{})
+		}
+		members[key(forum)][key(nickname)] = struct{}{}
+	}
+	for _, t := range r.threads {
+		threads[key(t.Forum)]++
+		addMember(t.Forum, t.Author)
+	}
+	for _, p := range r.posts {
+		if !p.post.IsDeleted {
+			posts[key(p.post.Forum)]++
+		}
+		addMember(p.post.Forum, p.post.Author)
+	}
+	for k, voice := range r.votes {
+		votes[k.thread] += int64(voice)
+	}
+
+	forumKeys := sortedKeys(r.forums)
+	threadIDs := make([]int, 0, len(r.threads))
+	for id := range r.threads {
+		threadIDs = append(threadIDs, id)
+	}
+	sort.Ints(threadIDs)
+
+	report := models.ConsistencyReport{Mismatches: []models.ConsistencyMismatch{}}
+	add := func(m models.ConsistencyMismatch) {
+		report.Mismatches = append(report.Mismatches, m)
+	}
+	for _, k := range forumKeys {
+		f := r.forums[k]
+		if int64(f.Posts) != posts[k] {
+			add(models.ConsistencyMismatch{Kind: models.MismatchForumPosts, Forum: f.Slug, Stored: int64(f.Posts), Actual: posts[k]})
+		}
+	}
+	for _, k := range forumKeys {
+		f := r.forums[k]
+		if int64(f.Threads) != threads[k] {
+			add(models.ConsistencyMismatch{Kind: models.MismatchForumThreads, Forum: f.Slug, Stored: int64(f.Threads), Actual: threads[k]})
+		}
+	}
+	for _, id := range threadIDs {
+		t := r.threads[id]
+		if int64(t.Votes) != votes[id] {
+			add(models.ConsistencyMismatch{Kind: models.MismatchThreadVotes, Forum: t.Forum, Thread: id, Stored: int64(t.Votes), Actual: votes[id]})
+		}
+	}
+	diffMembers := func(kind string, from, to map[string]map[string]struct{}) {
+		for _, forum := range sortedKeys(from) {
+			for _, nickname := range sortedKeys(from[forum]) {
+				if _, ok := to[forum][nickname]; !ok {
+					add(models.ConsistencyMismatch{Kind: kind, Forum: r.forums[forum].Slug, Nickname: r.users[nickname].NickName})
+				}
+			}
+		}
+	}
+	diffMembers(models.MismatchForumUserMissing, members, r.forumUsers)
+	diffMembers(models.MismatchForumUserExtra, r.forumUsers, members)
+
+	if !repair || len(report.Mismatches) == 0 {
+		return report, nil
+	}
+	for k, f := range r.forums {
+		f.Posts = int(posts[k])
+		f.Threads = int(threads[k])
+	}
+	for id, t := range r.threads {
+		t.Votes = int(votes[id])
+	}
+	r.forumUsers = members
+	report.Repaired = true
+	return report, nil
+}
+
+func (r *repoMemory) GetPostsFlat(ctx context.Context, params models.RequestParameters, threadID int) ([]models.Post, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	posts := make([]models.Post, 0)
+	for _, id := range r.threadPosts[threadID] {
+		if params.SinceInt != 0 {
+			if params.Desc && id >= params.SinceInt || !params.Desc && id <= params.SinceInt {
+				continue
+			}
+		}
+		posts = append(posts, tombstone(r.posts[id].post))
+	}
+
+	sort.Slice(posts, func(i, j int) bool {
+		return posts[i].ID < posts[j].ID != params.Desc
+	})
+	return limitSlice(posts, params.Limit), nil
+}
+
+func (r *repoMemory) GetPostsTree(ctx context.Context, params models.RequestParameters, threadID int) ([]models.Post, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var sincePath []int32
+	if params.SinceInt != 0 {
+		since, ok := r.posts[params.SinceInt]
+		if !ok {
+			return []models.Post{}, nil
+		}
+		sincePath = since.path
+	}
+
+	var found []*memoryPost
+	for _, id := range r.threadPosts[threadID] {
+		p := r.posts[id]
+		if sincePath != nil {
+			cmp := comparePaths(p.path, sincePath)
+			if params.Desc && cmp >= 0 || !params.Desc && cmp <= 0 {
+				continue
+			}
+		}
+		found = append(found, p)
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		return comparePaths(found[i].path, found[j].path) < 0 != params.Desc
+	})
+
+	posts := make([]models.Post, 0, len(found))
+	for _, p := range limitSlice(found, params.Limit) {
+		posts = append(posts, p.withPath())
+	}
+	return posts, nil
+}
+
+func (r *repoMemory) GetPostsParent(ctx context.Context, params models.RequestParameters, threadID int) ([]models.Post, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	sinceRoot := 0
+	if params.SinceInt != 0 {
+		since, ok := r.posts[params.SinceInt]
+		if !ok {
+			return []models.Post{}, nil
+		}
+		sinceRoot = int(since.path[0])
+	}
+
+	var roots []int
+	byRoot := make(map[int][]*memoryPost)
+	for _, id := range r.threadPosts[threadID] {
+		p := r.posts[id]
+		root := int(p.path[0])
+		if p.post.Parent == 0 {
+			if sinceRoot != 0 && (params.Desc && root >= sinceRoot || !params.Desc && root <= sinceRoot) {
+				continue
+			}
+			roots = append(roots, root)
+		}
+		byRoot[root] = append(byRoot[root], p)
+	}
+
+	sort.Slice(roots, func(i, j int) bool {
+		return roots[i] < roots[j] != params.Desc
+	})
+
+	posts := make([]models.Post, 0)
+	for _, root := range limitSlice(roots, params.Limit) {
+		branch := byRoot[root]
+		sort.Slice(branch, func(i, j int) bool {
+			return comparePaths(branch[i].path, branch[j].path) < 0
+		})
+		for _, p := range branch {
+			posts = append(posts, tombstone(p.post))
+		}
+	}
+	return posts, nil
+}
+
+func (p *memoryPost) withPath() models.Post {
+	post := p.post
+	elements := make([]pgtype.Int4, len(p.path))
+	for i, v := range p.path {
+		elements[i] = pgtype.Int4{Int: v, Status: pgtype.Present}
+	}
+	post.Path = pgtype.Int4Array{
+		Elements:   elements,
+		Dimensions: []pgtype.ArrayDimension{{Length: int32(len(p.path)), LowerBound: 1}},
+		Status:     pgtype.Present,
+	}
+	return tombstone(post)
+}
+
+// comparePaths сравнивает пути так же, как Postgres сравнивает массивы INTEGER[].
+func comparePaths(a, b []int32) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return len(a) - len(b)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Search ищет по словам без стемминга, поэтому конфигурация форума только проверяется при создании.
+// Из синтаксиса websearch поддерживаются слова, фразы в кавычках (как набор слов) и исключение через минус.
+func (r *repoMemory) Search(ctx context.Context, query models.SearchQuery) ([]models.SearchHit, error) {
+	include, exclude := parseSearchQuery(query.Query)
+	hits := make([]models.SearchHit, 0)
+	if len(include) == 0 {
+		return hits, nil
+	}
+	matches := func(forum, author string, created time.Time) bool {
+		return (query.Forum == "" || key(forum) == key(query.Forum)) &&
+			(query.Author == "" || key(author) == key(query.Author)) &&
+			(query.From.IsZero() || !created.Before(query.From)) &&
+			(query.To.IsZero() || created.Before(query.To))
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if query.Kind != models.SearchKindThread {
+		for _, p := range r.posts {
+			post := p.post
+			if post.IsDeleted || query.Thread != 0 && post.Thread != query.Thread || !matches(post.Forum, post.Author, post.Created) {
+				continue
+			}
+			if rank, ok := searchRank(post.Message, include, exclude); ok {
+				hits = append(hits, models.SearchHit{Kind: models.SearchKindPost, Rank: rank, Snippet: highlight(post.Message, include), Post: &post})
+			}
+		}
+	}
+	if query.Kind != models.SearchKindPost {
+		for _, t := range r.threads {
+			thread := *t
+			if query.Thread != 0 && thread.ID != query.Thread || !matches(thread.Forum, thread.Author, thread.Created) {
+				continue
+			}
+			if rank, ok := searchRank(thread.Title+" "+thread.Message, include, exclude); ok {
+				hits = append(hits, models.SearchHit{Kind: models.SearchKindThread, Rank: rank, Snippet: highlight(thread.Message, include), Thread: &thread})
+			}
+		}
+	}
+
+	// По умолчанию — по убыванию (rank, kind, id), как в repoPostgres.
+	sort.Slice(hits, func(i, j int) bool {
+		return compareHits(hits[i], hits[j]) > 0 != query.Reverse
+	})
+	if query.After != nil {
+		after := models.SearchHit{Kind: query.After.Kind, Rank: query.After.Rank, Post: &models.Post{ID: query.After.ID}}
+		k := 0
+		for ; k < len(hits); k++ {
+			if cmp := compareHits(hits[k], after); !query.Reverse && cmp < 0 || query.Reverse && cmp > 0 {
+				break
+			}
+		}
+		hits = hits[k:]
+	}
+	return limitSlice(hits, query.Limit), nil
+}
+
+// Известные конфигурации текстового поиска из поставки Postgres.
+var searchConfigs = map[string]bool{
+	"simple": true, "arabic": true, "danish": true, "dutch": true, "english": true, "finnish": true,
+	"french": true, "german": true, "hungarian": true, "indonesian": true, "irish": true, "italian": true,
+	"lithuanian": true, "nepali": true, "norwegian": true, "portuguese": true, "romanian": true,
+	"russian": true, "spanish": true, "swedish": true, "tamil": true, "turkish": true,
+}
+
+func searchWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(c rune) bool {
+		return !unicode.IsLetter(c) && !unicode.IsDigit(c)
+	})
+}
+
+func parseSearchQuery(query string) (include, exclude map[string]bool) {
+	include, exclude = make(map[string]bool), make(map[string]bool)
+	for _, field := range strings.Fields(query) {
+		target := include
+		if strings.HasPrefix(field, "-") {
+			target = exclude
+		}
+		for _, w := range searchWords(field) {
+			if w != "or" {
+				target[w] = true
+			}
+		}
+	}
+	return include, exclude
+}
+
+// searchRank — доля слов текста, совпавших с запросом; ok=false, если нет хотя бы одного слова запроса.
+func searchRank(text string, include, exclude map[string]bool) (float32, bool) {
+	words := searchWords(text)
+	found := make(map[string]bool)
+	hits := 0
+	for _, w := range words {
+		if exclude[w] {
+			return 0, false
+		}
+		if include[w] {
+			found[w] = true
+			hits++
+		}
+	}
+	if len(found) < len(include) {
+		return 0, false
+	}
+	return float32(hits) / float32(len(words)), true
+}
+
+// highlight оборачивает совпавшие слова в <b></b>, как ts_headline по умолчанию,
+// и экранирует остальной текст, как repoPostgres.
+func highlight(text string, include map[string]bool) string {
+	var b strings.Builder
+	start := -1
+	word := func(end int) {
+		if w := text[start:end]; include[strings.ToLower(w)] {
+			b.WriteString("<b>" + w + "</b>")
+		} else {
+			b.WriteString(w)
+		}
+		start = -1
+	}
+	for i, c := range text {
+		if unicode.IsLetter(c) || unicode.IsDigit(c) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			word(i)
+		}
+		b.WriteString(html.EscapeString(string(c)))
+	}
+	if start >= 0 {
+		word(len(text))
+	}
+	return b.String()
+}
+
+func compareHits(a, b models.SearchHit) int {
+	switch {
+	case a.Rank != b.Rank:
+		return compareOrder(a.Rank < b.Rank)
+	case a.Kind != b.Kind:
+		return compareOrder(a.Kind < b.Kind)
+	case hitID(a) != hitID(b):
+		return compareOrder(hitID(a) < hitID(b))
+	}
+	return 0
+}
+
+func compareOrder(less bool) int {
+	if less {
+		return -1
+	}
+	return 1
+}
+
+func hitID(h models.SearchHit) int {
+	if h.Thread != nil {
+		return h.Thread.ID
+	}
+	return h.Post.ID
+}
+
+// compareThreadPosition сравнивает ветку с позицией (created, id) так же, как сравнение кортежей в Postgres.
+func compareThreadPosition(t *models.Thread, created time.Time, id int) int {
+	switch {
+	case t.Created.Before(created):
+		return -1
+	case t.Created.After(created):
+		return 1
+	case t.ID < id:
+		return -1
+	case t.ID > id:
+		return 1
+	}
+	return 0
+}
+
+func limitSlice[T any](items []T, limit int) []T {
+	if limit > 0 && len(items) > limit {
+		return items[:limit]
+	}
+	return items
+}
+
+func (r *repoMemory) SetPassword(ctx context.Context, nickname string, hash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.users[key(nickname)]; !ok {
+		return errs.New(errs.CodeNotFound, "Can't find user by nickname: %s", nickname)
+	}
+	r.passwords[key(nickname)] = hash
+	return nil
+}
+
+func (r *repoMemory) GetPassword(ctx context.Context, nickname string) (string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	hash, ok := r.passwords[key(nickname)]
+	if !ok {
+		return "", errs.New(errs.CodeNotFound, "user %s has no password", nickname)
+	}
+	return hash, nil
+}
+
+func (r *repoMemory) CreateCredential(ctx context.Context, cred models.Credential) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[key(cred.Nickname)]
+	if !ok {
+		return errs.New(errs.CodeNotFound, "Can't find user by nickname: %s", cred.Nickname)
+	}
+	cred.Nickname = user.NickName
+	cred.Token = ""
+	r.credentials[cred.Hash] = cred
+	return nil
+}
+
+func (r *repoMemory) GetCredential(ctx context.Context, hash string) (models.Credential, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	cred, ok := r.credentials[hash]
+	if !ok {
+		return models.Credential{}, errs.New(errs.CodeNotFound, "unknown credential")
+	}
+	return cred, nil
+}
+
+func (r *repoMemory) DeleteCredential(ctx context.Context, hash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.credentials, hash)
+	return nil
+}
+
+func (r *repoMemory) SetForumRole(ctx context.Context, role models.ForumRole) (models.ForumRole, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	forum, okForum := r.forums[key(role.Forum)]
+	user, okUser := r.users[key(role.Nickname)]
+	if !okForum || !okUser {
+		return models.ForumRole{}, errs.New(errs.CodeNotFound, "Can't find forum %s or user %s", role.Forum, role.Nickname)
+	}
+	role.Forum, role.Nickname, role.Granted = forum.Slug, user.NickName, time.Now()
+	if r.roles[key(forum.Slug)] == nil {
+		r.roles[key(forum.Slug)] = make(map[string]models.ForumRole)
+	}
+	r.roles[key(forum.Slug)][key(user.NickName)] = role
+	return role, nil
+}
+
+func (r *repoMemory) GetForumRole(ctx context.Context, forum string, nickname string) (models.ForumRole, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	role, ok := r.roles[key(forum)][key(nickname)]
+	if !ok {
+		return models.ForumRole{}, errs.New(errs.CodeNotFound, "%s has no role in forum %s", nickname, forum)
+	}
+	return role, nil
+}
+
+func (r *repoMemory) GetForumRoles(ctx context.Context, forum string, role string) ([]models.ForumRole, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	roles := make([]models.ForumRole, 0)
+	for _, fr := range r.roles[key(forum)] {
+		if fr.Role == role {
+			roles = append(roles, fr)
+		}
+	}
+	sort.Slice(roles, func(i, j int) bool {
+		return key(roles[i].Nickname) < key(roles[j].Nickname)
+	})
+	return roles, nil
+}
+
+func (r *repoMemory) DeleteForumRole(ctx context.Context, forum string, nickname string, role string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if fr, ok := r.roles[key(forum)][key(nickname)]; !ok || fr.Role != role {
+		return errs.New(errs.CodeNotFound, "%s is not %s in forum %s", nickname, role, forum)
+	}
+	delete(r.roles[key(forum)], key(nickname))
+	return nil
+}
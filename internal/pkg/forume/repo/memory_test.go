@@ -0,0 +1,25 @@
+package repo
+
+import (
+	"github.com/BigBullas/TP_DB_project/internal/pkg/forume"
+	"github.com/BigBullas/TP_DB_project/internal/pkg/forume/repo/repotest"
+	"testing"
+)
+
+func TestRepoMemory(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) forume.Repository {
+		return NewRepoMemory()
+	})
+}
+
+func TestRepoMemoryConsistency(t *testing.T) {
+	newRepo := func(t *testing.T) forume.Repository {
+		return NewRepoMemory()
+	}
+	repotest.RunConsistency(t, newRepo, func(t *testing.T, r forume.Repository, forum string, thread int, author string) {
+		m := r.(*repoMemory)
+		m.forums[key(forum)].Posts += 5
+		m.threads[thread].Votes = 7
+		delete(m.forumUsers[key(forum)], key(author))
+	})
+}
@@ -0,0 +1,223 @@
+package repo
+
+import (
+	"context"
+	"github.com/BigBullas/TP_DB_project/internal/metrics"
+	"github.com/BigBullas/TP_DB_project/internal/models"
+	"github.com/BigBullas/TP_DB_project/internal/pkg/forume"
+	"time"
+)
+
+// repoMetrics замеряет время и ошибки каждого вызова next, в том числе для хранилища в памяти.
+type repoMetrics struct {
+	next    forume.Repository
+	metrics *metrics.Metrics
+}
+
+func WithMetrics(next forume.Repository, m *metrics.Metrics) forume.Repository {
+	return &repoMetrics{next: next, metrics: m}
+}
+
+func (r *repoMetrics) observe(method string, start time.Time, err *error) {
+	r.metrics.ObserveQuery(method, start, *err)
+}
+
+func (r *repoMetrics) CreateUser(ctx context.Context, user models.User, passwordHash string) (err error) {
+	defer r.observe("CreateUser", time.Now(), &err)
+	return r.next.CreateUser(ctx, user, passwordHash)
+}
+
+func (r *repoMetrics) CheckUserForUniq(ctx context.Context, user models.User) (_ []models.User, err error) {
+	defer r.observe("CheckUserForUniq", time.Now(), &err)
+	return r.next.CheckUserForUniq(ctx, user)
+}
+
+func (r *repoMetrics) GetUser(ctx context.Context, nickname string) (_ models.User, err error) {
+	defer r.observe("GetUser", time.Now(), &err)
+	return r.next.GetUser(ctx, nickname)
+}
+
+func (r *repoMetrics) ChangeUserInfo(ctx context.Context, user models.User) (_ models.User, err error) {
+	defer r.observe("ChangeUserInfo", time.Now(), &err)
+	return r.next.ChangeUserInfo(ctx, user)
+}
+
+func (r *repoMetrics) CreateForum(ctx context.Context, forum models.Forum) (_ models.Forum, err error) {
+	defer r.observe("CreateForum", time.Now(), &err)
+	return r.next.CreateForum(ctx, forum)
+}
+
+func (r *repoMetrics) CheckForumForUniq(ctx context.Context, forum models.Forum) (_ []models.Forum, err error) {
+	defer r.observe("CheckForumForUniq", time.Now(), &err)
+	return r.next.CheckForumForUniq(ctx, forum)
+}
+
+func (r *repoMetrics) GetForumDetails(ctx context.Context, slug string) (_ models.Forum, err error) {
+	defer r.observe("GetForumDetails", time.Now(), &err)
+	return r.next.GetForumDetails(ctx, slug)
+}
+
+func (r *repoMetrics) CreateThread(ctx context.Context, thread models.Thread) (_ models.Thread, err error) {
+	defer r.observe("CreateThread", time.Now(), &err)
+	return r.next.CreateThread(ctx, thread)
+}
+
+func (r *repoMetrics) CheckThreadForUniq(ctx context.Context, thread models.Thread) (_ []models.Thread, err error) {
+	defer r.observe("CheckThreadForUniq", time.Now(), &err)
+	return r.next.CheckThreadForUniq(ctx, thread)
+}
+
+func (r *repoMetrics) GetThreads(ctx context.Context, slug string, params models.RequestParameters) (_ []models.Thread, err error) {
+	defer r.observe("GetThreads", time.Now(), &err)
+	return r.next.GetThreads(ctx, slug, params)
+}
+
+func (r *repoMetrics) GetThreadBySlug(ctx context.Context, slug string) (_ models.Thread, err error) {
+	defer r.observe("GetThreadBySlug", time.Now(), &err)
+	return r.next.GetThreadBySlug(ctx, slug)
+}
+
+func (r *repoMetrics) GetThreadById(ctx context.Context, id int) (_ models.Thread, err error) {
+	defer r.observe("GetThreadById", time.Now(), &err)
+	return r.next.GetThreadById(ctx, id)
+}
+
+func (r *repoMetrics) CreatePosts(ctx context.Context, posts []models.Post, thread models.Thread) (_ []models.Post, err error) {
+	defer r.observe("CreatePosts", time.Now(), &err)
+	return r.next.CreatePosts(ctx, posts, thread)
+}
+
+func (r *repoMetrics) ImportPosts(ctx context.Context, posts []models.ImportPost, thread models.Thread) (_ models.ImportResult, err error) {
+	defer r.observe("ImportPosts", time.Now(), &err)
+	return r.next.ImportPosts(ctx, posts, thread)
+}
+
+func (r *repoMetrics) ChangeVote(ctx context.Context, vote models.Vote, thread models.Thread) (_ models.Thread, err error) {
+	defer r.observe("ChangeVote", time.Now(), &err)
+	return r.next.ChangeVote(ctx, vote, thread)
+}
+
+func (r *repoMetrics) ChangeThreadInfo(ctx context.Context, thread models.Thread) (_ models.Thread, err error) {
+	defer r.observe("ChangeThreadInfo", time.Now(), &err)
+	return r.next.ChangeThreadInfo(ctx, thread)
+}
+
+func (r *repoMetrics) GetUsers(ctx context.Context, slug string, params models.RequestParameters) (_ []models.User, err error) {
+	defer r.observe("GetUsers", time.Now(), &err)
+	return r.next.GetUsers(ctx, slug, params)
+}
+
+func (r *repoMetrics) GetPostDetails(ctx context.Context, id int, related []string) (_ models.PostDetailed, err error) {
+	defer r.observe("GetPostDetails", time.Now(), &err)
+	return r.next.GetPostDetails(ctx, id, related)
+}
+
+func (r *repoMetrics) ChangePostInfo(ctx context.Context, post models.Post, editor string) (_ models.Post, err error) {
+	defer r.observe("ChangePostInfo", time.Now(), &err)
+	return r.next.ChangePostInfo(ctx, post, editor)
+}
+
+func (r *repoMetrics) GetPostHistory(ctx context.Context, id int) (_ []models.PostRevision, err error) {
+	defer r.observe("GetPostHistory", time.Now(), &err)
+	return r.next.GetPostHistory(ctx, id)
+}
+
+func (r *repoMetrics) DeletePost(ctx context.Context, id int) (_ models.Post, err error) {
+	defer r.observe("DeletePost", time.Now(), &err)
+	return r.next.DeletePost(ctx, id)
+}
+
+func (r *repoMetrics) RestorePost(ctx context.Context, id int) (_ models.Post, err error) {
+	defer r.observe("RestorePost", time.Now(), &err)
+	return r.next.RestorePost(ctx, id)
+}
+
+func (r *repoMetrics) GetStatus(ctx context.Context, exact bool) (_ models.Info, err error) {
+	defer r.observe("GetStatus", time.Now(), &err)
+	return r.next.GetStatus(ctx, exact)
+}
+
+func (r *repoMetrics) Clear(ctx context.Context) (_ models.ClearReport, err error) {
+	defer r.observe("Clear", time.Now(), &err)
+	return r.next.Clear(ctx)
+}
+
+func (r *repoMetrics) CountClear(ctx context.Context, forum string) (_ models.ClearReport, err error) {
+	defer r.observe("CountClear", time.Now(), &err)
+	return r.next.CountClear(ctx, forum)
+}
+
+func (r *repoMetrics) ClearForum(ctx context.Context, forum string) (_ models.ClearReport, err error) {
+	defer r.observe("ClearForum", time.Now(), &err)
+	return r.next.ClearForum(ctx, forum)
+}
+
+func (r *repoMetrics) CheckConsistency(ctx context.Context, repair bool) (_ models.ConsistencyReport, err error) {
+	defer r.observe("CheckConsistency", time.Now(), &err)
+	return r.next.CheckConsistency(ctx, repair)
+}
+
+func (r *repoMetrics) Search(ctx context.Context, query models.SearchQuery) (_ []models.SearchHit, err error) {
+	defer r.observe("Search", time.Now(), &err)
+	return r.next.Search(ctx, query)
+}
+
+func (r *repoMetrics) GetPostsFlat(ctx context.Context, params models.RequestParameters, threadID int) (_ []models.Post, err error) {
+	defer r.observe("GetPostsFlat", time.Now(), &err)
+	return r.next.GetPostsFlat(ctx, params, threadID)
+}
+
+func (r *repoMetrics) GetPostsTree(ctx context.Context, params models.RequestParameters, threadID int) (_ []models.Post, err error) {
+	defer r.observe("GetPostsTree", time.Now(), &err)
+	return r.next.GetPostsTree(ctx, params, threadID)
+}
+
+func (r *repoMetrics) GetPostsParent(ctx context.Context, params models.RequestParameters, threadID int) (_ []models.Post, err error) {
+	defer r.observe("GetPostsParent", time.Now(), &err)
+	return r.next.GetPostsParent(ctx, params, threadID)
+}
+
+func (r *repoMetrics) SetPassword(ctx context.Context, nickname string, hash string) (err error) {
+	defer r.observe("SetPassword", time.Now(), &err)
+	return r.next.SetPassword(ctx, nickname, hash)
+}
+
+func (r *repoMetrics) GetPassword(ctx context.Context, nickname string) (_ string, err error) {
+	defer r.observe("GetPassword", time.Now(), &err)
+	return r.next.GetPassword(ctx, nickname)
+}
+
+func (r *repoMetrics) CreateCredential(ctx context.Context, cred models.Credential) (err error) {
+	defer r.observe("CreateCredential", time.Now(), &err)
+	return r.next.CreateCredential(ctx, cred)
+}
+
+func (r *repoMetrics) GetCredential(ctx context.Context, hash string) (_ models.Credential, err error) {
+	defer r.observe("GetCredential", time.Now(), &err)
+	return r.next.GetCredential(ctx, hash)
+}
+
+func (r *repoMetrics) DeleteCredential(ctx context.Context, hash string) (err error) {
+	defer r.observe("DeleteCredential", time.Now(), &err)
+	return r.next.DeleteCredential(ctx, hash)
+}
+
+func (r *repoMetrics) SetForumRole(ctx context.Context, role models.ForumRole) (_ models.ForumRole, err error) {
+	defer r.observe("SetForumRole", time.Now(), &err)
+	return r.next.SetForumRole(ctx, role)
+}
+
+func (r *repoMetrics) GetForumRole(ctx context.Context, forum string, nickname string) (_ models.ForumRole, err error) {
+	defer r.observe("GetForumRole", time.Now(), &err)
+	return r.next.GetForumRole(ctx, forum, nickname)
+}
+
+func (r *repoMetrics) GetForumRoles(ctx context.Context, forum string, role string) (_ []models.ForumRole, err error) {
+	defer r.observe("GetForumRoles", time.Now(), &err)
+	return r.next.GetForumRoles(ctx, forum, role)
+}
+
+func (r *repoMetrics) DeleteForumRole(ctx context.Context, forum string, nickname string, role string) (err error) {
+	defer r.observe("DeleteForumRole", time.Now(), &err)
+	return r.next.DeleteForumRole(ctx, forum, nickname, role)
+}
@@ -0,0 +1,30 @@
+package repo
+
+import (
+	"context"
+	"github.com/BigBullas/TP_DB_project/internal/config"
+	"github.com/BigBullas/TP_DB_project/internal/tracing"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+func NewPool(ctx context.Context, cfg config.Database) (*pgxpool.Pool, error) {
+	poolConfig, err := pgxpool.ParseConfig(cfg.DSN)
+	if err != nil {
+		return nil, err
+	}
+	poolConfig.MaxConns = int32(cfg.MaxConns)
+	poolConfig.MinConns = int32(cfg.MinConns)
+	if cfg.ConnectTimeout > 0 {
+		poolConfig.ConnConfig.ConnectTimeout = cfg.ConnectTimeout
+	}
+	// Триггеры из 0003_write_model пропускают работу, которую за них делает appWrites.
+	if cfg.WriteModel == "app" {
+		poolConfig.ConnConfig.RuntimeParams["forum.write_model"] = "app"
+	}
+	if tracing.Enabled() {
+		poolConfig.ConnConfig.Logger = tracing.QueryLogger{}
+		poolConfig.ConnConfig.LogLevel = pgx.LogLevelInfo
+	}
+	return pgxpool.ConnectConfig(ctx, poolConfig)
+}
@@ -0,0 +1,69 @@
+package repo
+
+import (
+	"context"
+	"github.com/BigBullas/TP_DB_project/db"
+	"github.com/BigBullas/TP_DB_project/internal/config"
+	"github.com/BigBullas/TP_DB_project/internal/migrate"
+	"github.com/BigBullas/TP_DB_project/internal/pkg/forume"
+	"github.com/BigBullas/TP_DB_project/internal/pkg/forume/repo/repotest"
+	"os"
+	"testing"
+)
+
+// Тест запускается только при заданной FORUM_TEST_DSN и очищает все таблицы этой базы.
+func TestRepoPostgres(t *testing.T) {
+	dsn := os.Getenv("FORUM_TEST_DSN")
+	if dsn == "" {
+		t.Skip("FORUM_TEST_DSN is not set")
+	}
+
+	for _, writeModel := range []string{"triggers", "app"} {
+		t.Run(writeModel, func(t *testing.T) {
+			testRepoPostgres(t, dsn, writeModel)
+		})
+	}
+}
+
+func testRepoPostgres(t *testing.T, dsn string, writeModel string) {
+	ctx := context.Background()
+	cfg := config.Default().DB
+	cfg.DSN = dsn
+	cfg.MaxConns = 10
+	cfg.WriteModel = writeModel
+	pool, err := NewPool(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	migrator, err := migrate.New(pool, db.Migrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	newRepo := func(t *testing.T) forume.Repository {
+		r := NewRepoPostgres(pool, cfg, nil)
+		if _, err := r.Clear(ctx); err != nil {
+			t.Fatalf("Clear: %v", err)
+		}
+		return r
+	}
+	repotest.Run(t, newRepo)
+	t.Run("ConsistencyRepair", func(t *testing.T) {
+		repotest.RunConsistency(t, newRepo, func(t *testing.T, r forume.Repository, forum string, thread int, author string) {
+			if _, err := pool.Exec(ctx, `UPDATE forum SET Posts = Posts + 5 WHERE Slug = $1;`, forum); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := pool.Exec(ctx, `UPDATE thread SET Votes = 7 WHERE Id = $1;`, thread); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := pool.Exec(ctx, `DELETE FROM users_forum WHERE Slug = $1 AND Nickname = $2;`, forum, author); err != nil {
+				t.Fatal(err)
+			}
+		})
+	})
+}
@@ -2,39 +2,107 @@ package repo
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"github.com/BigBullas/TP_DB_project/internal/config"
+	"github.com/BigBullas/TP_DB_project/internal/errs"
+	"github.com/BigBullas/TP_DB_project/internal/logging"
 	"github.com/BigBullas/TP_DB_project/internal/models"
 	"github.com/BigBullas/TP_DB_project/internal/pkg/forume"
-	mapset "github.com/deckarep/golang-set"
-	"github.com/jackc/pgtype"
+	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
-	"net/http"
+	"log/slog"
+	"strings"
 	"time"
 )
 
+const (
+	pgUniqueViolation = "23505"
+	pgUndefinedObject = "42704"
+)
+
 type repoPostgres struct {
-	Conn *pgxpool.Pool
+	Conn   *pgxpool.Pool
+	cfg    config.Database
+	writes writeModel
+	log    *slog.Logger
 }
 
-func NewRepoPostgres(Conn *pgxpool.Pool) forume.Repository {
-	return &repoPostgres{Conn: Conn}
+// NewRepoPostgres ожидает пул из NewPool с тем же cfg: для write_model = app соединения
+// должны отключать триггеры, иначе счётчики будут увеличиваться дважды. log может быть nil.
+func NewRepoPostgres(Conn *pgxpool.Pool, cfg config.Database, log *slog.Logger) forume.Repository {
+	if log == nil {
+		log = logging.Discard()
+	}
+	return &repoPostgres{Conn: Conn, cfg: cfg, writes: newWriteModel(cfg.WriteModel), log: log}
 }
 
-func (r *repoPostgres) CreateUser(ctx context.Context, user models.User) error {
-	const CreateUser = `INSERT INTO users(Nickname, FullName, About, Email) VALUES ($1, $2, $3, $4);`
-	_, err := r.Conn.Exec(ctx, CreateUser, user.NickName, user.FullName, user.About, user.Email)
+func (r *repoPostgres) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if r.cfg.QueryTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, r.cfg.QueryTimeout)
+}
+
+func (r *repoPostgres) inTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	tx, err := r.Conn.Begin(ctx)
 	if err != nil {
-		return models.InternalError
+		return errs.Wrap(err, "begin transaction")
 	}
-	return nil
+	if err := fn(tx); err != nil {
+		// Соединение с незавершённой транзакцией пул закроет сам, но такие случаи стоит видеть.
+		if rbErr := tx.Rollback(ctx); rbErr != nil {
+			r.log.WarnContext(ctx, "rollback failed", "err", rbErr, "cause", err)
+		}
+		return err
+	}
+	return errs.Wrap(tx.Commit(ctx), "commit transaction")
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation
+}
+
+func isUndefinedObject(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == pgUndefinedObject
+}
+
+func (r *repoPostgres) CreateUser(ctx context.Context, user models.User, passwordHash string) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	const CreateUser = `INSERT INTO users(Nickname, FullName, About, Email) VALUES ($1, $2, $3, $4);`
+	const SetPassword = `INSERT INTO user_password(Nickname, Hash) VALUES ($1, $2);`
+	var err error
+	if passwordHash == "" {
+		_, err = r.Conn.Exec(ctx, CreateUser, user.NickName, user.FullName, user.About, user.Email)
+	} else {
+		// Пользователь без пароля не смог бы войти, а повторное создание вернуло бы 409.
+		err = r.inTx(ctx, func(tx pgx.Tx) error {
+			if _, err := tx.Exec(ctx, CreateUser, user.NickName, user.FullName, user.About, user.Email); err != nil {
+				return err
+			}
+			_, err := tx.Exec(ctx, SetPassword, user.NickName, passwordHash)
+			return err
+		})
+	}
+	if isUniqueViolation(err) {
+		return errs.New(errs.CodeConflict, "user with nickname %s or email %s already exists", user.NickName, user.Email)
+	}
+	return errs.Wrap(err, "create user %s", user.NickName)
 }
 
 func (r *repoPostgres) CheckUserForUniq(ctx context.Context, user models.User) ([]models.User, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
 	const CheckUserForUniq = `SELECT * FROM users WHERE Nickname = $1 OR Email = $2;`
 	rows, err := r.Conn.Query(ctx, CheckUserForUniq, user.NickName, user.Email)
 	if err != nil {
-		return nil, err
+		return nil, errs.Wrap(err, "check user %s", user.NickName)
 	}
 	defer rows.Close()
 
@@ -43,91 +111,121 @@ func (r *repoPostgres) CheckUserForUniq(ctx context.Context, user models.User) (
 		var u models.User
 		err := rows.Scan(&u.NickName, &u.FullName, &u.About, &u.Email)
 		if err != nil {
-			return nil, err
+			return nil, errs.Wrap(err, "check user %s", user.NickName)
 		}
 		users = append(users, u)
 	}
 	if rows.Err() != nil {
-		return nil, rows.Err()
+		return nil, errs.Wrap(rows.Err(), "check user %s", user.NickName)
 	}
 	return users, nil
 }
 
 func (r *repoPostgres) GetUser(ctx context.Context, nickname string) (models.User, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
 	const GetUser = `SELECT * FROM users WHERE Nickname = $1;`
 
 	var fUser models.User
 	err := r.Conn.QueryRow(ctx, GetUser, nickname).Scan(&fUser.NickName, &fUser.FullName, &fUser.About, &fUser.Email)
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return models.User{}, nil
+			return models.User{}, errs.New(errs.CodeNotFound, "Can't find user by nickname: %s", nickname)
 		}
-		return models.User{}, err
+		return models.User{}, errs.Wrap(err, "get user %s", nickname)
 	}
 	return fUser, nil
 }
 
-func (r *repoPostgres) ChangeUserInfo(ctx context.Context, user models.User) (models.User, int) {
+func (r *repoPostgres) ChangeUserInfo(ctx context.Context, user models.User) (models.User, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
 	const ChangeUserInfo = `UPDATE users SET FullName = $1, About = $2, Email = $3 WHERE Nickname = $4;`
-	_, err := r.Conn.Exec(ctx, ChangeUserInfo, user.FullName, user.About, user.Email, user.NickName)
-	if err == nil {
-		return user, http.StatusOK
+	tag, err := r.Conn.Exec(ctx, ChangeUserInfo, user.FullName, user.About, user.Email, user.NickName)
+	if isUniqueViolation(err) {
+		return models.User{}, errs.New(errs.CodeConflict, "email %s is already taken", user.Email).WithField("email")
+	}
+	if err != nil {
+		return models.User{}, errs.Wrap(err, "change user %s", user.NickName)
 	}
-	return models.User{}, http.StatusInternalServerError
+	if tag.RowsAffected() == 0 {
+		return models.User{}, errs.New(errs.CodeNotFound, "Can't find user by nickname: %s", user.NickName)
+	}
+	return user, nil
 }
 
-func (r *repoPostgres) CheckForumForUniq(ctx context.Context, forum models.Forum) ([]models.Forum, int) {
-	const CheckForumForUniq = `SELECT * FROM forum WHERE Slug = $1;`
+func (r *repoPostgres) CheckForumForUniq(ctx context.Context, forum models.Forum) ([]models.Forum, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	const CheckForumForUniq = `SELECT Title, "user", Slug, Posts, Threads, COALESCE(Language::text, '') FROM forum WHERE Slug = $1;`
 	rows, err := r.Conn.Query(ctx, CheckForumForUniq, forum.Slug)
 	if err != nil {
-		return nil, http.StatusInternalServerError
+		return nil, errs.Wrap(err, "check forum %s", forum.Slug)
 	}
 	defer rows.Close()
 
 	var forums []models.Forum
 	for rows.Next() {
 		var f models.Forum
-		err := rows.Scan(&f.Title, &f.User, &f.Slug, &f.Posts, &f.Threads)
+		err := rows.Scan(&f.Title, &f.User, &f.Slug, &f.Posts, &f.Threads, &f.Language)
 		if err != nil {
-			return nil, http.StatusInternalServerError
+			return nil, errs.Wrap(err, "check forum %s", forum.Slug)
 		}
 		forums = append(forums, f)
 	}
 	if rows.Err() != nil {
-		return nil, http.StatusInternalServerError
+		return nil, errs.Wrap(rows.Err(), "check forum %s", forum.Slug)
 	}
-	return forums, http.StatusOK
+	return forums, nil
 }
 
-func (r *repoPostgres) CreateForum(ctx context.Context, forum models.Forum) ([]models.Forum, int) {
-	const CreateForum = `INSERT INTO forum(Title, "user", Slug, Posts, Threads) VALUES ($1, $2, $3, $4, $5);`
-	_, err := r.Conn.Exec(ctx, CreateForum, forum.Title, forum.User, forum.Slug, forum.Posts, forum.Threads)
+func (r *repoPostgres) CreateForum(ctx context.Context, forum models.Forum) (models.Forum, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	const CreateForum = `INSERT INTO forum(Title, "user", Slug, Posts, Threads, Language) VALUES ($1, $2, $3, $4, $5, NULLIF($6, '')::regconfig);`
+	_, err := r.Conn.Exec(ctx, CreateForum, forum.Title, forum.User, forum.Slug, forum.Posts, forum.Threads, forum.Language)
+	if isUniqueViolation(err) {
+		return models.Forum{}, errs.New(errs.CodeConflict, "forum %s already exists", forum.Slug)
+	}
+	if isUndefinedObject(err) {
+		return models.Forum{}, errs.New(errs.CodeBadRequest, "unknown text search configuration %q", forum.Language).WithField("language")
+	}
 	if err != nil {
-		return []models.Forum{}, http.StatusInternalServerError
+		return models.Forum{}, errs.Wrap(err, "create forum %s", forum.Slug)
 	}
-	return []models.Forum{forum}, http.StatusCreated
+	return forum, nil
 }
 
 func (r *repoPostgres) GetForumDetails(ctx context.Context, slug string) (models.Forum, error) {
-	const GetForumDetails = `SELECT * FROM forum WHERE Slug = $1;`
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	const GetForumDetails = `SELECT Title, "user", Slug, Posts, Threads, COALESCE(Language::text, '') FROM forum WHERE Slug = $1;`
 
 	var fForum models.Forum
 	err := r.Conn.QueryRow(ctx, GetForumDetails, slug).
-		Scan(&fForum.Title, &fForum.User, &fForum.Slug, &fForum.Posts, &fForum.Threads)
+		Scan(&fForum.Title, &fForum.User, &fForum.Slug, &fForum.Posts, &fForum.Threads, &fForum.Language)
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return models.Forum{}, nil
+			return models.Forum{}, errs.New(errs.CodeNotFound, "Can't find forum by slug: %s", slug)
 		}
-		return models.Forum{}, err
+		return models.Forum{}, errs.Wrap(err, "get forum %s", slug)
 	}
 	return fForum, nil
 }
 
-func (r *repoPostgres) CheckThreadForUniq(ctx context.Context, thread models.Thread) ([]models.Thread, int) {
-	const CheckThreadForUniq = `SELECT * FROM thread WHERE Slug = $1;`
+func (r *repoPostgres) CheckThreadForUniq(ctx context.Context, thread models.Thread) ([]models.Thread, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	const CheckThreadForUniq = `SELECT Id, Title, Author, Forum, Message, Votes, Slug, Created FROM thread WHERE Slug = $1;`
 	rows, err := r.Conn.Query(ctx, CheckThreadForUniq, thread.Slug)
 	if err != nil {
-		return nil, http.StatusInternalServerError
+		return nil, errs.Wrap(err, "check thread %s", thread.Slug)
 	}
 	defer rows.Close()
 
@@ -136,45 +234,65 @@ func (r *repoPostgres) CheckThreadForUniq(ctx context.Context, thread models.Thr
 		var t models.Thread
 		err := rows.Scan(&t.ID, &t.Title, &t.Author, &t.Forum, &t.Message, &t.Votes, &t.Slug, &t.Created)
 		if err != nil {
-			return nil, http.StatusInternalServerError
+			return nil, errs.Wrap(err, "check thread %s", thread.Slug)
 		}
 		threads = append(threads, t)
 	}
 	if rows.Err() != nil {
-		return nil, http.StatusInternalServerError
+		return nil, errs.Wrap(rows.Err(), "check thread %s", thread.Slug)
 	}
-	return threads, http.StatusOK
+	return threads, nil
 }
 
-func (r *repoPostgres) CreateThread(ctx context.Context, thread models.Thread) ([]models.Thread, int) {
+func (r *repoPostgres) CreateThread(ctx context.Context, thread models.Thread) (models.Thread, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
 	const CreateThread = `INSERT INTO thread(Title, Author, Forum, Message, Votes, Slug, Created) VALUES ($1, $2, $3, $4, $5, $6, $7) returning Id;`
-	err := r.Conn.QueryRow(ctx, CreateThread, thread.Title, thread.Author, thread.Forum,
-		thread.Message, thread.Votes, thread.Slug, thread.Created).Scan(&thread.ID)
+	err := r.inTx(ctx, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx, CreateThread, thread.Title, thread.Author, thread.Forum,
+			thread.Message, thread.Votes, thread.Slug, thread.Created).Scan(&thread.ID)
+		if err != nil {
+			return errs.Wrap(err, "create thread %s", thread.Title)
+		}
+		return r.writes.threadCreated(ctx, tx, thread)
+	})
 	if err != nil {
-		return []models.Thread{}, http.StatusInternalServerError
+		return models.Thread{}, err
 	}
-	return []models.Thread{thread}, http.StatusCreated
+	return thread, nil
 }
 
 func (r *repoPostgres) GetThreads(ctx context.Context, slug string, params models.RequestParameters) ([]models.Thread, error) {
-	var GetThreads = `SELECT * FROM thread WHERE Forum = $1`
-
-	if params.Desc {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var GetThreads = `SELECT Id, Title, Author, Forum, Message, Votes, Slug, Created FROM thread WHERE Forum = $1`
+	args := []interface{}{slug, params.Since, params.Limit}
+
+	switch {
+	case params.SinceID != 0 && params.Desc:
+		GetThreads = GetThreads + ` AND (created, id) < ($2::timestamptz, $4) ORDER BY created DESC, id DESC`
+		args = append(args, params.SinceID)
+	case params.SinceID != 0:
+		GetThreads = GetThreads + ` AND (created, id) > ($2::timestamptz, $4) ORDER BY created, id`
+		args = append(args, params.SinceID)
+	case params.Desc:
 		GetThreads = GetThreads + ` AND created <= $2 ORDER BY created DESC, id DESC`
 		if params.Since == "" {
-			params.Since = "9999-12-31T23:59:59.000Z"
+			args[1] = "9999-12-31T23:59:59.000Z"
 		}
-	} else {
+	default:
 		GetThreads = GetThreads + ` AND created >= $2  ORDER BY created, id`
 		if params.Since == "" {
-			params.Since = "0001-01-01T00:00:00.000Z"
+			args[1] = "0001-01-01T00:00:00.000Z"
 		}
 	}
 	GetThreads = GetThreads + ` LIMIT $3;`
 
-	rows, err := r.Conn.Query(ctx, GetThreads, slug, params.Since, params.Limit)
+	rows, err := r.Conn.Query(ctx, GetThreads, args...)
 	if err != nil {
-		return nil, err
+		return nil, errs.Wrap(err, "get threads of forum %s", slug)
 	}
 	defer rows.Close()
 
@@ -183,200 +301,225 @@ func (r *repoPostgres) GetThreads(ctx context.Context, slug string, params model
 		var t models.Thread
 		err := rows.Scan(&t.ID, &t.Title, &t.Author, &t.Forum, &t.Message, &t.Votes, &t.Slug, &t.Created)
 		if err != nil {
-			return nil, err
+			return nil, errs.Wrap(err, "get threads of forum %s", slug)
 		}
 		fThreads = append(fThreads, t)
 	}
 	if rows.Err() != nil {
-		return nil, rows.Err()
+		return nil, errs.Wrap(rows.Err(), "get threads of forum %s", slug)
 	}
 	return fThreads, nil
 }
 
 func (r *repoPostgres) GetThreadBySlug(ctx context.Context, slug string) (models.Thread, error) {
-	const GetThreadBySlug = `SELECT * FROM thread WHERE Slug = $1;`
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	const GetThreadBySlug = `SELECT Id, Title, Author, Forum, Message, Votes, Slug, Created FROM thread WHERE Slug = $1;`
 	var fThread models.Thread
 	err := r.Conn.QueryRow(ctx, GetThreadBySlug, slug).
 		Scan(&fThread.ID, &fThread.Title, &fThread.Author, &fThread.Forum,
 			&fThread.Message, &fThread.Votes, &fThread.Slug, &fThread.Created)
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return models.Thread{}, nil
+			return models.Thread{}, errs.New(errs.CodeNotFound, "Can't find thread by slug: %s", slug)
 		}
-		return models.Thread{}, err
+		return models.Thread{}, errs.Wrap(err, "get thread %s", slug)
 	}
 	return fThread, nil
 }
 
 func (r *repoPostgres) GetThreadById(ctx context.Context, id int) (models.Thread, error) {
-	const GetThreadBySlug = `SELECT * FROM thread WHERE Id = $1;`
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	const GetThreadBySlug = `SELECT Id, Title, Author, Forum, Message, Votes, Slug, Created FROM thread WHERE Id = $1;`
 	var fThread models.Thread
 	err := r.Conn.QueryRow(ctx, GetThreadBySlug, id).
 		Scan(&fThread.ID, &fThread.Title, &fThread.Author, &fThread.Forum,
 			&fThread.Message, &fThread.Votes, &fThread.Slug, &fThread.Created)
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return models.Thread{}, nil
+			return models.Thread{}, errs.New(errs.CodeNotFound, "Can't find thread by id: %d", id)
 		}
-		return models.Thread{}, err
+		return models.Thread{}, errs.Wrap(err, "get thread %d", id)
 	}
 	return fThread, nil
 }
 
-func (r *repoPostgres) CreatePosts(ctx context.Context, posts []models.Post, thread models.Thread) ([]models.Post, int) {
-	created := time.Now()
-	uniqueParents := mapset.NewSet()
-	var uniqueParentsFlag bool
-	uniqueAuthors := mapset.NewSet()
-	values := make([]interface{}, 0)
-	query := "INSERT INTO post (Author, Created, Forum, IsEdited, Message, Parent, Thread) VALUES"
+// CreatePosts вставляет пачку постов в одной транзакции. Авторы и родители блокируются FOR SHARE,
+// чтобы их не могли удалить или перенести между проверкой и вставкой. В ошибке поле Field
+// указывает на первый неподходящий пост, например "posts[2].parent".
+func (r *repoPostgres) CreatePosts(ctx context.Context, posts []models.Post, thread models.Thread) ([]models.Post, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
 
+	created := time.Now()
+	authors := make([]string, 0, len(posts))
+	parents := make([]int, 0, len(posts))
 	for k := range posts {
 		post := &posts[k]
 		post.Forum = thread.Forum
 		post.Thread = thread.ID
 		post.Created = created
 
+		if post.Author == "" {
+			return nil, errs.New(errs.CodeBadRequest, "post author is required").WithField(fmt.Sprintf("posts[%d].author", k))
+		}
+		authors = append(authors, post.Author)
 		if post.Parent != 0 {
-			uniqueParentsFlag = true
+			parents = append(parents, post.Parent)
 		}
-		uniqueParents.Add(post.Parent)
+	}
 
-		if post.Author == "" {
-			return nil, http.StatusBadRequest
-		}
-		uniqueAuthors.Add(post.Author)
-
-		query += fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d),", k*7+1, k*7+2, k*7+3, k*7+4, k*7+5, k*7+6, k*7+7)
-		values = append(values, post.Author, post.Created, post.Forum, post.IsEdited, post.Message, post.Parent, post.Thread)
-	}
-	query = query[:len(query)-1]
-	query += ` RETURNING id, path, created;`
-
-	queryCheckAuthors := "SELECT EXISTS (SELECT 1 FROM users WHERE Nickname IN ("
-	it := 0
-	argsNickname := make([]interface{}, uniqueAuthors.Cardinality())
-	for author := range uniqueAuthors.Iter() {
-		queryCheckAuthors += fmt.Sprintf(" $%d,", it+1)
-		argsNickname[it] = author
-		it++
-	}
-	queryCheckAuthors = queryCheckAuthors[:len(queryCheckAuthors)-1] + "));"
-	var existsAuthor bool
-	errAuthor := r.Conn.QueryRow(ctx, queryCheckAuthors, argsNickname...).Scan(&existsAuthor)
-	if errAuthor != nil {
-		return nil, http.StatusInternalServerError
-	}
-	if !existsAuthor {
-		return nil, http.StatusNotFound
-	}
-
-	if uniqueParentsFlag {
-		args := make([]interface{}, uniqueParents.Cardinality())
-		i := 0
-		queryCheckParents := "SELECT EXISTS (SELECT 1 FROM post WHERE Id IN ("
-		queryCheckParentsPart2 := ")) as exists, thread FROM post WHERE Id IN ("
-		for id := range uniqueParents.Iter() {
-			queryCheckParents += fmt.Sprintf(" $%d,", i+1)
-			queryCheckParentsPart2 += fmt.Sprintf(" $%d,", i+1)
-			args[i] = id
-			i++
-		}
-		queryCheckParentsPart2 = queryCheckParentsPart2[:len(queryCheckParentsPart2)-1] + ");"
-		queryCheckParents = queryCheckParents[:len(queryCheckParents)-1] + queryCheckParentsPart2
-
-		var exists bool
-		var fThread int
-		rows, err := r.Conn.Query(ctx, queryCheckParents, args...)
+	err := r.inTx(ctx, func(tx pgx.Tx) error {
+		if err := lockPostAuthors(ctx, tx, posts, authors); err != nil {
+			return err
+		}
+		parentPaths, err := lockPostParents(ctx, tx, posts, parents, thread.ID)
 		if err != nil {
-			return []models.Post{}, http.StatusInternalServerError
-		}
-		defer rows.Close()
-
-		for j := 0; j < uniqueParents.Cardinality(); j++ {
-			if rows.Next() {
-				err = rows.Scan(&exists, &fThread)
-				if err != nil {
-					return nil, http.StatusInternalServerError
-				}
-				if fThread != thread.ID {
-					return nil, http.StatusConflict
-				}
-			}
+			return err
 		}
-		if rows.Err() != nil {
-			return nil, http.StatusInternalServerError
+		return r.writes.insertPosts(ctx, tx, posts, parentPaths)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
+// lockPostAuthors проверяет, что все авторы существуют, и не даёт удалить их до конца транзакции.
+func lockPostAuthors(ctx context.Context, tx pgx.Tx, posts []models.Post, authors []string) error {
+	const LockAuthors = `SELECT Nickname FROM users WHERE Nickname = ANY($1::text[]::citext[]) FOR SHARE;`
+	rows, err := tx.Query(ctx, LockAuthors, authors)
+	if err != nil {
+		return errs.Wrap(err, "lock post authors")
+	}
+	defer rows.Close()
+
+	existing := make(map[string]struct{})
+	for rows.Next() {
+		var nickname string
+		if err := rows.Scan(&nickname); err != nil {
+			return errs.Wrap(err, "lock post authors")
 		}
+		existing[strings.ToLower(nickname)] = struct{}{}
+	}
+	if rows.Err() != nil {
+		return errs.Wrap(rows.Err(), "lock post authors")
+	}
 
-		if !exists {
-			return []models.Post{}, http.StatusConflict
+	for k, post := range posts {
+		if _, ok := existing[strings.ToLower(post.Author)]; !ok {
+			return errs.New(errs.CodeNotFound, "Can't find post author by nickname: %s", post.Author).
+				WithField(fmt.Sprintf("posts[%d].author", k))
 		}
 	}
+	return nil
+}
+
+// lockPostParents проверяет, что родители существуют и лежат в той же ветке, и возвращает их пути.
+func lockPostParents(ctx context.Context, tx pgx.Tx, posts []models.Post, parents []int, threadID int) (map[int][]int32, error) {
+	paths := make(map[int][]int32)
+	if len(parents) == 0 {
+		return paths, nil
+	}
 
-	rows, err := r.Conn.Query(ctx, query, values...)
+	const LockParents = `SELECT Id, Thread, Path FROM post WHERE Id = ANY($1) FOR SHARE;`
+	rows, err := tx.Query(ctx, LockParents, parents)
 	if err != nil {
-		return []models.Post{}, http.StatusInternalServerError
+		return nil, errs.Wrap(err, "lock post parents")
 	}
 	defer rows.Close()
 
-	var pathf pgtype.Int4Array
-	var ftime time.Time
-	for i, _ := range posts {
-		if rows.Next() {
-			err = rows.Scan(&posts[i].ID, &pathf, &ftime)
-			posts[i].Created = ftime
-			fmt.Println(posts[i].ID, pathf, ftime)
-			if err != nil {
-				fmt.Println("err", err)
-				return nil, http.StatusInternalServerError
-			}
+	parentThreads := make(map[int]int)
+	for rows.Next() {
+		var id, thread int
+		var path []int32
+		if err := rows.Scan(&id, &thread, &path); err != nil {
+			return nil, errs.Wrap(err, "lock post parents")
 		}
+		parentThreads[id] = thread
+		paths[id] = path
 	}
 	if rows.Err() != nil {
-		return nil, http.StatusInternalServerError
+		return nil, errs.Wrap(rows.Err(), "lock post parents")
+	}
+
+	for k, post := range posts {
+		if post.Parent == 0 {
+			continue
+		}
+		thread, ok := parentThreads[post.Parent]
+		if !ok {
+			return nil, errs.New(errs.CodeConflict, "Parent post %d does not exist", post.Parent).
+				WithField(fmt.Sprintf("posts[%d].parent", k))
+		}
+		if thread != threadID {
+			return nil, errs.New(errs.CodeConflict, "Parent post %d was created in another thread", post.Parent).
+				WithField(fmt.Sprintf("posts[%d].parent", k))
+		}
 	}
-	return posts, http.StatusCreated
+	return paths, nil
 }
 
+// ChangeVote возвращает ветку с уже пересчитанной суммой голосов.
 func (r *repoPostgres) ChangeVote(ctx context.Context, vote models.Vote, thread models.Thread) (models.Thread, error) {
-	const GetVote = `SELECT Author, Voice, Thread FROM vote WHERE Author = $1 AND Thread = $2;`
-	var fVote models.Vote
-	err := r.Conn.QueryRow(ctx, GetVote, vote.Nickname, vote.Thread).Scan(&fVote.Nickname, &fVote.Voice, &fVote.Thread)
-	if err != nil {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	changed := false
+	err := r.inTx(ctx, func(tx pgx.Tx) error {
+		const GetVote = `SELECT Voice FROM vote WHERE Author = $1 AND Thread = $2 FOR UPDATE;`
+		var voice int
+		err := tx.QueryRow(ctx, GetVote, vote.Nickname, vote.Thread).Scan(&voice)
 		if err == pgx.ErrNoRows {
 			const CreateVote = `INSERT INTO vote(Author, Voice, Thread) VALUES ($1, $2, $3);`
-			_, err := r.Conn.Exec(ctx, CreateVote, vote.Nickname, vote.Voice, vote.Thread)
-			if err != nil {
-				return models.Thread{}, err
+			if _, err := tx.Exec(ctx, CreateVote, vote.Nickname, vote.Voice, vote.Thread); err != nil {
+				return errs.Wrap(err, "create vote")
 			}
-			return models.Thread{}, nil
-		} else {
-			return models.Thread{}, err
+			changed = true
+			return r.writes.voteChanged(ctx, tx, vote.Thread, vote.Voice)
+		}
+		if err != nil {
+			return errs.Wrap(err, "get vote")
+		}
+		if voice == vote.Voice {
+			return nil
 		}
-	}
-
-	if fVote.Voice == vote.Voice {
-		return thread, nil
-	}
 
-	const UpdateVote = `UPDATE vote SET Voice=$1 WHERE Author=$2 AND Thread=$3;`
-	_, err = r.Conn.Exec(ctx, UpdateVote, vote.Voice, vote.Nickname, vote.Thread)
+		const UpdateVote = `UPDATE vote SET Voice=$1 WHERE Author=$2 AND Thread=$3;`
+		if _, err := tx.Exec(ctx, UpdateVote, vote.Voice, vote.Nickname, vote.Thread); err != nil {
+			return errs.Wrap(err, "update vote")
+		}
+		changed = true
+		return r.writes.voteChanged(ctx, tx, vote.Thread, vote.Voice-voice)
+	})
 	if err != nil {
 		return models.Thread{}, err
 	}
-	return models.Thread{}, nil
+	if !changed {
+		return thread, nil
+	}
+	return r.GetThreadById(ctx, vote.Thread)
 }
 
-func (r *repoPostgres) ChangeThreadInfo(ctx context.Context, thread models.Thread) (models.Thread, int) {
+func (r *repoPostgres) ChangeThreadInfo(ctx context.Context, thread models.Thread) (models.Thread, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
 	const ChangeThreadInfo = `UPDATE thread SET Title = $1, Message = $2 WHERE Id = $3;`
 	_, err := r.Conn.Exec(ctx, ChangeThreadInfo, thread.Title, thread.Message, thread.ID)
-	if err == nil {
-		return thread, http.StatusOK
+	if err != nil {
+		return models.Thread{}, errs.Wrap(err, "change thread %d", thread.ID)
 	}
-	return models.Thread{}, http.StatusInternalServerError
+	return thread, nil
 }
 
 func (r *repoPostgres) GetUsers(ctx context.Context, slug string, params models.RequestParameters) ([]models.User, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
 	var GetUsers = `SELECT Nickname, FullName, About, Email FROM users_forum WHERE Slug = $1`
 	var rows pgx.Rows
 	var err error
@@ -403,7 +546,7 @@ func (r *repoPostgres) GetUsers(ctx context.Context, slug string, params models.
 		rows, err = r.Conn.Query(ctx, GetUsers, slug, params.Since, params.Limit)
 	}
 	if err != nil {
-		return nil, err
+		return nil, errs.Wrap(err, "get users of forum %s", slug)
 	}
 	defer rows.Close()
 
@@ -412,199 +555,223 @@ func (r *repoPostgres) GetUsers(ctx context.Context, slug string, params models.
 		var u models.User
 		err := rows.Scan(&u.NickName, &u.FullName, &u.About, &u.Email)
 		if err != nil {
-			return nil, err
+			return nil, errs.Wrap(err, "get users of forum %s", slug)
 		}
 		fUsers = append(fUsers, u)
 	}
 	if rows.Err() != nil {
-		return nil, rows.Err()
+		return nil, errs.Wrap(rows.Err(), "get users of forum %s", slug)
 	}
 	return fUsers, nil
 }
 
+// GetPostDetails заполняет Author, Forum и Thread только если они перечислены в related.
 func (r *repoPostgres) GetPostDetails(ctx context.Context, id int, related []string) (models.PostDetailed, error) {
-	var (
-		flagUser   bool
-		flagForum  bool
-		flagThread bool
-	)
-	var errScan error
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
 	var fPost models.PostDetailed
-	var fAuthor models.User
-	var fForum models.Forum
-	var fThread models.Thread
-	fPost.Author = &fAuthor
-	fPost.Forum = &fForum
-	fPost.Thread = &fThread
+	GetPostDetails := "SELECT post.Id, post.Author, post.Created, post.Forum, post.isEdited, " +
+		"post.Message, post.Parent, post.Thread, post.IsDeleted"
+	joins := " FROM post"
+	dest := []interface{}{&fPost.Post.ID, &fPost.Post.Author, &fPost.Post.Created,
+		&fPost.Post.Forum, &fPost.Post.IsEdited, &fPost.Post.Message, &fPost.Post.Parent, &fPost.Post.Thread, &fPost.Post.IsDeleted}
 
 	for _, param := range related {
-		if param == "user" {
-			flagUser = true
-		}
-		if param == "forum" {
-			flagForum = true
-		}
-		if param == "thread" {
-			flagThread = true
+		switch {
+		case param == "user" && fPost.Author == nil:
+			fAuthor := &models.User{}
+			fPost.Author = fAuthor
+			GetPostDetails += ", users.Nickname, users.FullName, users.About, users.Email"
+			joins += " JOIN users ON post.Author = users.Nickname"
+			dest = append(dest, &fAuthor.NickName, &fAuthor.FullName, &fAuthor.About, &fAuthor.Email)
+		case param == "forum" && fPost.Forum == nil:
+			fForum := &models.Forum{}
+			fPost.Forum = fForum
+			GetPostDetails += ", forum.Title, forum.\"user\", forum.Slug, forum.Posts, forum.Threads"
+			joins += " JOIN forum ON post.Forum = forum.Slug"
+			dest = append(dest, &fForum.Title, &fForum.User, &fForum.Slug, &fForum.Posts, &fForum.Threads)
+		case param == "thread" && fPost.Thread == nil:
+			fThread := &models.Thread{}
+			fPost.Thread = fThread
+			GetPostDetails += ", thread.Id, thread.Title, thread.Author, thread.Forum, " +
+				"thread.Message, thread.Votes, thread.Slug, thread.Created"
+			joins += " JOIN thread ON post.Thread = thread.Id"
+			dest = append(dest, &fThread.ID, &fThread.Title, &fThread.Author, &fThread.Forum,
+				&fThread.Message, &fThread.Votes, &fThread.Slug, &fThread.Created)
 		}
 	}
+	GetPostDetails += joins + ` WHERE post.Id = $1;`
 
-	var GetPostDetails = "SELECT post.Id, post.Author, post.Created, post.Forum, post.isEdited, " +
-		"post.Message, post.Parent, post.Thread"
-
-	if flagUser {
-		GetPostDetails += ", users.Nickname, users.FullName, users.About, users.Email"
-	}
-	if flagForum {
-		GetPostDetails += ", forum.Title, forum.\"user\", forum.Slug, forum.Posts, forum.Threads"
-	}
-	if flagThread {
-		GetPostDetails += ", thread.Id, thread.Title, thread.Author, thread.Forum, " +
-			"thread.Message, thread.Votes, thread.Slug, thread.Created"
-	}
-	GetPostDetails += " FROM post"
-
-	if flagUser {
-		GetPostDetails += " JOIN users ON post.Author = users.Nickname"
-	}
-	if flagForum {
-		GetPostDetails += " JOIN forum ON post.Forum = forum.Slug"
-	}
-	if flagThread {
-		GetPostDetails += " JOIN thread ON post.Thread = thread.Id"
-	}
-	GetPostDetails += ` WHERE post.Id = $1;`
-
-	if flagUser && flagForum && flagThread {
-		errScan = r.Conn.QueryRow(ctx, GetPostDetails, id).Scan(&fPost.Post.ID, &fPost.Post.Author, &fPost.Post.Created,
-			&fPost.Post.Forum, &fPost.Post.IsEdited, &fPost.Post.Message, &fPost.Post.Parent, &fPost.Post.Thread,
-			&fAuthor.NickName, &fAuthor.FullName, &fAuthor.About, &fAuthor.Email,
-			&fForum.Title, &fForum.User, &fForum.Slug, &fForum.Posts, &fForum.Threads,
-			&fThread.ID, &fThread.Title, &fThread.Author, &fThread.Forum,
-			&fThread.Message, &fThread.Votes, &fThread.Slug, &fThread.Created)
-	} else {
-		if flagUser && flagForum {
-			errScan = r.Conn.QueryRow(ctx, GetPostDetails, id).Scan(&fPost.Post.ID, &fPost.Post.Author, &fPost.Post.Created,
-				&fPost.Post.Forum, &fPost.Post.IsEdited, &fPost.Post.Message, &fPost.Post.Parent, &fPost.Post.Thread,
-				&fAuthor.NickName, &fAuthor.FullName, &fAuthor.About, &fAuthor.Email,
-				&fForum.Title, &fForum.User, &fForum.Slug, &fForum.Posts, &fForum.Threads)
-		} else {
-			if flagUser && flagThread {
-				errScan = r.Conn.QueryRow(ctx, GetPostDetails, id).Scan(&fPost.Post.ID, &fPost.Post.Author, &fPost.Post.Created,
-					&fPost.Post.Forum, &fPost.Post.IsEdited, &fPost.Post.Message, &fPost.Post.Parent, &fPost.Post.Thread,
-					&fAuthor.NickName, &fAuthor.FullName, &fAuthor.About, &fAuthor.Email,
-					&fThread.ID, &fThread.Title, &fThread.Author, &fThread.Forum,
-					&fThread.Message, &fThread.Votes, &fThread.Slug, &fThread.Created)
-			} else {
-				if flagForum && flagThread {
-					errScan = r.Conn.QueryRow(ctx, GetPostDetails, id).Scan(&fPost.Post.ID, &fPost.Post.Author, &fPost.Post.Created,
-						&fPost.Post.Forum, &fPost.Post.IsEdited, &fPost.Post.Message, &fPost.Post.Parent, &fPost.Post.Thread,
-						&fForum.Title, &fForum.User, &fForum.Slug, &fForum.Posts, &fForum.Threads,
-						&fThread.ID, &fThread.Title, &fThread.Author, &fThread.Forum,
-						&fThread.Message, &fThread.Votes, &fThread.Slug, &fThread.Created)
-				} else {
-					if flagUser {
-						errScan = r.Conn.QueryRow(ctx, GetPostDetails, id).Scan(&fPost.Post.ID, &fPost.Post.Author, &fPost.Post.Created,
-							&fPost.Post.Forum, &fPost.Post.IsEdited, &fPost.Post.Message, &fPost.Post.Parent, &fPost.Post.Thread,
-							&fAuthor.NickName, &fAuthor.FullName, &fAuthor.About, &fAuthor.Email)
-					} else {
-						if flagForum {
-							errScan = r.Conn.QueryRow(ctx, GetPostDetails, id).Scan(&fPost.Post.ID, &fPost.Post.Author, &fPost.Post.Created,
-								&fPost.Post.Forum, &fPost.Post.IsEdited, &fPost.Post.Message, &fPost.Post.Parent, &fPost.Post.Thread,
-								&fForum.Title, &fForum.User, &fForum.Slug, &fForum.Posts, &fForum.Threads)
-						} else {
-							if flagThread {
-								errScan = r.Conn.QueryRow(ctx, GetPostDetails, id).Scan(&fPost.Post.ID, &fPost.Post.Author, &fPost.Post.Created,
-									&fPost.Post.Forum, &fPost.Post.IsEdited, &fPost.Post.Message, &fPost.Post.Parent, &fPost.Post.Thread,
-									&fThread.ID, &fThread.Title, &fThread.Author, &fThread.Forum,
-									&fThread.Message, &fThread.Votes, &fThread.Slug, &fThread.Created)
-							} else {
-								errScan = r.Conn.QueryRow(ctx, GetPostDetails, id).Scan(&fPost.Post.ID, &fPost.Post.Author, &fPost.Post.Created,
-									&fPost.Post.Forum, &fPost.Post.IsEdited, &fPost.Post.Message, &fPost.Post.Parent, &fPost.Post.Thread)
-							}
-						}
-					}
-				}
-			}
-		}
-	}
+	errScan := r.Conn.QueryRow(ctx, GetPostDetails, id).Scan(dest...)
 	if errScan != nil {
 		if errScan == pgx.ErrNoRows {
-			return models.PostDetailed{}, nil
+			return models.PostDetailed{}, errs.New(errs.CodeNotFound, "Can't find post with id: %d", id)
 		}
-		return models.PostDetailed{}, errScan
+		return models.PostDetailed{}, errs.Wrap(errScan, "get post %d", id)
+	}
+	fPost.Post = tombstone(fPost.Post)
+	if fPost.Post.IsDeleted {
+		fPost.Author = nil
 	}
 	return fPost, nil
-	// SELECT post.*, author.*
-	//FROM (SELECT * FROM post WHERE id = $1) AS post
-	//JOIN author ON post.author = author.id;
 }
 
-func (r *repoPostgres) ChangePostInfo(ctx context.Context, post models.Post) (models.Post, int) {
+// ChangePostInfo сохраняет новое сообщение очередной ревизией. Перед первой правкой
+// исходный текст записывается ревизией 1 со временем создания и автором поста.
+func (r *repoPostgres) ChangePostInfo(ctx context.Context, post models.Post, editor string) (models.Post, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	const LockPost = `SELECT Id FROM post WHERE Id = $1 FOR UPDATE;`
+	const SaveOriginal = `INSERT INTO post_revisions (Post, Revision, Message, Editor, Created)
+SELECT Id, 1, Message, Author, COALESCE(Created, now()) FROM post WHERE Id = $1
+ON CONFLICT DO NOTHING;`
+	const AddRevision = `INSERT INTO post_revisions (Post, Revision, Message, Editor)
+SELECT $1, max(Revision) + 1, $2, NULLIF($3, '') FROM post_revisions WHERE Post = $1;`
 	const ChangePostInfo = `UPDATE post SET Message = $1, IsEdited = true WHERE Id = $2;`
-	_, err := r.Conn.Exec(ctx, ChangePostInfo, post.Message, post.ID)
-	if err == nil {
-		return post, http.StatusOK
+	err := r.inTx(ctx, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx, LockPost, post.ID).Scan(&post.ID)
+		if err == pgx.ErrNoRows {
+			return errs.New(errs.CodeNotFound, "Can't find post with id: %d", post.ID)
+		}
+		if err != nil {
+			return errs.Wrap(err, "lock post %d", post.ID)
+		}
+		if _, err := tx.Exec(ctx, SaveOriginal, post.ID); err != nil {
+			return errs.Wrap(err, "save original of post %d", post.ID)
+		}
+		if _, err := tx.Exec(ctx, AddRevision, post.ID, post.Message, editor); err != nil {
+			return errs.Wrap(err, "add revision of post %d", post.ID)
+		}
+		_, err = tx.Exec(ctx, ChangePostInfo, post.Message, post.ID)
+		return errs.Wrap(err, "change post %d", post.ID)
+	})
+	if err != nil {
+		return models.Post{}, err
 	}
-	return models.Post{}, http.StatusInternalServerError
+	return post, nil
 }
 
-func (r *repoPostgres) GetStatus(ctx context.Context) (models.Info, int) {
-	countUsers := "SELECT count(*) FROM users"
-	countForums := "SELECT count(*) FROM forum"
-	countThreads := "SELECT count(*) FROM thread"
-	countPosts := "SELECT count(*) FROM post"
-
-	var info models.Info
-
-	err := r.Conn.QueryRow(ctx, countUsers).Scan(&info.Users)
+// GetPostHistory возвращает ревизии по возрастанию; у неправленного поста это одна ревизия из самого поста.
+func (r *repoPostgres) GetPostHistory(ctx context.Context, id int) ([]models.PostRevision, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	const GetHistory = `SELECT Revision, Message, COALESCE(Editor::text, ''), Created FROM post_revisions WHERE Post = $1
+UNION ALL
+SELECT 1, Message::text, Author::text, COALESCE(Created, now()) FROM post
+WHERE Id = $1 AND NOT EXISTS (SELECT 1 FROM post_revisions WHERE Post = $1)
+ORDER BY 1;`
+	rows, err := r.Conn.Query(ctx, GetHistory, id)
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return models.Info{}, http.StatusNotFound
-		}
-		return models.Info{}, http.StatusInternalServerError
+		return nil, errs.Wrap(err, "get history of post %d", id)
 	}
-	err = r.Conn.QueryRow(ctx, countForums).Scan(&info.Forums)
-	if err != nil {
-		if err == pgx.ErrNoRows {
-			return models.Info{}, http.StatusNotFound
+	defer rows.Close()
+
+	var revisions []models.PostRevision
+	for rows.Next() {
+		var rev models.PostRevision
+		if err := rows.Scan(&rev.Revision, &rev.Message, &rev.Editor, &rev.Created); err != nil {
+			return nil, errs.Wrap(err, "get history of post %d", id)
 		}
-		return models.Info{}, http.StatusInternalServerError
+		revisions = append(revisions, rev)
 	}
-	err = r.Conn.QueryRow(ctx, countThreads).Scan(&info.Threads)
-	if err != nil {
-		if err == pgx.ErrNoRows {
-			return models.Info{}, http.StatusNotFound
-		}
-		return models.Info{}, http.StatusInternalServerError
+	if rows.Err() != nil {
+		return nil, errs.Wrap(rows.Err(), "get history of post %d", id)
 	}
-	err = r.Conn.QueryRow(ctx, countPosts).Scan(&info.Posts)
-	if err != nil {
-		if err == pgx.ErrNoRows {
-			return models.Info{}, http.StatusNotFound
-		}
-		return models.Info{}, http.StatusInternalServerError
+	if len(revisions) == 0 {
+		return nil, errs.New(errs.CodeNotFound, "Can't find post with id: %d", id)
 	}
-	return info, http.StatusOK
+	return revisions, nil
+}
+
+func (r *repoPostgres) Clear(ctx context.Context) (models.ClearReport, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	const tables = `users, forum, thread, post, post_revisions, vote, users_forum, user_password, credential, forum_role`
+	// TRUNCATE всё равно берёт эту блокировку; взятая до подсчёта, она не даёт записям
+	// попасть между подсчётом и удалением.
+	const LockAll = `LOCK TABLE ` + tables + ` IN ACCESS EXCLUSIVE MODE;`
+	const ClearAll = `TRUNCATE TABLE ` + tables + ` CASCADE;`
+
+	var report models.ClearReport
+	err := r.inTx(ctx, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, LockAll); err != nil {
+			return errs.Wrap(err, "lock tables to clear")
+		}
+		var err error
+		if report, err = countClear(ctx, tx, ""); err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, ClearAll)
+		return errs.Wrap(err, "clear")
+	})
+	return report, err
+}
+
+// DeletePost оставляет удалённый пост в дереве, чтобы Path потомков остались верными.
+// Повторное удаление и восстановление видимого поста ничего не меняют.
+func (r *repoPostgres) DeletePost(ctx context.Context, id int) (models.Post, error) {
+	return r.setPostDeleted(ctx, id, true)
 }
 
-func (r *repoPostgres) Clear(ctx context.Context) int {
-	const ClearAll = `TRUNCATE TABLE users, forum, thread, post, vote, users_forum  CASCADE;`
-	_, err := r.Conn.Exec(ctx, ClearAll)
+func (r *repoPostgres) RestorePost(ctx context.Context, id int) (models.Post, error) {
+	return r.setPostDeleted(ctx, id, false)
+}
+
+func (r *repoPostgres) setPostDeleted(ctx context.Context, id int, deleted bool) (models.Post, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	const SetDeleted = `UPDATE post SET IsDeleted = $2 WHERE Id = $1 AND IsDeleted <> $2 RETURNING Forum;`
+	const GetPost = `SELECT Id, Author, Created, Forum, IsEdited, Message, Parent, Thread, IsDeleted FROM post WHERE Id = $1;`
+	var post models.Post
+	err := r.inTx(ctx, func(tx pgx.Tx) error {
+		var forum string
+		err := tx.QueryRow(ctx, SetDeleted, id, deleted).Scan(&forum)
+		switch {
+		case err == nil:
+			delta := 1
+			if deleted {
+				delta = -1
+			}
+			if err := r.writes.postVisibilityChanged(ctx, tx, forum, delta); err != nil {
+				return err
+			}
+		case err != pgx.ErrNoRows:
+			return errs.Wrap(err, "set post %d deleted=%t", id, deleted)
+		}
+
+		err = tx.QueryRow(ctx, GetPost, id).Scan(&post.ID, &post.Author, &post.Created, &post.Forum,
+			&post.IsEdited, &post.Message, &post.Parent, &post.Thread, &post.IsDeleted)
+		if err == pgx.ErrNoRows {
+			return errs.New(errs.CodeNotFound, "Can't find post with id: %d", id)
+		}
+		return errs.Wrap(err, "get post %d", id)
+	})
 	if err != nil {
-		return http.StatusInternalServerError
+		return models.Post{}, err
 	}
-	return http.StatusOK
+	return tombstone(post), nil
 }
 
-func (r *repoPostgres) GetPosts(ctx context.Context, idPost int, params models.RequestParameters) ([]models.Post, error) {
-	return []models.Post{}, nil
+// tombstone скрывает текст и автора удалённого поста; в базе они остаются для восстановления.
+func tombstone(post models.Post) models.Post {
+	if post.IsDeleted {
+		post.Message, post.Author = "", ""
+	}
+	return post
 }
 
 func (r *repoPostgres) GetPostsFlat(ctx context.Context, params models.RequestParameters, threadID int) ([]models.Post, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
 	var rows pgx.Rows
 	var err error
-	GetPosts := `SELECT Id, Author, Created, Forum, isEdited, Message, Parent, Thread FROM post WHERE Thread = $1`
+	GetPosts := `SELECT Id, Author, Created, Forum, isEdited, Message, Parent, Thread, IsDeleted FROM post WHERE Thread = $1`
 
 	if params.SinceInt == 0 {
 		if params.Desc {
@@ -626,92 +793,68 @@ func (r *repoPostgres) GetPostsFlat(ctx context.Context, params models.RequestPa
 	}
 
 	if err != nil {
-		return nil, err
+		return nil, errs.Wrap(err, "get posts of thread %d", threadID)
 	}
 	defer rows.Close()
 
 	posts := make([]models.Post, 0)
 	for rows.Next() {
 		p := models.Post{}
-		err := rows.Scan(&p.ID, &p.Author, &p.Created, &p.Forum, &p.IsEdited, &p.Message, &p.Parent, &p.Thread)
+		err := rows.Scan(&p.ID, &p.Author, &p.Created, &p.Forum, &p.IsEdited, &p.Message, &p.Parent, &p.Thread, &p.IsDeleted)
 		if err != nil {
-			return posts, models.InternalError
+			return posts, errs.Wrap(err, "get posts of thread %d", threadID)
 		}
-		fmt.Println(p.ID, " ", p.Parent, " ", p.Path.Elements)
-		posts = append(posts, p)
+		posts = append(posts, tombstone(p))
 	}
 
 	return posts, nil
 }
 
 func (r *repoPostgres) GetPostsTree(ctx context.Context, params models.RequestParameters, thread int) ([]models.Post, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
 	var rows pgx.Rows
 	var errQuery error
-	selectPosts := `SELECT post.Id, post.Author, post.Created, post.Forum, post.IsEdited, post.Message, post.Parent, post.Thread, post.Path
+	selectPosts := `SELECT post.Id, post.Author, post.Created, post.Forum, post.IsEdited, post.Message, post.Parent, post.Thread, post.Path, post.IsDeleted
                   FROM post`
 
-	if params.Limit == 100 {
-		if params.SinceInt != 0 && params.Desc {
-			selectPosts += ` JOIN post last ON last.id = $2 
-                       WHERE post.path < last.path AND post.thread = $1 
-                       ORDER BY post.path DESC, post.id DESC`
-			rows, errQuery = r.Conn.Query(ctx, selectPosts, thread, params.SinceInt)
-		}
-		if params.SinceInt == 0 && params.Desc {
-			selectPosts += ` WHERE post.Thread = $1 
-                        ORDER BY post.Path DESC, post.Id DESC`
-			rows, errQuery = r.Conn.Query(ctx, selectPosts, thread)
-		}
-		if params.SinceInt != 0 && !params.Desc {
-			selectPosts += ` JOIN post last ON last.id = $2 
-                       WHERE post.path > last.path AND post.thread = $1 
-                       ORDER BY post.path ASC, post.id ASC`
-			rows, errQuery = r.Conn.Query(ctx, selectPosts, thread, params.SinceInt)
-		}
-		if params.SinceInt == 0 && !params.Desc {
-			selectPosts += ` WHERE post.Thread = $1 
-                       ORDER BY post.Path ASC, post.Id ASC`
-			rows, errQuery = r.Conn.Query(ctx, selectPosts, thread)
-		}
-	} else {
-		if params.SinceInt != 0 && params.Desc {
-			selectPosts += ` JOIN post last ON last.id = $2 
+	if params.SinceInt != 0 && params.Desc {
+		selectPosts += ` JOIN post last ON last.id = $2 
                        WHERE post.path < last.path AND post.thread = $1 
                        ORDER BY post.path DESC, post.id DESC LIMIT $3`
-			rows, errQuery = r.Conn.Query(ctx, selectPosts, thread, params.SinceInt, params.Limit)
-		}
-		if params.SinceInt == 0 && params.Desc {
-			selectPosts += ` WHERE post.Thread = $1 
+		rows, errQuery = r.Conn.Query(ctx, selectPosts, thread, params.SinceInt, params.Limit)
+	}
+	if params.SinceInt == 0 && params.Desc {
+		selectPosts += ` WHERE post.Thread = $1 
                        ORDER BY post.Path DESC, post.Id DESC LIMIT $2`
-			rows, errQuery = r.Conn.Query(ctx, selectPosts, thread, params.Limit)
-		}
-		if params.SinceInt != 0 && !params.Desc {
-			selectPosts += ` JOIN post last ON last.id = $2 
+		rows, errQuery = r.Conn.Query(ctx, selectPosts, thread, params.Limit)
+	}
+	if params.SinceInt != 0 && !params.Desc {
+		selectPosts += ` JOIN post last ON last.id = $2 
                        WHERE post.path > last.path AND post.thread = $1 
                        ORDER BY post.path ASC, post.id ASC LIMIT $3`
-			rows, errQuery = r.Conn.Query(ctx, selectPosts, thread, params.SinceInt, params.Limit)
-		}
-		if params.SinceInt == 0 && !params.Desc {
-			selectPosts += ` WHERE post.Thread = $1 
+		rows, errQuery = r.Conn.Query(ctx, selectPosts, thread, params.SinceInt, params.Limit)
+	}
+	if params.SinceInt == 0 && !params.Desc {
+		selectPosts += ` WHERE post.Thread = $1 
                        ORDER BY post.Path ASC, post.Id ASC LIMIT $2`
-			rows, errQuery = r.Conn.Query(ctx, selectPosts, thread, params.Limit)
-		}
+		rows, errQuery = r.Conn.Query(ctx, selectPosts, thread, params.Limit)
 	}
 	if errQuery != nil {
-		return []models.Post{}, models.InternalError
+		return nil, errs.Wrap(errQuery, "get posts tree of thread %d", thread)
 	}
 	defer rows.Close()
 
 	posts := make([]models.Post, 0)
 	for rows.Next() {
 		postOne := models.Post{}
-		err := rows.Scan(&postOne.ID, &postOne.Author, &postOne.Created, &postOne.Forum, &postOne.IsEdited, &postOne.Message, &postOne.Parent, &postOne.Thread, &postOne.Path)
+		err := rows.Scan(&postOne.ID, &postOne.Author, &postOne.Created, &postOne.Forum, &postOne.IsEdited, &postOne.Message, &postOne.Parent, &postOne.Thread, &postOne.Path, &postOne.IsDeleted)
 
 		if err != nil {
-			return []models.Post{}, models.InternalError
+			return nil, errs.Wrap(err, "get posts tree of thread %d", thread)
 		}
-		fmt.Println(postOne.ID, " ", postOne.Parent, " ", postOne.Path.Elements)
-		posts = append(posts, postOne)
+		posts = append(posts, tombstone(postOne))
 	}
 	return posts, nil
 }
@@ -827,6 +970,9 @@ func (r *repoPostgres) GetPostsTree(ctx context.Context, params models.RequestPa
 //}
 
 func (r *repoPostgres) GetPostsParent(ctx context.Context, params models.RequestParameters, thread int) ([]models.Post, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
 	selectPostParents := fmt.Sprintf(`SELECT Id FROM post WHERE Thread = %d AND Parent = 0`, thread)
 
 	if params.SinceInt == 0 {
@@ -843,11 +989,9 @@ func (r *repoPostgres) GetPostsParent(ctx context.Context, params models.Request
 		}
 	}
 
-	if params.Limit != 100 {
-		selectPostParents += fmt.Sprintf(" LIMIT %d", params.Limit)
-	}
+	selectPostParents += fmt.Sprintf(" LIMIT %d", params.Limit)
 
-	selectPosts := fmt.Sprintf(`SELECT Id, Author, Created, Forum, IsEdited, Message, Parent, Thread FROM post WHERE Path[1] = ANY (%s) `, selectPostParents)
+	selectPosts := fmt.Sprintf(`SELECT Id, Author, Created, Forum, IsEdited, Message, Parent, Thread, IsDeleted FROM post WHERE Path[1] = ANY (%s) `, selectPostParents)
 
 	if params.Desc {
 		selectPosts += ` ORDER BY Path[1] DESC, Path, Id `
@@ -855,16 +999,19 @@ func (r *repoPostgres) GetPostsParent(ctx context.Context, params models.Request
 		selectPosts += ` ORDER BY Path[1] ASC, Path, Id `
 	}
 
-	rows, _ := r.Conn.Query(ctx, selectPosts)
+	rows, err := r.Conn.Query(ctx, selectPosts)
+	if err != nil {
+		return nil, errs.Wrap(err, "get parent tree of thread %d", thread)
+	}
 	defer rows.Close()
 	posts := make([]models.Post, 0)
 	for rows.Next() {
 		onePost := models.Post{}
-		err := rows.Scan(&onePost.ID, &onePost.Author, &onePost.Created, &onePost.Forum, &onePost.IsEdited, &onePost.Message, &onePost.Parent, &onePost.Thread)
+		err := rows.Scan(&onePost.ID, &onePost.Author, &onePost.Created, &onePost.Forum, &onePost.IsEdited, &onePost.Message, &onePost.Parent, &onePost.Thread, &onePost.IsDeleted)
 		if err != nil {
-			return posts, models.InternalError
+			return posts, errs.Wrap(err, "get parent tree of thread %d", thread)
 		}
-		posts = append(posts, onePost)
+		posts = append(posts, tombstone(onePost))
 	}
 
 	return posts, nil
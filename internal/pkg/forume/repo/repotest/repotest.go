@@ -0,0 +1,1090 @@
+// Package repotest содержит общий набор проверок для всех реализаций forume.Repository.
+// Реализация подключается из своего _test.go файла:
+//
+//	func TestRepoMemory(t *testing.T) {
+//		repotest.Run(t, func(t *testing.T) forume.Repository { return repo.NewRepoMemory() })
+//	}
+//
+// Фабрика вызывается для каждого подтеста и должна возвращать пустое хранилище.
+package repotest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/BigBullas/TP_DB_project/internal/errs"
+	"github.com/BigBullas/TP_DB_project/internal/models"
+	"github.com/BigBullas/TP_DB_project/internal/pkg/forume"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+type Factory func(t *testing.T) forume.Repository
+
+// Corrupt портит в обход репозитория счётчик постов форума, сумму голосов ветки
+// и убирает автора ветки из users_forum этого форума.
+type Corrupt func(t *testing.T, r forume.Repository, forum string, thread int, author string)
+
+func Run(t *testing.T, newRepo Factory) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, r forume.Repository)
+	}{
+		{"Users", testUsers},
+		{"Forums", testForums},
+		{"Threads", testThreads},
+		{"GetThreads", testGetThreads},
+		{"CreatePosts", testCreatePosts},
+		{"CreatePostsConflicts", testCreatePostsConflicts},
+		{"ImportPosts", testImportPosts},
+		{"GetPostsFlat", testGetPostsFlat},
+		{"GetPostsTree", testGetPostsTree},
+		{"GetPostsParent", testGetPostsParent},
+		{"ChangeVote", testChangeVote},
+		{"GetUsers", testGetUsers},
+		{"PostDetails", testPostDetails},
+		{"StatusAndClear", testStatusAndClear},
+		{"ClearForum", testClearForum},
+		{"Consistency", testConsistency},
+		{"Search", testSearch},
+		{"DeletePost", testDeletePost},
+		{"PostHistory", testPostHistory},
+		{"Credentials", testCredentials},
+		{"ForumRoles", testForumRoles},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newRepo(t))
+		})
+	}
+}
+
+var ctx = context.Background()
+
+func createUser(t *testing.T, r forume.Repository, nickname string) models.User {
+	t.Helper()
+	user := models.User{NickName: nickname, FullName: "Full " + nickname, About: "about", Email: nickname + "@mail.ru"}
+	if err := r.CreateUser(ctx, user, ""); err != nil {
+		t.Fatalf("CreateUser(%s): %v", nickname, err)
+	}
+	return user
+}
+
+func createForum(t *testing.T, r forume.Repository, slug string, owner string) models.Forum {
+	t.Helper()
+	forum := models.Forum{Title: "Forum " + slug, User: owner, Slug: slug}
+	created, err := r.CreateForum(ctx, forum)
+	if err != nil {
+		t.Fatalf("CreateForum(%s): %v", slug, err)
+	}
+	return created
+}
+
+func createThread(t *testing.T, r forume.Repository, thread models.Thread) models.Thread {
+	t.Helper()
+	created, err := r.CreateThread(ctx, thread)
+	if err != nil {
+		t.Fatalf("CreateThread(%s): %v", thread.Title, err)
+	}
+	return created
+}
+
+func createPosts(t *testing.T, r forume.Repository, thread models.Thread, posts ...models.Post) []models.Post {
+	t.Helper()
+	created, err := r.CreatePosts(ctx, posts, thread)
+	if err != nil {
+		t.Fatalf("CreatePosts: %v", err)
+	}
+	return created
+}
+
+// fixture: пользователь, форум и ветка в нём.
+func fixture(t *testing.T, r forume.Repository) (models.User, models.Forum, models.Thread) {
+	t.Helper()
+	user := createUser(t, r, "author")
+	forum := createForum(t, r, "forum", user.NickName)
+	thread := createThread(t, r, models.Thread{
+		Title: "thread", Author: user.NickName, Forum: forum.Slug, Message: "message", Slug: "thread",
+		Created: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+	})
+	return user, forum, thread
+}
+
+// tree создаёт в ветке дерево:
+//
+//	a
+//	├── a1
+//	│   └── a11
+//	└── a2
+//	b
+//	└── b1
+//	c
+func tree(t *testing.T, r forume.Repository, thread models.Thread, author string) map[string]int {
+	t.Helper()
+	ids := make(map[string]int)
+	add := func(name string, parent string) {
+		posts := createPosts(t, r, thread, models.Post{Author: author, Message: name, Parent: ids[parent]})
+		ids[name] = posts[0].ID
+	}
+	add("a", "")
+	add("b", "")
+	add("a1", "a")
+	add("c", "")
+	add("b1", "b")
+	add("a11", "a1")
+	add("a2", "a")
+	return ids
+}
+
+func postIDs(posts []models.Post) []int {
+	ids := make([]int, 0, len(posts))
+	for _, p := range posts {
+		ids = append(ids, p.ID)
+	}
+	return ids
+}
+
+func names(ids map[string]int, list ...string) []int {
+	res := make([]int, 0, len(list))
+	for _, name := range list {
+		res = append(res, ids[name])
+	}
+	return res
+}
+
+func nicknames(users []models.User) []string {
+	res := make([]string, 0, len(users))
+	for _, u := range users {
+		res = append(res, u.NickName)
+	}
+	return res
+}
+
+func expectCode(t *testing.T, what string, err error, want *errs.Error) {
+	t.Helper()
+	if !errors.Is(err, want) {
+		t.Errorf("%s: got error %v, want %s", what, err, want.Code)
+	}
+}
+
+func expectIDs(t *testing.T, what string, got []int, want []int) {
+	t.Helper()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("%s: got %v, want %v", what, got, want)
+	}
+}
+
+func testUsers(t *testing.T, r forume.Repository) {
+	user := createUser(t, r, "Alice")
+
+	got, err := r.GetUser(ctx, "ALICE")
+	if err != nil || got != user {
+		t.Fatalf("GetUser is case-insensitive: got %+v, %v", got, err)
+	}
+	_, err = r.GetUser(ctx, "nobody")
+	expectCode(t, "GetUser(missing)", err, errs.NotFound)
+	expectCode(t, "CreateUser(duplicate)", r.CreateUser(ctx, models.User{NickName: "alice", Email: "x@mail.ru"}, ""), errs.Conflict)
+
+	same, err := r.CheckUserForUniq(ctx, models.User{NickName: "other", Email: "ALICE@mail.ru"})
+	if err != nil || len(same) != 1 || same[0].NickName != "Alice" {
+		t.Errorf("CheckUserForUniq by email: got %+v, %v", same, err)
+	}
+	same, err = r.CheckUserForUniq(ctx, models.User{NickName: "alice", Email: "new@mail.ru"})
+	if err != nil || len(same) != 1 {
+		t.Errorf("CheckUserForUniq by nickname: got %+v, %v", same, err)
+	}
+
+	user.FullName = "Alice Cooper"
+	user.Email = "cooper@mail.ru"
+	if _, err := r.ChangeUserInfo(ctx, user); err != nil {
+		t.Fatalf("ChangeUserInfo: %v", err)
+	}
+	got, _ = r.GetUser(ctx, "alice")
+	if got.FullName != "Alice Cooper" || got.Email != "cooper@mail.ru" {
+		t.Errorf("ChangeUserInfo not applied: %+v", got)
+	}
+	_, err = r.ChangeUserInfo(ctx, models.User{NickName: "nobody", Email: "nobody@mail.ru"})
+	expectCode(t, "ChangeUserInfo(missing)", err, errs.NotFound)
+}
+
+func testForums(t *testing.T, r forume.Repository) {
+	user := createUser(t, r, "owner")
+	forum := createForum(t, r, "Pirate-Stories", user.NickName)
+
+	got, err := r.GetForumDetails(ctx, "pirate-stories")
+	if err != nil || got.Slug != forum.Slug || got.User != user.NickName || got.Posts != 0 || got.Threads != 0 {
+		t.Errorf("GetForumDetails: got %+v, %v", got, err)
+	}
+	_, err = r.GetForumDetails(ctx, "missing")
+	expectCode(t, "GetForumDetails(missing)", err, errs.NotFound)
+
+	same, err := r.CheckForumForUniq(ctx, models.Forum{Slug: "PIRATE-STORIES"})
+	if err != nil || len(same) != 1 {
+		t.Errorf("CheckForumForUniq: got %+v, %v", same, err)
+	}
+	_, err = r.CreateForum(ctx, models.Forum{Title: "again", User: user.NickName, Slug: "pirate-stories"})
+	expectCode(t, "CreateForum(duplicate)", err, errs.Conflict)
+}
+
+func testThreads(t *testing.T, r forume.Repository) {
+	user, forum, thread := fixture(t, r)
+	if thread.ID == 0 {
+		t.Fatal("CreateThread did not assign id")
+	}
+
+	bySlug, err := r.GetThreadBySlug(ctx, "THREAD")
+	if err != nil || bySlug.ID != thread.ID {
+		t.Errorf("GetThreadBySlug: got %+v, %v", bySlug, err)
+	}
+	byID, err := r.GetThreadById(ctx, thread.ID)
+	if err != nil || byID.Slug != "thread" || byID.Author != user.NickName {
+		t.Errorf("GetThreadById: got %+v, %v", byID, err)
+	}
+	_, err = r.GetThreadById(ctx, thread.ID+1000)
+	expectCode(t, "GetThreadById(missing)", err, errs.NotFound)
+	_, err = r.GetThreadBySlug(ctx, "missing")
+	expectCode(t, "GetThreadBySlug(missing)", err, errs.NotFound)
+	same, err := r.CheckThreadForUniq(ctx, models.Thread{Slug: "Thread"})
+	if err != nil || len(same) != 1 {
+		t.Errorf("CheckThreadForUniq: got %+v, %v", same, err)
+	}
+
+	details, _ := r.GetForumDetails(ctx, forum.Slug)
+	if details.Threads != 1 {
+		t.Errorf("forum threads counter: got %d, want 1", details.Threads)
+	}
+	users, _ := r.GetUsers(ctx, forum.Slug, models.RequestParameters{Limit: 10})
+	if !reflect.DeepEqual(nicknames(users), []string{user.NickName}) {
+		t.Errorf("thread author is not a forum member: %v", nicknames(users))
+	}
+
+	thread.Title = "new title"
+	thread.Message = "new message"
+	if _, err := r.ChangeThreadInfo(ctx, thread); err != nil {
+		t.Fatalf("ChangeThreadInfo: %v", err)
+	}
+	byID, _ = r.GetThreadById(ctx, thread.ID)
+	if byID.Title != "new title" || byID.Message != "new message" {
+		t.Errorf("ChangeThreadInfo not applied: %+v", byID)
+	}
+}
+
+func testGetThreads(t *testing.T, r forume.Repository) {
+	user := createUser(t, r, "author")
+	forum := createForum(t, r, "forum", user.NickName)
+	day := func(d int) time.Time { return time.Date(2020, 1, d, 0, 0, 0, 0, time.UTC) }
+	var ids []int
+	for d := 1; d <= 4; d++ {
+		thread := createThread(t, r, models.Thread{Title: "t", Author: user.NickName, Forum: forum.Slug, Message: "m", Created: day(d)})
+		ids = append(ids, thread.ID)
+	}
+	threadIDs := func(params models.RequestParameters) []int {
+		t.Helper()
+		threads, err := r.GetThreads(ctx, "FORUM", params)
+		if err != nil {
+			t.Fatalf("GetThreads(%+v): %v", params, err)
+		}
+		res := make([]int, 0, len(threads))
+		for _, th := range threads {
+			res = append(res, th.ID)
+		}
+		return res
+	}
+
+	expectIDs(t, "asc", threadIDs(models.RequestParameters{Limit: 10}), ids)
+	expectIDs(t, "desc limit", threadIDs(models.RequestParameters{Limit: 2, Desc: true}), []int{ids[3], ids[2]})
+	expectIDs(t, "since is inclusive", threadIDs(models.RequestParameters{Limit: 10, Since: "2020-01-02T00:00:00Z"}), ids[1:])
+	expectIDs(t, "desc since", threadIDs(models.RequestParameters{Limit: 10, Since: "2020-01-02T00:00:00Z", Desc: true}), []int{ids[1], ids[0]})
+
+	// Позиция курсора (created, id) строгая и различает ветки с одинаковым created.
+	twin := createThread(t, r, models.Thread{Title: "t", Author: user.NickName, Forum: forum.Slug, Message: "m", Created: day(2)})
+	expectIDs(t, "after position", threadIDs(models.RequestParameters{Limit: 10, Since: "2020-01-02T00:00:00Z", SinceID: ids[1]}), []int{twin.ID, ids[2], ids[3]})
+	expectIDs(t, "desc before position", threadIDs(models.RequestParameters{Limit: 10, Since: "2020-01-02T00:00:00Z", SinceID: twin.ID, Desc: true}), []int{ids[1], ids[0]})
+}
+
+func testCreatePosts(t *testing.T, r forume.Repository) {
+	user, forum, thread := fixture(t, r)
+	other := createUser(t, r, "replier")
+
+	posts := createPosts(t, r, thread,
+		models.Post{Author: user.NickName, Message: "first"},
+		models.Post{Author: other.NickName, Message: "second"},
+	)
+	if len(posts) != 2 || posts[0].ID == 0 || posts[1].ID <= posts[0].ID {
+		t.Fatalf("CreatePosts ids: %+v", posts)
+	}
+	for _, p := range posts {
+		if p.Forum != forum.Slug || p.Thread != thread.ID || p.Created.IsZero() {
+			t.Errorf("CreatePosts did not fill post: %+v", p)
+		}
+	}
+
+	reply := createPosts(t, r, thread, models.Post{Author: user.NickName, Message: "reply", Parent: posts[0].ID})
+	if reply[0].Parent != posts[0].ID {
+		t.Errorf("reply parent: got %d", reply[0].Parent)
+	}
+
+	details, _ := r.GetForumDetails(ctx, forum.Slug)
+	if details.Posts != 3 {
+		t.Errorf("forum posts counter: got %d, want 3", details.Posts)
+	}
+	members, _ := r.GetUsers(ctx, forum.Slug, models.RequestParameters{Limit: 10})
+	if !reflect.DeepEqual(nicknames(members), []string{"author", "replier"}) {
+		t.Errorf("post authors are not forum members: %v", nicknames(members))
+	}
+}
+
+func testCreatePostsConflicts(t *testing.T, r forume.Repository) {
+	user, forum, thread := fixture(t, r)
+	otherThread := createThread(t, r, models.Thread{Title: "other", Author: user.NickName, Forum: forum.Slug, Message: "m"})
+	foreign := createPosts(t, r, otherThread, models.Post{Author: user.NickName, Message: "foreign"})
+
+	valid := models.Post{Author: user.NickName, Message: "ok"}
+	tests := []struct {
+		name  string
+		posts []models.Post
+		code  *errs.Error
+		field string
+	}{
+		{"empty author", []models.Post{{Message: "m"}}, errs.BadRequest, "posts[0].author"},
+		{"unknown author", []models.Post{valid, {Author: "nobody", Message: "m"}}, errs.NotFound, "posts[1].author"},
+		{"missing parent", []models.Post{valid, valid, {Author: user.NickName, Message: "m", Parent: foreign[0].ID + 1000}}, errs.Conflict, "posts[2].parent"},
+		{"parent in another thread", []models.Post{{Author: user.NickName, Message: "m", Parent: foreign[0].ID}, valid}, errs.Conflict, "posts[0].parent"},
+	}
+	for _, tt := range tests {
+		_, err := r.CreatePosts(ctx, tt.posts, thread)
+		expectCode(t, tt.name, err, tt.code)
+		var e *errs.Error
+		if errors.As(err, &e) && e.Field != tt.field {
+			t.Errorf("%s: got field %q, want %q", tt.name, e.Field, tt.field)
+		}
+	}
+
+	flat, _ := r.GetPostsFlat(ctx, models.RequestParameters{Limit: 10}, thread.ID)
+	if len(flat) != 0 {
+		t.Errorf("rejected batches must not insert posts, got %v", postIDs(flat))
+	}
+}
+
+func testImportPosts(t *testing.T, r forume.Repository) {
+	user, forum, thread := fixture(t, r)
+	other := createUser(t, r, "importer")
+	root := createPosts(t, r, thread, models.Post{Author: user.NickName, Message: "root"})[0]
+	created := time.Date(2015, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	result, err := r.ImportPosts(ctx, []models.ImportPost{
+		{Ref: "x", Author: other.NickName, Message: "x"},
+		{Ref: "y", ParentRef: "x", Author: "IMPORTER", Message: "y"},
+		{Parent: root.ID, Author: user.NickName, Message: "z"},
+		{ParentRef: "y", Author: other.NickName, Message: "w", Created: created},
+	}, thread)
+	if err != nil {
+		t.Fatalf("ImportPosts: %v", err)
+	}
+	if result.Imported != 4 || len(result.Refs) != 2 || result.Refs["x"] == 0 || result.Refs["y"] == 0 {
+		t.Fatalf("ImportPosts result: %+v", result)
+	}
+
+	flat, _ := r.GetPostsFlat(ctx, models.RequestParameters{Limit: 10}, thread.ID)
+	if len(flat) != 5 {
+		t.Fatalf("imported posts: got %v", postIDs(flat))
+	}
+	z, w := flat[3], flat[4]
+	if z.Parent != root.ID || w.Parent != result.Refs["y"] || !w.Created.Equal(created) || w.Forum != forum.Slug {
+		t.Errorf("imported posts are not linked: z %+v, w %+v", z, w)
+	}
+	treePosts, _ := r.GetPostsTree(ctx, models.RequestParameters{Limit: 10}, thread.ID)
+	expectIDs(t, "tree", postIDs(treePosts), []int{root.ID, z.ID, result.Refs["x"], result.Refs["y"], w.ID})
+
+	details, _ := r.GetForumDetails(ctx, forum.Slug)
+	if details.Posts != 5 {
+		t.Errorf("forum posts counter: got %d, want 5", details.Posts)
+	}
+	members, _ := r.GetUsers(ctx, forum.Slug, models.RequestParameters{Limit: 10})
+	if !reflect.DeepEqual(nicknames(members), []string{"author", "importer"}) {
+		t.Errorf("importers are not forum members: %v", nicknames(members))
+	}
+
+	otherThread := createThread(t, r, models.Thread{Title: "other", Author: user.NickName, Forum: forum.Slug, Message: "m"})
+	foreign := createPosts(t, r, otherThread, models.Post{Author: user.NickName, Message: "foreign"})[0]
+	valid := models.ImportPost{Author: user.NickName, Message: "ok"}
+	tests := []struct {
+		name  string
+		posts []models.ImportPost
+		code  *errs.Error
+		field string
+	}{
+		{"empty author", []models.ImportPost{valid, {Message: "m"}}, errs.BadRequest, "posts[1].author"},
+		{"forward parent_ref", []models.ImportPost{{ParentRef: "later", Author: user.NickName}, {Ref: "later", Author: user.NickName}}, errs.BadRequest, "posts[0].parent_ref"},
+		{"duplicate ref", []models.ImportPost{{Ref: "a", Author: user.NickName}, {Ref: "a", Author: user.NickName}}, errs.BadRequest, "posts[1].ref"},
+		{"unknown author", []models.ImportPost{valid, valid, {Author: "nobody"}}, errs.NotFound, "posts[2].author"},
+		{"missing parent", []models.ImportPost{{Parent: foreign.ID + 1000, Author: user.NickName}}, errs.Conflict, "posts[0].parent"},
+		{"parent in another thread", []models.ImportPost{valid, {Parent: foreign.ID, Author: user.NickName}}, errs.Conflict, "posts[1].parent"},
+	}
+	for _, tt := range tests {
+		_, err := r.ImportPosts(ctx, tt.posts, thread)
+		expectCode(t, tt.name, err, tt.code)
+		var e *errs.Error
+		if errors.As(err, &e) && e.Field != tt.field {
+			t.Errorf("%s: got field %q, want %q", tt.name, e.Field, tt.field)
+		}
+	}
+	flat, _ = r.GetPostsFlat(ctx, models.RequestParameters{Limit: 10}, thread.ID)
+	if len(flat) != 5 {
+		t.Errorf("rejected imports must not insert posts, got %v", postIDs(flat))
+	}
+}
+
+func testGetPostsFlat(t *testing.T, r forume.Repository) {
+	user, _, thread := fixture(t, r)
+	ids := tree(t, r, thread, user.NickName)
+
+	get := func(params models.RequestParameters) []int {
+		t.Helper()
+		posts, err := r.GetPostsFlat(ctx, params, thread.ID)
+		if err != nil {
+			t.Fatalf("GetPostsFlat(%+v): %v", params, err)
+		}
+		return postIDs(posts)
+	}
+	expectIDs(t, "asc", get(models.RequestParameters{Limit: 10}), names(ids, "a", "b", "a1", "c", "b1", "a11", "a2"))
+	expectIDs(t, "asc limit", get(models.RequestParameters{Limit: 3}), names(ids, "a", "b", "a1"))
+	expectIDs(t, "desc", get(models.RequestParameters{Limit: 3, Desc: true}), names(ids, "a2", "a11", "b1"))
+	expectIDs(t, "since", get(models.RequestParameters{Limit: 2, SinceInt: ids["a1"]}), names(ids, "c", "b1"))
+	expectIDs(t, "desc since", get(models.RequestParameters{Limit: 2, SinceInt: ids["a1"], Desc: true}), names(ids, "b", "a"))
+}
+
+func testGetPostsTree(t *testing.T, r forume.Repository) {
+	user, _, thread := fixture(t, r)
+	ids := tree(t, r, thread, user.NickName)
+
+	get := func(params models.RequestParameters) []int {
+		t.Helper()
+		posts, err := r.GetPostsTree(ctx, params, thread.ID)
+		if err != nil {
+			t.Fatalf("GetPostsTree(%+v): %v", params, err)
+		}
+		return postIDs(posts)
+	}
+	expectIDs(t, "asc", get(models.RequestParameters{Limit: 10}), names(ids, "a", "a1", "a11", "a2", "b", "b1", "c"))
+	expectIDs(t, "asc limit", get(models.RequestParameters{Limit: 3}), names(ids, "a", "a1", "a11"))
+	expectIDs(t, "desc", get(models.RequestParameters{Limit: 4, Desc: true}), names(ids, "c", "b1", "b", "a2"))
+	expectIDs(t, "since", get(models.RequestParameters{Limit: 3, SinceInt: ids["a11"]}), names(ids, "a2", "b", "b1"))
+	expectIDs(t, "desc since", get(models.RequestParameters{Limit: 3, SinceInt: ids["b"], Desc: true}), names(ids, "a2", "a11", "a1"))
+}
+
+func testGetPostsParent(t *testing.T, r forume.Repository) {
+	user, _, thread := fixture(t, r)
+	ids := tree(t, r, thread, user.NickName)
+
+	get := func(params models.RequestParameters) []int {
+		t.Helper()
+		posts, err := r.GetPostsParent(ctx, params, thread.ID)
+		if err != nil {
+			t.Fatalf("GetPostsParent(%+v): %v", params, err)
+		}
+		return postIDs(posts)
+	}
+	expectIDs(t, "asc", get(models.RequestParameters{Limit: 10}), names(ids, "a", "a1", "a11", "a2", "b", "b1", "c"))
+	expectIDs(t, "limit counts roots", get(models.RequestParameters{Limit: 2}), names(ids, "a", "a1", "a11", "a2", "b", "b1"))
+	expectIDs(t, "desc", get(models.RequestParameters{Limit: 2, Desc: true}), names(ids, "c", "b", "b1"))
+	expectIDs(t, "since", get(models.RequestParameters{Limit: 1, SinceInt: ids["a11"]}), names(ids, "b", "b1"))
+	expectIDs(t, "desc since", get(models.RequestParameters{Limit: 3, SinceInt: ids["b1"], Desc: true}), names(ids, "a", "a1", "a11", "a2"))
+}
+
+func testChangeVote(t *testing.T, r forume.Repository) {
+	_, _, thread := fixture(t, r)
+	createUser(t, r, "voter")
+	votes := func() int {
+		t.Helper()
+		th, err := r.GetThreadById(ctx, thread.ID)
+		if err != nil {
+			t.Fatalf("GetThreadById: %v", err)
+		}
+		return th.Votes
+	}
+
+	steps := []struct {
+		nickname string
+		voice    int
+		votes    int
+	}{
+		{"voter", 1, 1},
+		{"VOTER", 1, 1},
+		{"voter", -1, -1},
+		{"author", -1, -2},
+		{"voter", 1, 0},
+	}
+	for i, step := range steps {
+		vote := models.Vote{Nickname: step.nickname, Voice: step.voice, Thread: thread.ID}
+		changed, err := r.ChangeVote(ctx, vote, thread)
+		if err != nil {
+			t.Fatalf("step %d: ChangeVote: %v", i, err)
+		}
+		if got := votes(); got != step.votes {
+			t.Errorf("step %d: %s votes %d, thread votes %d, want %d", i, step.nickname, step.voice, got, step.votes)
+		}
+		if changed.Votes != step.votes {
+			t.Errorf("step %d: ChangeVote returned votes %d, want %d", i, changed.Votes, step.votes)
+		}
+		thread = changed
+	}
+}
+
+func testGetUsers(t *testing.T, r forume.Repository) {
+	owner := createUser(t, r, "owner")
+	forum := createForum(t, r, "forum", owner.NickName)
+	thread := createThread(t, r, models.Thread{Title: "t", Author: owner.NickName, Forum: forum.Slug, Message: "m"})
+	for _, nickname := range []string{"charlie", "alpha", "Bravo", "delta"} {
+		createUser(t, r, nickname)
+		createPosts(t, r, thread, models.Post{Author: nickname, Message: "hi"})
+	}
+	createPosts(t, r, thread, models.Post{Author: "alpha", Message: "again"})
+
+	get := func(params models.RequestParameters) []string {
+		t.Helper()
+		users, err := r.GetUsers(ctx, "Forum", params)
+		if err != nil {
+			t.Fatalf("GetUsers(%+v): %v", params, err)
+		}
+		return nicknames(users)
+	}
+	expect := func(what string, got []string, want ...string) {
+		t.Helper()
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %v, want %v", what, got, want)
+		}
+	}
+	expect("asc", get(models.RequestParameters{Limit: 10}), "alpha", "Bravo", "charlie", "delta", "owner")
+	expect("asc limit", get(models.RequestParameters{Limit: 2}), "alpha", "Bravo")
+	expect("desc", get(models.RequestParameters{Limit: 2, Desc: true}), "owner", "delta")
+	expect("since ignores case", get(models.RequestParameters{Limit: 10, Since: "bravo"}), "charlie", "delta", "owner")
+	expect("desc since", get(models.RequestParameters{Limit: 10, Since: "CHARLIE", Desc: true}), "Bravo", "alpha")
+}
+
+func testPostDetails(t *testing.T, r forume.Repository) {
+	user, forum, thread := fixture(t, r)
+	post := createPosts(t, r, thread, models.Post{Author: user.NickName, Message: "original"})[0]
+
+	details, err := r.GetPostDetails(ctx, post.ID, []string{"user", "forum", "thread"})
+	if err != nil {
+		t.Fatalf("GetPostDetails: %v", err)
+	}
+	if details.Post.ID != post.ID || details.Post.Message != "original" || details.Post.IsEdited {
+		t.Errorf("GetPostDetails post: %+v", details.Post)
+	}
+	if details.Author == nil || details.Author.NickName != user.NickName {
+		t.Errorf("GetPostDetails author: %+v", details.Author)
+	}
+	if details.Forum == nil || details.Forum.Slug != forum.Slug || details.Forum.Posts != 1 {
+		t.Errorf("GetPostDetails forum: %+v", details.Forum)
+	}
+	if details.Thread == nil || details.Thread.ID != thread.ID {
+		t.Errorf("GetPostDetails thread: %+v", details.Thread)
+	}
+
+	_, err = r.GetPostDetails(ctx, post.ID+1000, nil)
+	expectCode(t, "GetPostDetails(missing)", err, errs.NotFound)
+
+	post.Message = "edited"
+	if _, err := r.ChangePostInfo(ctx, post, user.NickName); err != nil {
+		t.Fatalf("ChangePostInfo: %v", err)
+	}
+	details, _ = r.GetPostDetails(ctx, post.ID, nil)
+	if details.Post.Message != "edited" || !details.Post.IsEdited {
+		t.Errorf("ChangePostInfo not applied: %+v", details.Post)
+	}
+	if details.Author != nil || details.Forum != nil || details.Thread != nil {
+		t.Errorf("GetPostDetails without related must leave relations empty: %+v", details)
+	}
+}
+
+func testStatusAndClear(t *testing.T, r forume.Repository) {
+	user, forum, thread := fixture(t, r)
+	createPosts(t, r, thread, models.Post{Author: user.NickName, Message: "1"}, models.Post{Author: user.NickName, Message: "2"})
+	if _, err := r.ChangeVote(ctx, models.Vote{Nickname: user.NickName, Voice: 1, Thread: thread.ID}, thread); err != nil {
+		t.Fatalf("ChangeVote: %v", err)
+	}
+
+	// Форумы, ветки и посты берутся из счётчиков и точны в обоих режимах; users и vote
+	// без exact в Postgres — оценка.
+	byForum := []models.ForumInfo{{Slug: forum.Slug, Threads: 1, Posts: 2}}
+	for _, exact := range []bool{true, false} {
+		info, err := r.GetStatus(ctx, exact)
+		if err != nil || info.Forums != 1 || info.Threads != 1 || info.Posts != 2 || !reflect.DeepEqual(info.ByForum, byForum) {
+			t.Errorf("GetStatus(exact=%v): got %+v, %v", exact, info, err)
+		}
+		if exact && (info.Users != 1 || info.Votes != 1 || !info.Exact) {
+			t.Errorf("GetStatus(exact): got %+v", info)
+		}
+	}
+	report, err := r.Clear(ctx)
+	if err != nil {
+		t.Fatalf("Clear: %v", err)
+	}
+	if want := (models.ClearReport{Users: 1, Forums: 1, Threads: 1, Posts: 2, Votes: 1}); report != want {
+		t.Errorf("Clear: got %+v, want %+v", report, want)
+	}
+	info, _ := r.GetStatus(ctx, true)
+	if !reflect.DeepEqual(info, models.Info{Exact: true, ByForum: []models.ForumInfo{}}) {
+		t.Errorf("GetStatus after Clear: %+v", info)
+	}
+}
+
+func testClearForum(t *testing.T, r forume.Repository) {
+	user, forum, thread := fixture(t, r)
+	voter := createUser(t, r, "voter")
+	posts := createPosts(t, r, thread, models.Post{Author: user.NickName, Message: "1"}, models.Post{Author: voter.NickName, Message: "2"})
+	if _, err := r.ChangePostInfo(ctx, models.Post{ID: posts[0].ID, Message: "edited"}, voter.NickName); err != nil {
+		t.Fatalf("ChangePostInfo: %v", err)
+	}
+	if _, err := r.ChangeVote(ctx, models.Vote{Nickname: voter.NickName, Voice: 1, Thread: thread.ID}, thread); err != nil {
+		t.Fatalf("ChangeVote: %v", err)
+	}
+	if _, err := r.SetForumRole(ctx, models.ForumRole{Forum: forum.Slug, Nickname: voter.NickName, Role: models.RoleModerator}); err != nil {
+		t.Fatalf("SetForumRole: %v", err)
+	}
+	other := createForum(t, r, "other", voter.NickName)
+	kept := createThread(t, r, models.Thread{Title: "kept", Author: voter.NickName, Forum: other.Slug, Message: "m", Slug: "kept"})
+	createPosts(t, r, kept, models.Post{Author: voter.NickName, Message: "3"})
+
+	all, err := r.CountClear(ctx, "")
+	if err != nil || all != (models.ClearReport{Users: 2, Forums: 2, Threads: 2, Posts: 3, Votes: 1}) {
+		t.Errorf("CountClear(all): got %+v, %v", all, err)
+	}
+	_, err = r.CountClear(ctx, "nowhere")
+	expectCode(t, "CountClear(missing forum)", err, errs.NotFound)
+	_, err = r.ClearForum(ctx, "nowhere")
+	expectCode(t, "ClearForum(missing forum)", err, errs.NotFound)
+
+	want := models.ClearReport{Forum: forum.Slug, Forums: 1, Threads: 1, Posts: 2, Votes: 1}
+	if got, err := r.CountClear(ctx, "FORUM"); err != nil || got != want {
+		t.Errorf("CountClear(forum): got %+v, %v, want %+v", got, err, want)
+	}
+	if got, err := r.ClearForum(ctx, "FORUM"); err != nil || got != want {
+		t.Errorf("ClearForum: got %+v, %v, want %+v", got, err, want)
+	}
+
+	_, err = r.GetForumDetails(ctx, forum.Slug)
+	expectCode(t, "GetForumDetails after ClearForum", err, errs.NotFound)
+	_, err = r.GetThreadById(ctx, thread.ID)
+	expectCode(t, "GetThread after ClearForum", err, errs.NotFound)
+	_, err = r.GetPostDetails(ctx, posts[0].ID, nil)
+	expectCode(t, "GetPostDetails after ClearForum", err, errs.NotFound)
+	_, err = r.GetForumRole(ctx, forum.Slug, voter.NickName)
+	expectCode(t, "GetForumRole after ClearForum", err, errs.NotFound)
+	info, err := r.GetStatus(ctx, true)
+	if err != nil || info.Users != 2 || info.Forums != 1 || info.Threads != 1 || info.Posts != 1 || info.Votes != 0 {
+		t.Errorf("GetStatus after ClearForum: got %+v, %v", info, err)
+	}
+	// Слаг освободился.
+	createForum(t, r, forum.Slug, user.NickName)
+}
+
+func consistencyFixture(t *testing.T, r forume.Repository) (models.User, models.Forum, models.Thread) {
+	t.Helper()
+	user, forum, thread := fixture(t, r)
+	voter := createUser(t, r, "voter")
+	createThread(t, r, models.Thread{Title: "second", Author: voter.NickName, Forum: forum.Slug, Message: "m"})
+	ids := tree(t, r, thread, user.NickName)
+	createPosts(t, r, thread, models.Post{Author: voter.NickName, Message: "reply", Parent: ids["a1"]})
+	for _, voice := range []int{1, -1} {
+		if _, err := r.ChangeVote(ctx, models.Vote{Nickname: voter.NickName, Voice: voice, Thread: thread.ID}, thread); err != nil {
+			t.Fatalf("ChangeVote: %v", err)
+		}
+	}
+	return user, forum, thread
+}
+
+func testConsistency(t *testing.T, r forume.Repository) {
+	consistencyFixture(t, r)
+
+	report, err := r.CheckConsistency(ctx, false)
+	if err != nil || len(report.Mismatches) != 0 || report.Repaired {
+		t.Fatalf("CheckConsistency on consistent data: got %+v, %v", report, err)
+	}
+	report, err = r.CheckConsistency(ctx, true)
+	if err != nil || len(report.Mismatches) != 0 || report.Repaired {
+		t.Errorf("CheckConsistency(repair) on consistent data: got %+v, %v", report, err)
+	}
+}
+
+// RunConsistency проверяет, что CheckConsistency находит и исправляет расхождения, созданные corrupt.
+func RunConsistency(t *testing.T, newRepo Factory, corrupt Corrupt) {
+	r := newRepo(t)
+	user, forum, thread := consistencyFixture(t, r)
+	corrupt(t, r, forum.Slug, thread.ID, user.NickName)
+
+	report, err := r.CheckConsistency(ctx, false)
+	if err != nil {
+		t.Fatalf("CheckConsistency: %v", err)
+	}
+	kinds := make(map[string]models.ConsistencyMismatch)
+	for _, m := range report.Mismatches {
+		kinds[m.Kind] = m
+	}
+	if m, ok := kinds[models.MismatchForumPosts]; !ok || m.Actual != 8 || key(m.Forum) != key(forum.Slug) {
+		t.Errorf("forum posts mismatch: %+v", report.Mismatches)
+	}
+	if m, ok := kinds[models.MismatchThreadVotes]; !ok || m.Actual != -1 || m.Thread != thread.ID {
+		t.Errorf("thread votes mismatch: %+v", report.Mismatches)
+	}
+	if m, ok := kinds[models.MismatchForumUserMissing]; !ok || key(m.Nickname) != key(user.NickName) {
+		t.Errorf("missing forum user: %+v", report.Mismatches)
+	}
+	if report.Repaired {
+		t.Error("CheckConsistency without repair reported Repaired")
+	}
+
+	report, err = r.CheckConsistency(ctx, true)
+	if err != nil || !report.Repaired || len(report.Mismatches) != len(kinds) {
+		t.Fatalf("CheckConsistency(repair): got %+v, %v", report, err)
+	}
+	report, err = r.CheckConsistency(ctx, false)
+	if err != nil || len(report.Mismatches) != 0 {
+		t.Errorf("mismatches left after repair: %+v, %v", report.Mismatches, err)
+	}
+	details, _ := r.GetForumDetails(ctx, forum.Slug)
+	th, _ := r.GetThreadById(ctx, thread.ID)
+	if details.Posts != 8 || details.Threads != 2 || th.Votes != -1 {
+		t.Errorf("counters after repair: forum %+v, thread votes %d", details, th.Votes)
+	}
+}
+
+func key(s string) string {
+	return strings.ToLower(s)
+}
+
+func testSearch(t *testing.T, r forume.Repository) {
+	alice, bob := createUser(t, r, "alice"), createUser(t, r, "bob")
+	forum := createForum(t, r, "forum", alice.NickName)
+	other, err := r.CreateForum(ctx, models.Forum{Title: "Other", User: bob.NickName, Slug: "other", Language: "english"})
+	if err != nil {
+		t.Fatalf("CreateForum with language: %v", err)
+	}
+	if got, _ := r.GetForumDetails(ctx, other.Slug); got.Language != "english" {
+		t.Errorf("forum language: got %q, want english", got.Language)
+	}
+	_, err = r.CreateForum(ctx, models.Forum{Title: "Bad", User: bob.NickName, Slug: "bad", Language: "klingon"})
+	expectCode(t, "CreateForum(unknown language)", err, errs.BadRequest)
+
+	created := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	thread := createThread(t, r, models.Thread{Title: "Pirate ship", Author: alice.NickName, Forum: forum.Slug, Message: "about the sea", Created: created})
+	posts := createPosts(t, r, thread,
+		models.Post{Author: alice.NickName, Message: "the black pearl sails"},
+		models.Post{Author: bob.NickName, Message: "pearl of the sea"},
+		models.Post{Author: bob.NickName, Message: "nothing here"},
+	)
+	otherThread := createThread(t, r, models.Thread{Title: "t", Author: bob.NickName, Forum: other.Slug, Message: "m", Created: created})
+	otherPost := createPosts(t, r, otherThread, models.Post{Author: bob.NickName, Message: "pearl"})[0]
+
+	search := func(q models.SearchQuery) []string {
+		t.Helper()
+		hits, err := r.Search(ctx, q)
+		if err != nil {
+			t.Fatalf("Search(%+v): %v", q, err)
+		}
+		var res []string
+		for _, h := range hits {
+			if h.Rank <= 0 {
+				t.Errorf("Search(%q): hit %+v has no rank", q.Query, h)
+			}
+			if h.Thread != nil {
+				res = append(res, fmt.Sprintf("thread %d", h.Thread.ID))
+			} else {
+				res = append(res, fmt.Sprintf("post %d", h.Post.ID))
+			}
+		}
+		sort.Strings(res)
+		return res
+	}
+	post := func(i int) string { return fmt.Sprintf("post %d", posts[i].ID) }
+	expect := func(what string, got []string, want ...string) {
+		t.Helper()
+		sort.Strings(got)
+		sort.Strings(want)
+		if len(got) != len(want) || len(want) > 0 && !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %v, want %v", what, got, want)
+		}
+	}
+
+	expect("word in forum", search(models.SearchQuery{Query: "pearl", Forum: "FORUM"}), post(0), post(1))
+	expect("exclusion", search(models.SearchQuery{Query: "pearl -black", Forum: forum.Slug}), post(1))
+	expect("author", search(models.SearchQuery{Query: "pearl", Author: "BOB", Forum: forum.Slug}), post(1))
+	expect("threads only", search(models.SearchQuery{Query: "sea", Kind: models.SearchKindThread}), fmt.Sprintf("thread %d", thread.ID))
+	expect("posts and threads", search(models.SearchQuery{Query: "sea"}), post(1), fmt.Sprintf("thread %d", thread.ID))
+	expect("thread filter", search(models.SearchQuery{Query: "pearl", Thread: otherThread.ID}), fmt.Sprintf("post %d", otherPost.ID))
+	// Посты получают текущее время, ветка — 2021 год.
+	expect("from", search(models.SearchQuery{Query: "pearl", From: time.Now().Add(time.Hour)}))
+	expect("to", search(models.SearchQuery{Query: "sea", To: created.Add(time.Hour)}), fmt.Sprintf("thread %d", thread.ID))
+	expect("no match", search(models.SearchQuery{Query: "treasure"}))
+
+	hits, err := r.Search(ctx, models.SearchQuery{Query: "black pearl"})
+	if err != nil || len(hits) != 1 || !strings.Contains(hits[0].Snippet, "<b>pearl</b>") {
+		t.Errorf("snippet: got %+v, %v", hits, err)
+	}
+
+	// Чем плотнее совпадения, тем выше rank; Reverse разворачивает порядок.
+	ranked := createPosts(t, r, thread,
+		models.Post{Author: alice.NickName, Message: "a kraken was seen near the old harbour at dawn"},
+		models.Post{Author: alice.NickName, Message: "kraken kraken kraken"},
+	)
+	for _, reverse := range []bool{false, true} {
+		hits, err := r.Search(ctx, models.SearchQuery{Query: "kraken", Reverse: reverse})
+		if err != nil || len(hits) != 2 {
+			t.Fatalf("Search(kraken, reverse=%v): %+v, %v", reverse, hits, err)
+		}
+		dense, sparse := hits[0], hits[1]
+		if reverse {
+			dense, sparse = sparse, dense
+		}
+		if dense.Post.ID != ranked[1].ID || dense.Rank <= sparse.Rank {
+			t.Errorf("ranking (reverse=%v): got post %d (%v) before post %d (%v)", reverse, hits[0].Post.ID, hits[0].Rank, hits[1].Post.ID, hits[1].Rank)
+		}
+	}
+
+	// Сниппет — HTML: разметка из сообщения экранируется, теги добавляет только подсветка.
+	createPosts(t, r, thread, models.Post{Author: bob.NickName, Message: `squid <script>alert("x")</script> & 1 < 2`})
+	hits, err = r.Search(ctx, models.SearchQuery{Query: "squid"})
+	if err != nil || len(hits) != 1 {
+		t.Fatalf("Search(squid): %+v, %v", hits, err)
+	}
+	snippet := hits[0].Snippet
+	if !strings.Contains(snippet, "<b>squid</b>") {
+		t.Errorf("snippet %q does not highlight the match", snippet)
+	}
+	if bare := strings.NewReplacer("<b>", "", "</b>", "").Replace(snippet); strings.ContainsAny(bare, `<>"`) || !strings.Contains(bare, "&amp;") || !strings.Contains(bare, "&lt;") {
+		t.Errorf("snippet %q is not escaped", snippet)
+	}
+
+	// Постранично: три поста с pearl по одному, без повторов и пропусков.
+	var paged []string
+	q := models.SearchQuery{Query: "pearl", Limit: 1}
+	for i := 0; i < 4; i++ {
+		page, err := r.Search(ctx, q)
+		if err != nil {
+			t.Fatalf("Search page %d: %v", i, err)
+		}
+		if len(page) == 0 {
+			break
+		}
+		h := page[0]
+		paged = append(paged, fmt.Sprintf("post %d", h.Post.ID))
+		q.After = &models.Cursor{Rank: h.Rank, Kind: h.Kind, ID: h.Post.ID}
+	}
+	expect("pages", paged, search(models.SearchQuery{Query: "pearl"})...)
+}
+
+func testDeletePost(t *testing.T, r forume.Repository) {
+	user, forum, thread := fixture(t, r)
+	ids := tree(t, r, thread, user.NickName)
+
+	deleted, err := r.DeletePost(ctx, ids["a1"])
+	if err != nil {
+		t.Fatalf("DeletePost: %v", err)
+	}
+	if !deleted.IsDeleted || deleted.Message != "" || deleted.Author != "" || deleted.ID != ids["a1"] {
+		t.Errorf("DeletePost returned %+v, want tombstone", deleted)
+	}
+	if _, err := r.DeletePost(ctx, ids["a1"]); err != nil {
+		t.Errorf("second DeletePost: %v", err)
+	}
+	_, err = r.DeletePost(ctx, ids["c"]+1000)
+	expectCode(t, "DeletePost(missing)", err, errs.NotFound)
+
+	details, _ := r.GetForumDetails(ctx, forum.Slug)
+	if details.Posts != 6 {
+		t.Errorf("forum posts after delete: got %d, want 6", details.Posts)
+	}
+	if status, _ := r.GetStatus(ctx, false); status.Posts != 6 {
+		t.Errorf("status posts after delete: got %d, want 6", status.Posts)
+	}
+
+	// Потомки удалённого поста остаются на месте во всех сортировках.
+	params := models.RequestParameters{Limit: 10}
+	flat, _ := r.GetPostsFlat(ctx, params, thread.ID)
+	treePosts, _ := r.GetPostsTree(ctx, params, thread.ID)
+	parent, _ := r.GetPostsParent(ctx, params, thread.ID)
+	expectIDs(t, "flat", postIDs(flat), names(ids, "a", "b", "a1", "c", "b1", "a11", "a2"))
+	expectIDs(t, "tree", postIDs(treePosts), names(ids, "a", "a1", "a11", "a2", "b", "b1", "c"))
+	expectIDs(t, "parent_tree", postIDs(parent), names(ids, "a", "a1", "a11", "a2", "b", "b1", "c"))
+	for _, posts := range [][]models.Post{flat, treePosts, parent} {
+		for _, p := range posts {
+			if (p.ID == ids["a1"]) != p.IsDeleted || p.IsDeleted && (p.Message != "" || p.Author != "") {
+				t.Errorf("post %d in list: %+v", p.ID, p)
+			}
+		}
+	}
+	got, err := r.GetPostDetails(ctx, ids["a1"], []string{"user", "thread"})
+	if err != nil || !got.Post.IsDeleted || got.Post.Message != "" || got.Author != nil || got.Thread == nil {
+		t.Errorf("GetPostDetails of deleted post: %+v, %v", got, err)
+	}
+	if hits, _ := r.Search(ctx, models.SearchQuery{Query: "a1"}); len(hits) != 0 {
+		t.Errorf("deleted post is found by search: %+v", hits)
+	}
+	if report, err := r.CheckConsistency(ctx, false); err != nil || len(report.Mismatches) != 0 {
+		t.Errorf("CheckConsistency after delete: %+v, %v", report, err)
+	}
+
+	restored, err := r.RestorePost(ctx, ids["a1"])
+	if err != nil || restored.IsDeleted || restored.Message != "a1" || restored.Author != user.NickName {
+		t.Errorf("RestorePost: %+v, %v", restored, err)
+	}
+	details, _ = r.GetForumDetails(ctx, forum.Slug)
+	if details.Posts != 7 {
+		t.Errorf("forum posts after restore: got %d, want 7", details.Posts)
+	}
+}
+
+func testPostHistory(t *testing.T, r forume.Repository) {
+	user, _, thread := fixture(t, r)
+	editor := createUser(t, r, "moderator")
+	post := createPosts(t, r, thread, models.Post{Author: user.NickName, Message: "first"})[0]
+
+	history, err := r.GetPostHistory(ctx, post.ID)
+	if err != nil || len(history) != 1 || history[0].Revision != 1 || history[0].Message != "first" || history[0].Editor != user.NickName {
+		t.Fatalf("history of unedited post: %+v, %v", history, err)
+	}
+
+	post.Message = "second"
+	if _, err := r.ChangePostInfo(ctx, post, user.NickName); err != nil {
+		t.Fatalf("ChangePostInfo: %v", err)
+	}
+	post.Message = "third"
+	if _, err := r.ChangePostInfo(ctx, post, editor.NickName); err != nil {
+		t.Fatalf("ChangePostInfo: %v", err)
+	}
+
+	history, err = r.GetPostHistory(ctx, post.ID)
+	if err != nil || len(history) != 3 {
+		t.Fatalf("history after two edits: %+v, %v", history, err)
+	}
+	for i, want := range []struct{ message, editor string }{{"first", user.NickName}, {"second", user.NickName}, {"third", editor.NickName}} {
+		if got := history[i]; got.Revision != i+1 || got.Message != want.message || got.Editor != want.editor || got.Created.IsZero() {
+			t.Errorf("revision %d: got %+v, want %+v", i+1, got, want)
+		}
+	}
+
+	_, err = r.GetPostHistory(ctx, post.ID+1000)
+	expectCode(t, "GetPostHistory(missing)", err, errs.NotFound)
+	_, err = r.ChangePostInfo(ctx, models.Post{ID: post.ID + 1000, Message: "x"}, user.NickName)
+	expectCode(t, "ChangePostInfo(missing)", err, errs.NotFound)
+}
+
+func testCredentials(t *testing.T, r forume.Repository) {
+	user := createUser(t, r, "Author")
+
+	_, err := r.GetPassword(ctx, "author")
+	expectCode(t, "GetPassword(no password)", err, errs.NotFound)
+	expectCode(t, "SetPassword(missing user)", r.SetPassword(ctx, "nobody", "hash"), errs.NotFound)
+	for _, hash := range []string{"hash1", "hash2"} {
+		if err := r.SetPassword(ctx, "author", hash); err != nil {
+			t.Fatalf("SetPassword: %v", err)
+		}
+		if got, err := r.GetPassword(ctx, "AUTHOR"); err != nil || got != hash {
+			t.Errorf("GetPassword: got %q, %v, want %q", got, err, hash)
+		}
+	}
+
+	withPassword := models.User{NickName: "Reader", Email: "reader@mail.ru"}
+	if err := r.CreateUser(ctx, withPassword, "hash3"); err != nil {
+		t.Fatalf("CreateUser with password: %v", err)
+	}
+	expectCode(t, "CreateUser(duplicate with password)", r.CreateUser(ctx, withPassword, "hash4"), errs.Conflict)
+	if got, err := r.GetPassword(ctx, "reader"); err != nil || got != "hash3" {
+		t.Errorf("GetPassword after CreateUser: got %q, %v, want hash3", got, err)
+	}
+
+	created := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	cred := models.Credential{Hash: "h1", Kind: models.CredentialToken, Nickname: user.NickName, Name: "ci",
+		Created: created, Expires: created.Add(time.Hour)}
+	if err := r.CreateCredential(ctx, cred); err != nil {
+		t.Fatalf("CreateCredential: %v", err)
+	}
+	got, err := r.GetCredential(ctx, "h1")
+	if err != nil || got.Hash != "h1" || got.Kind != cred.Kind || got.Nickname != user.NickName || got.Name != "ci" ||
+		!got.Created.Equal(cred.Created) || !got.Expires.Equal(cred.Expires) {
+		t.Errorf("GetCredential: got %+v, %v, want %+v", got, err, cred)
+	}
+	_, err = r.GetCredential(ctx, "h2")
+	expectCode(t, "GetCredential(missing)", err, errs.NotFound)
+
+	if err := r.DeleteCredential(ctx, "h1"); err != nil {
+		t.Fatalf("DeleteCredential: %v", err)
+	}
+	_, err = r.GetCredential(ctx, "h1")
+	expectCode(t, "GetCredential(deleted)", err, errs.NotFound)
+	if err := r.DeleteCredential(ctx, "h1"); err != nil {
+		t.Errorf("DeleteCredential(again): %v", err)
+	}
+
+	if err := r.CreateCredential(ctx, models.Credential{Hash: "h3", Kind: models.CredentialSession, Nickname: user.NickName, Expires: created}); err != nil {
+		t.Fatalf("CreateCredential: %v", err)
+	}
+	if _, err := r.Clear(ctx); err != nil {
+		t.Fatalf("Clear: %v", err)
+	}
+	_, err = r.GetCredential(ctx, "h3")
+	expectCode(t, "GetCredential after Clear", err, errs.NotFound)
+}
+
+func testForumRoles(t *testing.T, r forume.Repository) {
+	owner, forum, _ := fixture(t, r)
+	for _, nickname := range []string{"Mod", "alpha", "Troll"} {
+		createUser(t, r, nickname)
+	}
+
+	_, err := r.GetForumRole(ctx, "FORUM", "mod")
+	expectCode(t, "GetForumRole(no role)", err, errs.NotFound)
+	_, err = r.SetForumRole(ctx, models.ForumRole{Forum: "nowhere", Nickname: "mod", Role: models.RoleModerator})
+	expectCode(t, "SetForumRole(missing forum)", err, errs.NotFound)
+	_, err = r.SetForumRole(ctx, models.ForumRole{Forum: forum.Slug, Nickname: "nobody", Role: models.RoleModerator})
+	expectCode(t, "SetForumRole(missing user)", err, errs.NotFound)
+
+	for _, role := range []models.ForumRole{
+		{Forum: "FORUM", Nickname: "mod", Role: models.RoleModerator, GrantedBy: owner.NickName},
+		{Forum: forum.Slug, Nickname: "alpha", Role: models.RoleModerator},
+		{Forum: forum.Slug, Nickname: "troll", Role: models.RoleModerator},
+		{Forum: forum.Slug, Nickname: "TROLL", Role: models.RoleBanned, GrantedBy: "mod"},
+	} {
+		if _, err := r.SetForumRole(ctx, role); err != nil {
+			t.Fatalf("SetForumRole(%+v): %v", role, err)
+		}
+	}
+	got, err := r.GetForumRole(ctx, forum.Slug, "MOD")
+	if err != nil || got.Forum != forum.Slug || got.Nickname != "Mod" || got.Role != models.RoleModerator ||
+		got.GrantedBy != owner.NickName || got.Granted.IsZero() {
+		t.Errorf("GetForumRole: got %+v, %v", got, err)
+	}
+	if got, err := r.GetForumRole(ctx, forum.Slug, "troll"); err != nil || got.Role != models.RoleBanned || got.Nickname != "Troll" {
+		t.Errorf("GetForumRole(replaced): got %+v, %v", got, err)
+	}
+
+	moderators, err := r.GetForumRoles(ctx, "forum", models.RoleModerator)
+	if err != nil {
+		t.Fatalf("GetForumRoles: %v", err)
+	}
+	if len(moderators) != 2 || moderators[0].Nickname != "alpha" || moderators[1].Nickname != "Mod" {
+		t.Errorf("GetForumRoles(moderator): got %+v", moderators)
+	}
+	if admins, err := r.GetForumRoles(ctx, forum.Slug, models.RoleAdmin); err != nil || admins == nil || len(admins) != 0 {
+		t.Errorf("GetForumRoles(admin): got %#v, %v, want empty slice", admins, err)
+	}
+
+	expectCode(t, "DeleteForumRole(other role)", r.DeleteForumRole(ctx, forum.Slug, "troll", models.RoleModerator), errs.NotFound)
+	if err := r.DeleteForumRole(ctx, forum.Slug, "TROLL", models.RoleBanned); err != nil {
+		t.Fatalf("DeleteForumRole: %v", err)
+	}
+	_, err = r.GetForumRole(ctx, forum.Slug, "troll")
+	expectCode(t, "GetForumRole(deleted)", err, errs.NotFound)
+	expectCode(t, "DeleteForumRole(again)", r.DeleteForumRole(ctx, forum.Slug, "troll", models.RoleBanned), errs.NotFound)
+
+	if _, err := r.Clear(ctx); err != nil {
+		t.Fatalf("Clear: %v", err)
+	}
+	_, err = r.GetForumRole(ctx, forum.Slug, "mod")
+	expectCode(t, "GetForumRole after Clear", err, errs.NotFound)
+}
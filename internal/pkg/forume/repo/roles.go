@@ -0,0 +1,87 @@
+package repo
+
+import (
+	"context"
+	"github.com/BigBullas/TP_DB_project/internal/errs"
+	"github.com/BigBullas/TP_DB_project/internal/models"
+	"github.com/jackc/pgx/v4"
+)
+
+// SetForumRole заменяет прежнюю роль пользователя в форуме. Слаг и ник берутся
+// из forum и users, чтобы в ответе они были в исходном регистре.
+func (r *repoPostgres) SetForumRole(ctx context.Context, role models.ForumRole) (models.ForumRole, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	const SetForumRole = `INSERT INTO forum_role(Forum, Nickname, Role, GrantedBy)
+SELECT f.Slug, u.Nickname, $3, NULLIF($4, '') FROM forum f, users u WHERE f.Slug = $1 AND u.Nickname = $2
+ON CONFLICT (Forum, Nickname) DO UPDATE SET Role = EXCLUDED.Role, GrantedBy = EXCLUDED.GrantedBy, Granted = now()
+RETURNING Forum, Nickname, Role, COALESCE(GrantedBy::text, ''), Granted;`
+	var set models.ForumRole
+	err := r.Conn.QueryRow(ctx, SetForumRole, role.Forum, role.Nickname, role.Role, role.GrantedBy).
+		Scan(&set.Forum, &set.Nickname, &set.Role, &set.GrantedBy, &set.Granted)
+	if err == pgx.ErrNoRows {
+		return models.ForumRole{}, errs.New(errs.CodeNotFound, "Can't find forum %s or user %s", role.Forum, role.Nickname)
+	}
+	if err != nil {
+		return models.ForumRole{}, errs.Wrap(err, "set role of %s in %s", role.Nickname, role.Forum)
+	}
+	return set, nil
+}
+
+func (r *repoPostgres) GetForumRole(ctx context.Context, forum string, nickname string) (models.ForumRole, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	const GetForumRole = `SELECT Forum, Nickname, Role, COALESCE(GrantedBy::text, ''), Granted FROM forum_role
+WHERE Forum = $1 AND Nickname = $2;`
+	var role models.ForumRole
+	err := r.Conn.QueryRow(ctx, GetForumRole, forum, nickname).
+		Scan(&role.Forum, &role.Nickname, &role.Role, &role.GrantedBy, &role.Granted)
+	if err == pgx.ErrNoRows {
+		return models.ForumRole{}, errs.New(errs.CodeNotFound, "%s has no role in forum %s", nickname, forum)
+	}
+	if err != nil {
+		return models.ForumRole{}, errs.Wrap(err, "get role of %s in %s", nickname, forum)
+	}
+	return role, nil
+}
+
+func (r *repoPostgres) GetForumRoles(ctx context.Context, forum string, role string) ([]models.ForumRole, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	const GetForumRoles = `SELECT Forum, Nickname, Role, COALESCE(GrantedBy::text, ''), Granted FROM forum_role
+WHERE Forum = $1 AND Role = $2 ORDER BY Nickname;`
+	rows, err := r.Conn.Query(ctx, GetForumRoles, forum, role)
+	if err != nil {
+		return nil, errs.Wrap(err, "get %s roles in %s", role, forum)
+	}
+	defer rows.Close()
+
+	roles := make([]models.ForumRole, 0)
+	for rows.Next() {
+		var fr models.ForumRole
+		if err := rows.Scan(&fr.Forum, &fr.Nickname, &fr.Role, &fr.GrantedBy, &fr.Granted); err != nil {
+			return nil, errs.Wrap(err, "get %s roles in %s", role, forum)
+		}
+		roles = append(roles, fr)
+	}
+	return roles, errs.Wrap(rows.Err(), "get %s roles in %s", role, forum)
+}
+
+// DeleteForumRole снимает роль, только если у пользователя именно она.
+func (r *repoPostgres) DeleteForumRole(ctx context.Context, forum string, nickname string, role string) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	const DeleteForumRole = `DELETE FROM forum_role WHERE Forum = $1 AND Nickname = $2 AND Role = $3;`
+	tag, err := r.Conn.Exec(ctx, DeleteForumRole, forum, nickname, role)
+	if err != nil {
+		return errs.Wrap(err, "delete role of %s in %s", nickname, forum)
+	}
+	if tag.RowsAffected() == 0 {
+		return errs.New(errs.CodeNotFound, "%s is not %s in forum %s", nickname, role, forum)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"github.com/BigBullas/TP_DB_project/internal/errs"
+	"github.com/BigBullas/TP_DB_project/internal/models"
+	"html"
+	"strings"
+)
+
+// ts_headline не экранирует текст, поэтому совпадения помечаются управляющими символами,
+// а HTML собирается в Go: сначала экранируется весь сниппет, потом метки заменяются на <b></b>.
+// Из самого сообщения метки вырезаются, чтобы их нельзя было подделать.
+const (
+	snippetStart = "\x02"
+	snippetStop  = "\x03"
+)
+
+var snippetTags = strings.NewReplacer(snippetStart, "<b>", snippetStop, "</b>")
+
+// Запрос разбирается конфигурацией каждого форума отдельно: векторы форума построены ею же.
+// Сниппеты считаются только для строк страницы, а не для всех совпадений.
+const searchHits = `WITH q AS (
+    SELECT Slug, COALESCE(Language, 'simple'::regconfig) AS config,
+           websearch_to_tsquery(COALESCE(Language, 'simple'::regconfig), $1) AS query
+    FROM forum WHERE $2 = '' OR Slug = $2::citext
+), hits AS (
+    SELECT 'post'::text AS kind, p.Id AS id, ts_rank(p.Search, q.query) AS rank, q.config, q.query,
+           p.Author::text AS author, p.Created AS created, p.Forum::text AS forum, p.Thread AS thread,
+           ''::text AS title, p.Message::text AS message, p.Parent AS parent, p.IsEdited AS isedited, ''::text AS slug, 0 AS votes
+    FROM post p JOIN q ON p.Forum = q.Slug
+    WHERE $3 <> 'thread' AND NOT p.IsDeleted AND p.Search @@ q.query
+      AND ($4 = 0 OR p.Thread = $4) AND ($5 = '' OR p.Author = $5::citext)
+      AND ($6::timestamptz IS NULL OR p.Created >= $6) AND ($7::timestamptz IS NULL OR p.Created < $7)
+    UNION ALL
+    SELECT 'thread'::text, t.Id, ts_rank(t.Search, q.query), q.config, q.query,
+           t.Author::text, t.Created, t.Forum::text, t.Id,
+           t.Title, t.Message, 0, false, COALESCE(t.Slug::text, ''), t.Votes
+    FROM thread t JOIN q ON t.Forum = q.Slug
+    WHERE $3 <> 'post' AND t.Search @@ q.query
+      AND ($4 = 0 OR t.Id = $4) AND ($5 = '' OR t.Author = $5::citext)
+      AND ($6::timestamptz IS NULL OR t.Created >= $6) AND ($7::timestamptz IS NULL OR t.Created < $7)
+)
+SELECT kind, id, rank,
+       ts_headline(config, translate(message, chr(2) || chr(3), ''), query,
+                   'MaxFragments=2, StartSel=' || chr(2) || ', StopSel=' || chr(3)),
+       author, created, forum, thread, title, message, parent, isedited, slug, votes
+FROM (SELECT * FROM hits WHERE %s ORDER BY rank %[2]s, kind %[2]s, id %[2]s LIMIT $8) page
+ORDER BY rank %[2]s, kind %[2]s, id %[2]s;`
+
+func (r *repoPostgres) Search(ctx context.Context, query models.SearchQuery) ([]models.SearchHit, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	order, after := "DESC", "<"
+	if query.Reverse {
+		order, after = "ASC", ">"
+	}
+	position := "true"
+	args := []interface{}{query.Query, query.Forum, query.Kind, query.Thread, query.Author, nil, nil, nil}
+	if !query.From.IsZero() {
+		args[5] = query.From
+	}
+	if !query.To.IsZero() {
+		args[6] = query.To
+	}
+	if query.Limit > 0 {
+		args[7] = query.Limit
+	}
+	if query.After != nil {
+		position = fmt.Sprintf("(rank, kind, id) %s ($9::real, $10::text, $11::int)", after)
+		args = append(args, query.After.Rank, query.After.Kind, query.After.ID)
+	}
+
+	rows, err := r.Conn.Query(ctx, fmt.Sprintf(searchHits, position, order), args...)
+	if err != nil {
+		return nil, errs.Wrap(err, "search %q", query.Query)
+	}
+	defer rows.Close()
+
+	hits := make([]models.SearchHit, 0)
+	for rows.Next() {
+		var hit models.SearchHit
+		var id, thread, parent, votes int
+		var author, forum, title, message, slug string
+		var post models.Post
+		err := rows.Scan(&hit.Kind, &id, &hit.Rank, &hit.Snippet,
+			&author, &post.Created, &forum, &thread, &title, &message, &parent, &post.IsEdited, &slug, &votes)
+		if err != nil {
+			return nil, errs.Wrap(err, "search %q", query.Query)
+		}
+		hit.Snippet = snippetTags.Replace(html.EscapeString(hit.Snippet))
+		if hit.Kind == models.SearchKindThread {
+			hit.Thread = &models.Thread{ID: id, Title: title, Author: author, Forum: forum, Message: message, Votes: votes, Slug: slug, Created: post.Created}
+		} else {
+			post.ID, post.Author, post.Forum, post.Thread, post.Message, post.Parent = id, author, forum, thread, message, parent
+			hit.Post = &post
+		}
+		hits = append(hits, hit)
+	}
+	if rows.Err() != nil {
+		return nil, errs.Wrap(rows.Err(), "search %q", query.Query)
+	}
+	return hits, nil
+}
@@ -0,0 +1,72 @@
+package repo
+
+import (
+	"context"
+	"github.com/BigBullas/TP_DB_project/internal/errs"
+	"github.com/BigBullas/TP_DB_project/internal/models"
+)
+
+// GetStatus без exact читает forum.Threads и forum.Posts, которые и так поддерживаются
+// при каждой записи, а users и vote оценивает по pg_class, как планировщик.
+// С exact считает всё count(*) — на миллионах постов это секунды.
+func (r *repoPostgres) GetStatus(ctx context.Context, exact bool) (models.Info, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	// reltuples отстаёт от таблицы до следующего ANALYZE, поэтому плотность строк умножается
+	// на текущий размер таблицы. Таблицу, которую ещё не анализировали, дешевле посчитать.
+	const Estimate = `SELECT CASE WHEN c.reltuples < 0 OR c.relpages = 0 THEN NULL
+ELSE (c.reltuples / c.relpages * (pg_relation_size(c.oid) / current_setting('block_size')::int))::bigint END
+FROM pg_class c WHERE c.oid = $1::text::regclass;`
+	const CountExact = `SELECT (SELECT count(*) FROM users), (SELECT count(*) FROM vote);`
+	const ByForum = `SELECT Slug, COALESCE(Threads, 0), COALESCE(Posts, 0) FROM forum ORDER BY Slug;`
+	const ByForumExact = `SELECT f.Slug, COALESCE(t.n, 0), COALESCE(p.n, 0) FROM forum f
+LEFT JOIN (SELECT Forum, count(*) AS n FROM thread GROUP BY Forum) t ON t.Forum = f.Slug
+LEFT JOIN (SELECT Forum, count(*) AS n FROM post WHERE NOT IsDeleted GROUP BY Forum) p ON p.Forum = f.Slug
+ORDER BY f.Slug;`
+
+	info := models.Info{Exact: exact, ByForum: []models.ForumInfo{}}
+	if exact {
+		if err := r.Conn.QueryRow(ctx, CountExact).Scan(&info.Users, &info.Votes); err != nil {
+			return models.Info{}, errs.Wrap(err, "count users and votes")
+		}
+	} else {
+		for table, dst := range map[string]*int64{"users": &info.Users, "vote": &info.Votes} {
+			var estimate *int64
+			if err := r.Conn.QueryRow(ctx, Estimate, table).Scan(&estimate); err != nil {
+				return models.Info{}, errs.Wrap(err, "estimate %s", table)
+			}
+			if estimate != nil {
+				*dst = *estimate
+				continue
+			}
+			if err := r.Conn.QueryRow(ctx, "SELECT count(*) FROM "+table).Scan(dst); err != nil {
+				return models.Info{}, errs.Wrap(err, "count %s", table)
+			}
+		}
+	}
+
+	query := ByForum
+	if exact {
+		query = ByForumExact
+	}
+	rows, err := r.Conn.Query(ctx, query)
+	if err != nil {
+		return models.Info{}, errs.Wrap(err, "count by forum")
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var forum models.ForumInfo
+		if err := rows.Scan(&forum.Slug, &forum.Threads, &forum.Posts); err != nil {
+			return models.Info{}, errs.Wrap(err, "scan forum counts")
+		}
+		info.ByForum = append(info.ByForum, forum)
+		info.Forums++
+		info.Threads += forum.Threads
+		info.Posts += forum.Posts
+	}
+	if err := rows.Err(); err != nil {
+		return models.Info{}, errs.Wrap(err, "count by forum")
+	}
+	return info, nil
+}
@@ -0,0 +1,138 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"github.com/BigBullas/TP_DB_project/internal/errs"
+	"github.com/BigBullas/TP_DB_project/internal/models"
+	"github.com/jackc/pgx/v4"
+)
+
+// writeModel отвечает за данные, производные от постов, веток и голосов: пути постов,
+// счётчики Posts/Threads у форума, users_forum и сумму голосов в ветке.
+// triggerWrites оставляет это триггерам из db/migrations, appWrites считает всё сам пакетными запросами;
+// в этом режиме триггеры отключены через forum.write_model = 'app' (см. NewPool).
+type writeModel interface {
+	// insertPosts вставляет уже проверенные посты и заполняет им ID и Created.
+	insertPosts(ctx context.Context, tx pgx.Tx, posts []models.Post, parentPaths map[int][]int32) error
+	threadCreated(ctx context.Context, tx pgx.Tx, thread models.Thread) error
+	voteChanged(ctx context.Context, tx pgx.Tx, threadID int, delta int) error
+	// postVisibilityChanged вызывается, когда пост удалён (delta = -1) или восстановлен (delta = 1).
+	postVisibilityChanged(ctx context.Context, tx pgx.Tx, forum string, delta int) error
+}
+
+func newWriteModel(name string) writeModel {
+	if name == "app" {
+		return appWrites{}
+	}
+	return triggerWrites{}
+}
+
+type triggerWrites struct{}
+
+func (triggerWrites) insertPosts(ctx context.Context, tx pgx.Tx, posts []models.Post, parentPaths map[int][]int32) error {
+	values := make([]interface{}, 0, len(posts)*7)
+	query := "INSERT INTO post (Author, Created, Forum, IsEdited, Message, Parent, Thread) VALUES"
+	for k, post := range posts {
+		query += fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d),", k*7+1, k*7+2, k*7+3, k*7+4, k*7+5, k*7+6, k*7+7)
+		values = append(values, post.Author, post.Created, post.Forum, post.IsEdited, post.Message, post.Parent, post.Thread)
+	}
+	query = query[:len(query)-1] + ` RETURNING id, created;`
+
+	rows, err := tx.Query(ctx, query, values...)
+	if err != nil {
+		return errs.Wrap(err, "create posts")
+	}
+	defer rows.Close()
+	for i := 0; rows.Next() && i < len(posts); i++ {
+		if err := rows.Scan(&posts[i].ID, &posts[i].Created); err != nil {
+			return errs.Wrap(err, "create posts")
+		}
+	}
+	return errs.Wrap(rows.Err(), "create posts")
+}
+
+func (triggerWrites) threadCreated(ctx context.Context, tx pgx.Tx, thread models.Thread) error {
+	return nil
+}
+
+func (triggerWrites) voteChanged(ctx context.Context, tx pgx.Tx, threadID int, delta int) error {
+	return nil
+}
+
+func (triggerWrites) postVisibilityChanged(ctx context.Context, tx pgx.Tx, forum string, delta int) error {
+	return nil
+}
+
+type appWrites struct{}
+
+// insertPosts заранее берёт Id из последовательности, чтобы посчитать пути до вставки,
+// и обновляет счётчик форума и users_forum одним запросом на пакет.
+func (appWrites) insertPosts(ctx context.Context, tx pgx.Tx, posts []models.Post, parentPaths map[int][]int32) error {
+	const NextIds = `SELECT nextval(pg_get_serial_sequence('post', 'id'))::INT FROM generate_series(1, $1);`
+	rows, err := tx.Query(ctx, NextIds, len(posts))
+	if err != nil {
+		return errs.Wrap(err, "allocate post ids")
+	}
+	for i := 0; rows.Next() && i < len(posts); i++ {
+		if err := rows.Scan(&posts[i].ID); err != nil {
+			rows.Close()
+			return errs.Wrap(err, "allocate post ids")
+		}
+	}
+	rows.Close()
+	if rows.Err() != nil {
+		return errs.Wrap(rows.Err(), "allocate post ids")
+	}
+
+	values := make([]interface{}, 0, len(posts)*9)
+	authors := make([]string, 0, len(posts))
+	query := "INSERT INTO post (Id, Author, Created, Forum, IsEdited, Message, Parent, Thread, Path) VALUES"
+	for k, post := range posts {
+		path := append(append([]int32{}, parentPaths[post.Parent]...), int32(post.ID))
+
+		n := k * 9
+		query += fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d),", n+1, n+2, n+3, n+4, n+5, n+6, n+7, n+8, n+9)
+		values = append(values, post.ID, post.Author, post.Created, post.Forum, post.IsEdited, post.Message, post.Parent, post.Thread, path)
+		authors = append(authors, post.Author)
+	}
+	query = query[:len(query)-1] + ";"
+	if _, err := tx.Exec(ctx, query, values...); err != nil {
+		return errs.Wrap(err, "create posts")
+	}
+
+	forum := posts[0].Forum
+	const Counter = `UPDATE forum SET Posts = Posts + $2 WHERE Slug = $1;`
+	if _, err := tx.Exec(ctx, Counter, forum, len(posts)); err != nil {
+		return errs.Wrap(err, "update forum posts")
+	}
+	return addForumUsers(ctx, tx, forum, authors)
+}
+
+func (appWrites) threadCreated(ctx context.Context, tx pgx.Tx, thread models.Thread) error {
+	const Counter = `UPDATE forum SET Threads = Threads + 1 WHERE Slug = $1;`
+	if _, err := tx.Exec(ctx, Counter, thread.Forum); err != nil {
+		return errs.Wrap(err, "update forum threads")
+	}
+	return addForumUsers(ctx, tx, thread.Forum, []string{thread.Author})
+}
+
+func (appWrites) voteChanged(ctx context.Context, tx pgx.Tx, threadID int, delta int) error {
+	const UpdateVotes = `UPDATE thread SET Votes = Votes + $2 WHERE Id = $1;`
+	_, err := tx.Exec(ctx, UpdateVotes, threadID, delta)
+	return errs.Wrap(err, "update thread votes")
+}
+
+func (appWrites) postVisibilityChanged(ctx context.Context, tx pgx.Tx, forum string, delta int) error {
+	const Counter = `UPDATE forum SET Posts = Posts + $2 WHERE Slug = $1;`
+	_, err := tx.Exec(ctx, Counter, forum, delta)
+	return errs.Wrap(err, "update forum posts")
+}
+
+func addForumUsers(ctx context.Context, tx pgx.Tx, forum string, nicknames []string) error {
+	const AddForumUsers = `INSERT INTO users_forum (Nickname, FullName, About, Email, Slug)
+SELECT Nickname, FullName, About, Email, $1 FROM users WHERE Nickname = ANY($2::text[]::citext[])
+ON CONFLICT DO NOTHING;`
+	_, err := tx.Exec(ctx, AddForumUsers, forum, nicknames)
+	return errs.Wrap(err, "update forum users")
+}
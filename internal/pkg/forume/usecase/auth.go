@@ -0,0 +1,126 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/BigBullas/TP_DB_project/internal/auth"
+	"github.com/BigBullas/TP_DB_project/internal/errs"
+	"github.com/BigBullas/TP_DB_project/internal/models"
+	"strings"
+	"time"
+)
+
+const minPasswordLen = 8
+
+// Хеш случайной строки: для неизвестного ника Login тратит на проверку столько же времени,
+// сколько для известного, и по времени ответа нельзя перебирать ники.
+const dummyPasswordHash = "$argon2id$v=19$m=19456,t=2,p=1$d8XUBEC2PAMnGrxjwvKcYA$T7fXk0soE82waOb3TPbdFpt2ojcySk0k5oLcWJy2qUc"
+
+// actAs проверяет, что запрос может действовать от имени nickname. Анонимный запрос
+// проходит, только если вход не обязателен.
+func (u *UseCase) actAs(ctx context.Context, nickname string, field string) error {
+	caller := auth.Caller(ctx)
+	if caller == "" {
+		return u.authenticated(ctx)
+	}
+	if !strings.EqualFold(caller, nickname) {
+		return errs.New(errs.CodeForbidden, "%s can not act as %s", caller, nickname).WithField(field)
+	}
+	return nil
+}
+
+func (u *UseCase) authenticated(ctx context.Context) error {
+	if u.auth.Required && auth.Caller(ctx) == "" {
+		return errs.New(errs.CodeUnauthorized, "authentication required")
+	}
+	return nil
+}
+
+func checkPassword(password string) error {
+	if len(password) < minPasswordLen {
+		return errs.New(errs.CodeBadRequest, "password must be at least %d bytes long", minPasswordLen).WithField("password")
+	}
+	return nil
+}
+
+func hashPassword(password string) (string, error) {
+	hash, err := auth.HashPassword(password)
+	return hash, errs.Wrap(err, "hash password")
+}
+
+func (u *UseCase) setPassword(ctx context.Context, nickname string, password string) error {
+	hash, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+	return u.repo.SetPassword(ctx, nickname, hash)
+}
+
+func (u *UseCase) Login(ctx context.Context, login models.Login) (models.Credential, error) {
+	invalid := errs.New(errs.CodeUnauthorized, "invalid nickname or password")
+	hash, err := u.repo.GetPassword(ctx, login.Nickname)
+	if errors.Is(err, errs.NotFound) {
+		auth.CheckPassword(dummyPasswordHash, login.Password)
+		u.log.WarnContext(ctx, "login failed", "nickname", login.Nickname, "reason", "no password")
+		return models.Credential{}, invalid
+	}
+	if err != nil {
+		return models.Credential{}, err
+	}
+	if !auth.CheckPassword(hash, login.Password) {
+		u.log.WarnContext(ctx, "login failed", "nickname", login.Nickname, "reason", "wrong password")
+		return models.Credential{}, invalid
+	}
+	user, err := u.repo.GetUser(ctx, login.Nickname)
+	if err != nil {
+		return models.Credential{}, err
+	}
+	u.log.InfoContext(ctx, "logged in", "nickname", user.NickName)
+	return u.issue(ctx, models.CredentialSession, user.NickName, "", u.auth.SessionTTL)
+}
+
+func (u *UseCase) CreateToken(ctx context.Context, name string) (models.Credential, error) {
+	caller := auth.Caller(ctx)
+	if caller == "" {
+		return models.Credential{}, errs.New(errs.CodeUnauthorized, "authentication required")
+	}
+	u.log.InfoContext(ctx, "api token issued", "name", name)
+	return u.issue(ctx, models.CredentialToken, caller, name, u.auth.TokenTTL)
+}
+
+func (u *UseCase) issue(ctx context.Context, kind string, nickname string, name string, ttl time.Duration) (models.Credential, error) {
+	token, err := auth.NewToken()
+	if err != nil {
+		return models.Credential{}, errs.Wrap(err, "generate token")
+	}
+	now := time.Now()
+	cred := models.Credential{Hash: auth.HashToken(token), Kind: kind, Nickname: nickname, Name: name, Created: now, Expires: now.Add(ttl)}
+	if err := u.repo.CreateCredential(ctx, cred); err != nil {
+		return models.Credential{}, err
+	}
+	cred.Token = token
+	return cred, nil
+}
+
+func (u *UseCase) Logout(ctx context.Context, token string) error {
+	return u.repo.DeleteCredential(ctx, auth.HashToken(token))
+}
+
+func (u *UseCase) Authenticate(ctx context.Context, token string) (string, error) {
+	invalid := errs.New(errs.CodeUnauthorized, "invalid or expired token")
+	cred, err := u.repo.GetCredential(ctx, auth.HashToken(token))
+	if errors.Is(err, errs.NotFound) {
+		return "", invalid
+	}
+	if err != nil {
+		return "", err
+	}
+	if time.Now().After(cred.Expires) {
+		// Просроченные записи удаляются при первой попытке ими воспользоваться.
+		if err := u.repo.DeleteCredential(ctx, cred.Hash); err != nil {
+			return "", err
+		}
+		return "", invalid
+	}
+	return cred.Nickname, nil
+}
@@ -0,0 +1,163 @@
+package usecase
+
+import (
+	"errors"
+	"github.com/BigBullas/TP_DB_project/internal/auth"
+	"github.com/BigBullas/TP_DB_project/internal/config"
+	"github.com/BigBullas/TP_DB_project/internal/errs"
+	"github.com/BigBullas/TP_DB_project/internal/models"
+	"github.com/BigBullas/TP_DB_project/internal/pkg/forume/repo"
+	"testing"
+	"time"
+)
+
+func expectCode(t *testing.T, what string, err error, want *errs.Error) {
+	t.Helper()
+	if !errors.Is(err, want) {
+		t.Errorf("%s: got error %v, want %s", what, err, want.Code)
+	}
+}
+
+func TestLogin(t *testing.T) {
+	uc := NewRepoUseCase(repo.NewRepoMemory(), nil, config.Auth{Required: true, SessionTTL: time.Hour, TokenTTL: time.Hour}, nil)
+
+	_, err := uc.CreateUser(ctx, models.User{NickName: "Alice", Email: "alice@mail.ru"})
+	expectCode(t, "CreateUser without password", err, errs.BadRequest)
+	_, err = uc.CreateUser(ctx, models.User{NickName: "Alice", Email: "alice@mail.ru", Password: "short"})
+	expectCode(t, "CreateUser with short password", err, errs.BadRequest)
+	users, err := uc.CreateUser(ctx, models.User{NickName: "Alice", Email: "alice@mail.ru", Password: "password1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if users[0].Password != "" {
+		t.Error("CreateUser returns the password")
+	}
+
+	for _, login := range []models.Login{{Nickname: "alice", Password: "password2"}, {Nickname: "bob", Password: "password1"}} {
+		_, err := uc.Login(ctx, login)
+		expectCode(t, "Login("+login.Nickname+", "+login.Password+")", err, errs.Unauthorized)
+	}
+	session, err := uc.Login(ctx, models.Login{Nickname: "alice", Password: "password1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.Kind != models.CredentialSession || session.Nickname != "Alice" || session.Token == "" {
+		t.Errorf("session: %+v", session)
+	}
+	if nickname, err := uc.Authenticate(ctx, session.Token); err != nil || nickname != "Alice" {
+		t.Errorf("Authenticate(session): %q, %v", nickname, err)
+	}
+
+	_, err = uc.CreateToken(ctx, "ci")
+	expectCode(t, "CreateToken anonymously", err, errs.Unauthorized)
+	token, err := uc.CreateToken(auth.WithCaller(ctx, "Alice"), "ci")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nickname, err := uc.Authenticate(ctx, token.Token); err != nil || nickname != "Alice" || token.Kind != models.CredentialToken {
+		t.Errorf("Authenticate(token): %q, %v", nickname, err)
+	}
+
+	if err := uc.Logout(ctx, session.Token); err != nil {
+		t.Fatal(err)
+	}
+	_, err = uc.Authenticate(ctx, session.Token)
+	expectCode(t, "Authenticate after Logout", err, errs.Unauthorized)
+	_, err = uc.Authenticate(ctx, "garbage")
+	expectCode(t, "Authenticate(garbage)", err, errs.Unauthorized)
+
+	if _, err := uc.ChangeUserInfo(auth.WithCaller(ctx, "alice"), models.User{NickName: "Alice", Password: "password3"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uc.Login(ctx, models.Login{Nickname: "alice", Password: "password3"}); err != nil {
+		t.Errorf("Login with changed password: %v", err)
+	}
+}
+
+func TestExpiredSession(t *testing.T) {
+	uc := NewRepoUseCase(repo.NewRepoMemory(), nil, config.Auth{SessionTTL: time.Nanosecond}, nil)
+	if _, err := uc.CreateUser(ctx, models.User{NickName: "alice", Email: "alice@mail.ru", Password: "password1"}); err != nil {
+		t.Fatal(err)
+	}
+	session, err := uc.Login(ctx, models.Login{Nickname: "alice", Password: "password1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond)
+	_, err = uc.Authenticate(ctx, session.Token)
+	expectCode(t, "Authenticate(expired)", err, errs.Unauthorized)
+}
+
+func TestActAs(t *testing.T) {
+	r := repo.NewRepoMemory()
+	for _, nickname := range []string{"alice", "bob"} {
+		if err := r.CreateUser(ctx, models.User{NickName: nickname, Email: nickname + "@mail.ru"}, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := r.CreateForum(ctx, models.Forum{Title: "f", User: "alice", Slug: "forum"}); err != nil {
+		t.Fatal(err)
+	}
+	thread, err := r.CreateThread(ctx, models.Thread{Title: "t", Author: "alice", Forum: "forum", Message: "m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice, bob := auth.WithCaller(ctx, "Alice"), auth.WithCaller(ctx, "bob")
+
+	for _, required := range []bool{false, true} {
+		uc := NewRepoUseCase(r, nil, config.Auth{Required: required}, nil)
+		_, err := uc.CreatePosts(ctx, []models.Post{{Author: "alice", Message: "anonymous"}}, thread)
+		if required {
+			expectCode(t, "anonymous CreatePosts", err, errs.Unauthorized)
+		} else if err != nil {
+			t.Errorf("anonymous CreatePosts without required auth: %v", err)
+		}
+
+		_, err = uc.CreatePosts(bob, []models.Post{{Author: "bob", Message: "ok"}, {Author: "alice", Message: "forged"}}, thread)
+		expectCode(t, "CreatePosts as another author", err, errs.Forbidden)
+		var e *errs.Error
+		if errors.As(err, &e) && e.Field != "posts[1].author" {
+			t.Errorf("CreatePosts as another author: field %q", e.Field)
+		}
+		_, err = uc.ChangeUserInfo(bob, models.User{NickName: "alice", Email: "bob2@mail.ru"})
+		expectCode(t, "ChangeUserInfo of another user", err, errs.Forbidden)
+		_, err = uc.ChangeUserInfo(ctx, models.User{NickName: "alice", Password: "password1"})
+		if required {
+			expectCode(t, "anonymous password change", err, errs.Unauthorized)
+		} else {
+			expectCode(t, "anonymous password change", err, errs.Forbidden)
+		}
+		_, err = uc.ChangeVote(bob, models.Vote{Nickname: "alice", Voice: 1, Thread: thread.ID}, thread)
+		expectCode(t, "ChangeVote as another user", err, errs.Forbidden)
+		_, err = uc.ChangeThreadInfo(bob, models.Thread{Title: "t2"}, thread)
+		expectCode(t, "ChangeThreadInfo of another author", err, errs.Forbidden)
+	}
+
+	uc := NewRepoUseCase(r, nil, config.Auth{Required: true}, nil)
+	posts, err := uc.CreatePosts(alice, []models.Post{{Author: "alice", Message: "mine"}}, thread)
+	if err != nil {
+		t.Fatal(err)
+	}
+	post := posts[0]
+	_, err = uc.ChangePostInfo(bob, models.Post{Message: "edited"}, post)
+	expectCode(t, "ChangePostInfo of another author", err, errs.Forbidden)
+	if _, err := uc.ChangePostInfo(alice, models.Post{Message: "edited"}, post); err != nil {
+		t.Fatal(err)
+	}
+	history, _ := uc.GetPostHistory(ctx, post.ID)
+	if len(history) != 2 || history[1].Editor != "Alice" {
+		t.Errorf("edit is not attributed to the caller: %+v", history)
+	}
+
+	_, err = uc.DeletePost(bob, post.ID)
+	expectCode(t, "DeletePost of another author", err, errs.Forbidden)
+	if _, err := uc.DeletePost(alice, post.ID); err != nil {
+		t.Fatal(err)
+	}
+	_, err = uc.RestorePost(bob, post.ID)
+	expectCode(t, "RestorePost of another author", err, errs.Forbidden)
+	// alice владеет форумом; автор без роли пост не вернёт, см. TestRestoreRequiresModerator.
+	if _, err := uc.RestorePost(alice, post.ID); err != nil {
+		t.Errorf("forum owner can not restore a deleted post: %v", err)
+	}
+}
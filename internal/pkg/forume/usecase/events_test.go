@@ -0,0 +1,59 @@
+package usecase
+
+import (
+	"github.com/BigBullas/TP_DB_project/internal/config"
+	"github.com/BigBullas/TP_DB_project/internal/events"
+	"github.com/BigBullas/TP_DB_project/internal/models"
+	"github.com/BigBullas/TP_DB_project/internal/pkg/forume/repo"
+	"testing"
+)
+
+func TestEventsArePublished(t *testing.T) {
+	r := repo.NewRepoMemory()
+	if err := r.CreateUser(ctx, models.User{NickName: "author", Email: "author@mail.ru"}, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.CreateForum(ctx, models.Forum{Title: "f", User: "author", Slug: "Forum"}); err != nil {
+		t.Fatal(err)
+	}
+	thread, err := r.CreateThread(ctx, models.Thread{Title: "t", Author: "author", Forum: "Forum", Message: "m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bus := events.New(100)
+	uc := NewRepoUseCase(r, bus, config.Auth{}, nil)
+	sub, _, _ := bus.Subscribe(events.Filter{Thread: thread.ID}, events.ID{})
+
+	posts, err := uc.CreatePosts(ctx, []models.Post{{Author: "author", Message: "a"}, {Author: "author", Message: "b"}}, thread)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uc.ChangePostInfo(ctx, models.Post{Message: "a2"}, posts[0]); err != nil {
+		t.Fatal(err)
+	}
+	// Правка без изменений ничего не публикует.
+	if _, err := uc.ChangePostInfo(ctx, models.Post{Message: "b"}, posts[1]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uc.ChangeVote(ctx, models.Vote{Nickname: "author", Voice: 1, Thread: thread.ID}, thread); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uc.ChangeThreadInfo(ctx, models.Thread{Title: "t2"}, thread); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uc.CreatePosts(ctx, []models.Post{{Author: "nobody", Message: "c"}}, thread); err == nil {
+		t.Fatal("post by unknown author is created")
+	}
+
+	want := []string{events.PostCreated, events.PostCreated, events.PostUpdated, events.ThreadVoted, events.ThreadUpdated}
+	if len(sub.C) != len(want) {
+		t.Fatalf("got %d events, want %d", len(sub.C), len(want))
+	}
+	for i, typ := range want {
+		e := <-sub.C
+		if e.Type != typ || e.Thread != thread.ID || e.Forum != "Forum" {
+			t.Errorf("event %d: got %s %s/%d, want %s", i, e.Type, e.Forum, e.Thread, typ)
+		}
+	}
+}
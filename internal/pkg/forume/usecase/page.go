@@ -0,0 +1,168 @@
+package usecase
+
+import (
+	"fmt"
+	"github.com/BigBullas/TP_DB_project/internal/errs"
+	"github.com/BigBullas/TP_DB_project/internal/models"
+	"hash/fnv"
+	"strings"
+	"time"
+)
+
+// pager листает один список по курсорам. Репозиторий умеет только «после позиции в заданном
+// направлении», поэтому страница назад — это запрос в обратную сторону с разворотом результата,
+// а наличие следующей страницы узнаётся по лишнему элементу сверх limit.
+type pager[T any] struct {
+	scope string
+	fetch func(params models.RequestParameters) ([]T, error)
+	// seek переносит позицию курсора в параметры запроса к репозиторию.
+	seek func(params *models.RequestParameters, c models.Cursor)
+	// at возвращает позицию элемента; Scope, Sort и направление заполняет list.
+	at func(item T) models.Cursor
+	// split делит выдачу на единицы, к которым относится limit; nil — каждый элемент сам по себе.
+	split func(items []T) [][]T
+}
+
+func (p pager[T]) list(params models.RequestParameters) ([]T, models.Page, error) {
+	var page models.Page
+	backward := false
+	if c := params.Cursor; c != nil {
+		if c.Scope != p.scope {
+			return nil, page, errs.New(errs.CodeBadRequest, "cursor was issued for another list").WithField("cursor")
+		}
+		params.Desc, backward = c.Desc, c.Backward
+		p.seek(&params, *c)
+	}
+
+	query := params
+	query.Desc = params.Desc != backward
+	if params.Limit > 0 {
+		query.Limit = params.Limit + 1
+	}
+	items, err := p.fetch(query)
+	if err != nil {
+		return nil, page, err
+	}
+
+	var groups [][]T
+	if p.split != nil {
+		groups = p.split(items)
+	} else {
+		for i := range items {
+			groups = append(groups, items[i:i+1])
+		}
+	}
+	more := params.Limit > 0 && len(groups) > params.Limit
+	if more {
+		groups = groups[:params.Limit]
+	}
+	if backward {
+		for i, j := 0, len(groups)-1; i < j; i, j = i+1, j-1 {
+			groups[i], groups[j] = groups[j], groups[i]
+		}
+	}
+	result := make([]T, 0, len(items))
+	for _, g := range groups {
+		result = append(result, g...)
+	}
+	if len(result) == 0 {
+		return result, page, nil
+	}
+
+	position := func(item T, backward bool) *models.Cursor {
+		c := p.at(item)
+		c.Scope, c.Sort, c.Desc, c.Backward = p.scope, params.Sort, params.Desc, backward
+		return &c
+	}
+	// Вперёд листать некуда только на последней странице; назад — на первой, то есть без курсора
+	// или когда обратный запрос не вернул лишнего.
+	if more || backward {
+		page.Next = position(result[len(result)-1], false)
+	}
+	if backward && more || !backward && params.Cursor != nil {
+		page.Prev = position(result[0], true)
+	}
+	return result, page, nil
+}
+
+func threadPager(scope string, fetch func(params models.RequestParameters) ([]models.Thread, error)) pager[models.Thread] {
+	return pager[models.Thread]{
+		scope: scope,
+		fetch: fetch,
+		seek: func(params *models.RequestParameters, c models.Cursor) {
+			params.Since, params.SinceID = c.Created.Format(time.RFC3339Nano), c.ID
+		},
+		at: func(t models.Thread) models.Cursor {
+			return models.Cursor{Created: t.Created, ID: t.ID}
+		},
+	}
+}
+
+func userPager(scope string, fetch func(params models.RequestParameters) ([]models.User, error)) pager[models.User] {
+	return pager[models.User]{
+		scope: scope,
+		fetch: fetch,
+		seek: func(params *models.RequestParameters, c models.Cursor) {
+			params.Since = c.Nickname
+		},
+		at: func(u models.User) models.Cursor {
+			return models.Cursor{Nickname: u.NickName}
+		},
+	}
+}
+
+// Для всех сортировок позиция — id поста: flat и tree продолжают после него,
+// parent_tree — после его корня.
+func postPager(scope string, fetch func(params models.RequestParameters) ([]models.Post, error)) pager[models.Post] {
+	return pager[models.Post]{
+		scope: scope,
+		fetch: fetch,
+		seek: func(params *models.RequestParameters, c models.Cursor) {
+			params.SinceInt = c.ID
+		},
+		at: func(p models.Post) models.Cursor {
+			return models.Cursor{ID: p.ID}
+		},
+	}
+}
+
+// Позиция в поиске — (rank, kind, id) результата. Курсор привязан к тексту запроса и фильтрам,
+// так что с другими фильтрами он не примется.
+func searchPager(query models.SearchQuery, search func(query models.SearchQuery) ([]models.SearchHit, error)) pager[models.SearchHit] {
+	filter := fnv.New64a()
+	fmt.Fprintf(filter, "%q %q %d %q %q %s %s", query.Query, strings.ToLower(query.Forum), query.Thread,
+		strings.ToLower(query.Author), query.Kind, query.From.Format(time.RFC3339Nano), query.To.Format(time.RFC3339Nano))
+
+	var after *models.Cursor
+	return pager[models.SearchHit]{
+		scope: fmt.Sprintf("search:%x", filter.Sum64()),
+		fetch: func(params models.RequestParameters) ([]models.SearchHit, error) {
+			query.After, query.Reverse, query.Limit = after, params.Desc, params.Limit
+			return search(query)
+		},
+		seek: func(_ *models.RequestParameters, c models.Cursor) {
+			after = &c
+		},
+		at: func(h models.SearchHit) models.Cursor {
+			c := models.Cursor{Rank: h.Rank, Kind: h.Kind}
+			if h.Thread != nil {
+				c.ID = h.Thread.ID
+			} else {
+				c.ID = h.Post.ID
+			}
+			return c
+		},
+	}
+}
+
+// splitByRoots режет выдачу parent_tree на ветки: каждая начинается с корневого поста.
+func splitByRoots(posts []models.Post) [][]models.Post {
+	var branches [][]models.Post
+	for i, p := range posts {
+		if p.Parent == 0 || i == 0 {
+			branches = append(branches, nil)
+		}
+		branches[len(branches)-1] = append(branches[len(branches)-1], p)
+	}
+	return branches
+}
@@ -0,0 +1,163 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/BigBullas/TP_DB_project/internal/config"
+	"github.com/BigBullas/TP_DB_project/internal/errs"
+	"github.com/BigBullas/TP_DB_project/internal/models"
+	"github.com/BigBullas/TP_DB_project/internal/pkg/forume"
+	"github.com/BigBullas/TP_DB_project/internal/pkg/forume/repo"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var ctx = context.Background()
+
+// walk проходит список страницами вперёд до конца, затем назад по prev_cursor
+// и проверяет, что обратный проход вернул те же страницы.
+func walk[T any](t *testing.T, name string, list func(params models.RequestParameters) ([]T, models.Page, error), params models.RequestParameters) []T {
+	t.Helper()
+	var pages [][]T
+	var last models.Page
+	for cursor := (*models.Cursor)(nil); ; {
+		params.Cursor = cursor
+		items, page, err := list(params)
+		if err != nil {
+			t.Fatalf("%s: page %d: %v", name, len(pages), err)
+		}
+		if len(pages) == 0 && page.Prev != nil {
+			t.Errorf("%s: first page has prev cursor", name)
+		}
+		pages, last = append(pages, items), page
+		if page.Next == nil {
+			break
+		}
+		if len(pages) > 100 {
+			t.Fatalf("%s: pagination does not end", name)
+		}
+		cursor = page.Next
+	}
+
+	for i, cursor := len(pages)-2, last.Prev; cursor != nil; i-- {
+		params.Cursor = cursor
+		items, page, err := list(params)
+		if err != nil {
+			t.Fatalf("%s: back to page %d: %v", name, i, err)
+		}
+		if i < 0 || !reflect.DeepEqual(items, pages[i]) {
+			t.Fatalf("%s: back to page %d: got %v", name, i, items)
+		}
+		if page.Next == nil {
+			t.Errorf("%s: page %d on the way back has no next cursor", name, i)
+		}
+		cursor = page.Prev
+		if cursor == nil && i != 0 {
+			t.Errorf("%s: prev cursors end at page %d", name, i)
+		}
+	}
+
+	var all []T
+	for _, p := range pages {
+		all = append(all, p...)
+	}
+	return all
+}
+
+func fixture(t *testing.T) (forume.UseCase, models.Thread) {
+	r := repo.NewRepoMemory()
+	var nicknames []string
+	for i := 0; i < 5; i++ {
+		nickname := fmt.Sprintf("user%d", i)
+		if err := r.CreateUser(ctx, models.User{NickName: nickname, Email: nickname + "@mail.ru"}, ""); err != nil {
+			t.Fatal(err)
+		}
+		nicknames = append(nicknames, nickname)
+	}
+	if _, err := r.CreateForum(ctx, models.Forum{Title: "f", User: nicknames[0], Slug: "Forum"}); err != nil {
+		t.Fatal(err)
+	}
+	var thread models.Thread
+	for i := 0; i < 5; i++ {
+		// Две ветки с одинаковым created проверяют строгость позиции.
+		created := time.Date(2020, 1, 1+i/2*2, 0, 0, 0, 0, time.UTC)
+		th, err := r.CreateThread(ctx, models.Thread{Title: "t", Author: nicknames[i], Forum: "forum", Message: "m", Created: created})
+		if err != nil {
+			t.Fatal(err)
+		}
+		thread = th
+	}
+	for root := 0; root < 4; root++ {
+		roots, err := r.CreatePosts(ctx, []models.Post{{Author: nicknames[root], Message: "root"}}, thread)
+		if err != nil {
+			t.Fatal(err)
+		}
+		children, err := r.CreatePosts(ctx, []models.Post{
+			{Author: nicknames[root+1], Message: "child", Parent: roots[0].ID},
+			{Author: nicknames[0], Message: "child", Parent: roots[0].ID},
+		}, thread)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := r.CreatePosts(ctx, []models.Post{{Author: nicknames[1], Message: "grandchild", Parent: children[0].ID}}, thread); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return NewRepoUseCase(r, nil, config.Auth{}, nil), thread
+}
+
+func TestCursorPagination(t *testing.T) {
+	uc, thread := fixture(t)
+
+	for _, desc := range []bool{false, true} {
+		full := models.RequestParameters{Limit: 100, Desc: desc}
+		paged := models.RequestParameters{Limit: 2, Desc: desc}
+
+		want, _, _ := uc.GetThreads(ctx, "forum", full)
+		got := walk(t, fmt.Sprintf("threads desc=%v", desc), func(p models.RequestParameters) ([]models.Thread, models.Page, error) {
+			return uc.GetThreads(ctx, "forum", p)
+		}, paged)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("threads desc=%v: pages add up to %v, want %v", desc, got, want)
+		}
+
+		wantUsers, _, _ := uc.GetUsers(ctx, "forum", full)
+		gotUsers := walk(t, fmt.Sprintf("users desc=%v", desc), func(p models.RequestParameters) ([]models.User, models.Page, error) {
+			return uc.GetUsers(ctx, "forum", p)
+		}, paged)
+		if !reflect.DeepEqual(gotUsers, wantUsers) {
+			t.Errorf("users desc=%v: pages add up to %v, want %v", desc, gotUsers, wantUsers)
+		}
+
+		for _, sort := range []string{"flat", "tree", "parent_tree"} {
+			full.Sort, paged.Sort = sort, sort
+			wantPosts, _, _ := uc.GetPosts(ctx, thread.ID, full)
+			gotPosts := walk(t, fmt.Sprintf("posts %s desc=%v", sort, desc), func(p models.RequestParameters) ([]models.Post, models.Page, error) {
+				return uc.GetPosts(ctx, thread.ID, p)
+			}, paged)
+			if len(wantPosts) != 16 || !reflect.DeepEqual(gotPosts, wantPosts) {
+				t.Errorf("posts %s desc=%v: pages add up to %v, want %v", sort, desc, gotPosts, wantPosts)
+			}
+		}
+	}
+}
+
+func TestCursorFromAnotherList(t *testing.T) {
+	uc, thread := fixture(t)
+
+	_, page, err := uc.GetThreads(ctx, "forum", models.RequestParameters{Limit: 1})
+	if err != nil || page.Next == nil {
+		t.Fatalf("GetThreads: %+v, %v", page, err)
+	}
+	_, _, err = uc.GetUsers(ctx, "forum", models.RequestParameters{Limit: 1, Cursor: page.Next})
+	var e *errs.Error
+	if !errors.As(err, &e) || e.Code != errs.CodeBadRequest || e.Field != "cursor" {
+		t.Errorf("users with threads cursor: got %v", err)
+	}
+	_, _, err = uc.GetPosts(ctx, thread.ID, models.RequestParameters{Limit: 1, Cursor: page.Next})
+	if !errors.Is(err, errs.BadRequest) {
+		t.Errorf("posts with threads cursor: got %v", err)
+	}
+}
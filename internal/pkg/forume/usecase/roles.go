@@ -0,0 +1,199 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/BigBullas/TP_DB_project/internal/auth"
+	"github.com/BigBullas/TP_DB_project/internal/errs"
+	"github.com/BigBullas/TP_DB_project/internal/models"
+	"strings"
+)
+
+var roleRank = map[string]int{
+	models.RoleBanned:    0,
+	models.RoleMember:    1,
+	models.RoleModerator: 2,
+	models.RoleOwner:     3,
+	models.RoleAdmin:     4,
+}
+
+func (u *UseCase) isAdmin(nickname string) bool {
+	for _, admin := range u.auth.Admins {
+		if strings.EqualFold(admin, nickname) {
+			return true
+		}
+	}
+	return false
+}
+
+// roleIn возвращает действующую роль nickname в форуме.
+func (u *UseCase) roleIn(ctx context.Context, forum models.Forum, nickname string) (string, error) {
+	if u.isAdmin(nickname) {
+		return models.RoleAdmin, nil
+	}
+	if strings.EqualFold(forum.User, nickname) {
+		return models.RoleOwner, nil
+	}
+	role, err := u.repo.GetForumRole(ctx, forum.Slug, nickname)
+	if errors.Is(err, errs.NotFound) {
+		return models.RoleMember, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return role.Role, nil
+}
+
+func (u *UseCase) callerRole(ctx context.Context, slug string) (string, error) {
+	forum, err := u.repo.GetForumDetails(ctx, slug)
+	if err != nil {
+		return "", err
+	}
+	return u.roleIn(ctx, forum, auth.Caller(ctx))
+}
+
+// notBanned запрещает забаненному в форуме писать в нём и голосовать.
+func (u *UseCase) notBanned(ctx context.Context, forum string) error {
+	if auth.Caller(ctx) == "" {
+		return nil
+	}
+	role, err := u.callerRole(ctx, forum)
+	if err != nil {
+		return err
+	}
+	if role == models.RoleBanned {
+		return errs.New(errs.CodeForbidden, "%s is banned in forum %s", auth.Caller(ctx), forum)
+	}
+	return nil
+}
+
+// moderate разрешает менять чужое модераторам форума и выше, своё — автору, если он не забанен.
+func (u *UseCase) moderate(ctx context.Context, forum string, author string) error {
+	if auth.Caller(ctx) == "" {
+		return u.authenticated(ctx)
+	}
+	role, err := u.callerRole(ctx, forum)
+	if err != nil {
+		return err
+	}
+	if role == models.RoleBanned {
+		return errs.New(errs.CodeForbidden, "%s is banned in forum %s", auth.Caller(ctx), forum)
+	}
+	if roleRank[role] >= roleRank[models.RoleModerator] {
+		return nil
+	}
+	return u.actAs(ctx, author, "author")
+}
+
+// requireRole требует у вызывающего роль не ниже min; без форума (forum == "") проверяется только admin.
+// Анонимный запрос проходит, только если вход не обязателен и min не выше участника:
+// права модератора и выше без входа не даются даже при auth.required=false.
+func (u *UseCase) requireRole(ctx context.Context, forum string, min string) error {
+	caller := auth.Caller(ctx)
+	if caller == "" {
+		if roleRank[min] > roleRank[models.RoleMember] {
+			return errs.New(errs.CodeUnauthorized, "%s role is required, sign in first", min)
+		}
+		return u.authenticated(ctx)
+	}
+	role := models.RoleMember
+	if u.isAdmin(caller) {
+		role = models.RoleAdmin
+	} else if forum != "" {
+		var err error
+		if role, err = u.callerRole(ctx, forum); err != nil {
+			return err
+		}
+	}
+	if roleRank[role] < roleRank[min] {
+		return errs.New(errs.CodeForbidden, "%s role is required", min)
+	}
+	return nil
+}
+
+// grantRank — роль, нужная, чтобы назначать и снимать role.
+func grantRank(role string) (string, error) {
+	switch role {
+	case models.RoleModerator:
+		return models.RoleOwner, nil
+	case models.RoleBanned:
+		return models.RoleModerator, nil
+	}
+	return "", errs.New(errs.CodeBadRequest, "unknown role %q", role).WithField("role")
+}
+
+func (u *UseCase) GetForumRoles(ctx context.Context, slug string, role string) ([]models.ForumRole, error) {
+	if _, err := grantRank(role); err != nil {
+		return nil, err
+	}
+	forum, err := u.repo.GetForumDetails(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	return u.repo.GetForumRoles(ctx, forum.Slug, role)
+}
+
+// GrantForumRole заменяет прежнюю роль пользователя. Забанить можно только того, чья роль ниже
+// роли вызывающего, а владельцу и администраторам роль модератора не нужна.
+func (u *UseCase) GrantForumRole(ctx context.Context, slug string, nickname string, role string) (models.ForumRole, error) {
+	forum, target, callerRole, err := u.checkGrant(ctx, slug, nickname, role)
+	if err != nil {
+		return models.ForumRole{}, err
+	}
+	targetRole, err := u.roleIn(ctx, forum, target.NickName)
+	if err != nil {
+		return models.ForumRole{}, err
+	}
+	if role == models.RoleModerator && roleRank[targetRole] > roleRank[models.RoleModerator] {
+		return models.ForumRole{}, errs.New(errs.CodeConflict, "%s is already %s of forum %s", target.NickName, targetRole, forum.Slug)
+	}
+	if role == models.RoleBanned && roleRank[targetRole] >= roleRank[callerRole] {
+		return models.ForumRole{}, errs.New(errs.CodeForbidden, "%s can not ban %s %s", auth.Caller(ctx), targetRole, target.NickName).WithField("nickname")
+	}
+	granted, err := u.repo.SetForumRole(ctx, models.ForumRole{Forum: forum.Slug, Nickname: target.NickName, Role: role, GrantedBy: auth.Caller(ctx)})
+	if err != nil {
+		return models.ForumRole{}, err
+	}
+	u.log.InfoContext(ctx, "forum role granted", "forum", forum.Slug, "nickname", target.NickName, "role", role)
+	return granted, nil
+}
+
+func (u *UseCase) RevokeForumRole(ctx context.Context, slug string, nickname string, role string) error {
+	forum, target, _, err := u.checkGrant(ctx, slug, nickname, role)
+	if err != nil {
+		return err
+	}
+	if err := u.repo.DeleteForumRole(ctx, forum.Slug, target.NickName, role); err != nil {
+		return err
+	}
+	u.log.InfoContext(ctx, "forum role revoked", "forum", forum.Slug, "nickname", target.NickName, "role", role)
+	return nil
+}
+
+// checkGrant проверяет право вызывающего управлять role и возвращает форум, цель и роль вызывающего.
+func (u *UseCase) checkGrant(ctx context.Context, slug string, nickname string, role string) (models.Forum, models.User, string, error) {
+	need, err := grantRank(role)
+	if err != nil {
+		return models.Forum{}, models.User{}, "", err
+	}
+	caller := auth.Caller(ctx)
+	if caller == "" {
+		return models.Forum{}, models.User{}, "", errs.New(errs.CodeUnauthorized, "authentication required")
+	}
+	forum, err := u.repo.GetForumDetails(ctx, slug)
+	if err != nil {
+		return models.Forum{}, models.User{}, "", err
+	}
+	callerRole, err := u.roleIn(ctx, forum, caller)
+	if err != nil {
+		return models.Forum{}, models.User{}, "", err
+	}
+	if roleRank[callerRole] < roleRank[need] {
+		return models.Forum{}, models.User{}, "", errs.New(errs.CodeForbidden, "%s role is required to manage %s role", need, role)
+	}
+	target, err := u.repo.GetUser(ctx, nickname)
+	if err != nil {
+		return models.Forum{}, models.User{}, "", err
+	}
+	return forum, target, callerRole, nil
+}
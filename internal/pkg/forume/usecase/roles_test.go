@@ -0,0 +1,240 @@
+package usecase
+
+import (
+	"fmt"
+	"github.com/BigBullas/TP_DB_project/internal/auth"
+	"github.com/BigBullas/TP_DB_project/internal/config"
+	"github.com/BigBullas/TP_DB_project/internal/errs"
+	"github.com/BigBullas/TP_DB_project/internal/models"
+	"github.com/BigBullas/TP_DB_project/internal/pkg/forume/repo"
+	"testing"
+)
+
+func TestForumRoles(t *testing.T) {
+	r := repo.NewRepoMemory()
+	for _, nickname := range []string{"owner", "mod", "alice", "troll", "root"} {
+		if err := r.CreateUser(ctx, models.User{NickName: nickname, Email: nickname + "@mail.ru"}, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := r.CreateForum(ctx, models.Forum{Title: "f", User: "owner", Slug: "forum"}); err != nil {
+		t.Fatal(err)
+	}
+	thread, err := r.CreateThread(ctx, models.Thread{Title: "t", Author: "alice", Forum: "forum", Message: "m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	uc := NewRepoUseCase(r, nil, config.Auth{Required: true, Admins: []string{"Root"}}, nil)
+	owner, mod, alice, troll, root := auth.WithCaller(ctx, "owner"), auth.WithCaller(ctx, "mod"),
+		auth.WithCaller(ctx, "alice"), auth.WithCaller(ctx, "troll"), auth.WithCaller(ctx, "root")
+
+	_, err = uc.GrantForumRole(ctx, "forum", "mod", models.RoleModerator)
+	expectCode(t, "anonymous GrantForumRole", err, errs.Unauthorized)
+	_, err = uc.GrantForumRole(alice, "forum", "mod", models.RoleModerator)
+	expectCode(t, "GrantForumRole by member", err, errs.Forbidden)
+	_, err = uc.GrantForumRole(owner, "forum", "mod", models.RoleOwner)
+	expectCode(t, "GrantForumRole(owner)", err, errs.BadRequest)
+	_, err = uc.GrantForumRole(owner, "forum", "owner", models.RoleModerator)
+	expectCode(t, "GrantForumRole to owner", err, errs.Conflict)
+	granted, err := uc.GrantForumRole(owner, "FORUM", "MOD", models.RoleModerator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if granted.Forum != "forum" || granted.Nickname != "mod" || granted.GrantedBy != "owner" {
+		t.Errorf("GrantForumRole: %+v", granted)
+	}
+	_, err = uc.GrantForumRole(mod, "forum", "alice", models.RoleModerator)
+	expectCode(t, "GrantForumRole(moderator) by moderator", err, errs.Forbidden)
+
+	posts, err := uc.CreatePosts(alice, []models.Post{{Author: "alice", Message: "mine"}}, thread)
+	if err != nil {
+		t.Fatal(err)
+	}
+	post := posts[0]
+	if _, err := uc.ChangePostInfo(mod, models.Post{Message: "moderated"}, post); err != nil {
+		t.Errorf("moderator can not edit a post: %v", err)
+	}
+	if _, err := uc.ChangeThreadInfo(mod, models.Thread{Title: "moderated"}, thread); err != nil {
+		t.Errorf("moderator can not edit a thread: %v", err)
+	}
+	if _, err := uc.DeletePost(mod, post.ID); err != nil {
+		t.Errorf("moderator can not delete a post: %v", err)
+	}
+	if _, err := uc.RestorePost(owner, post.ID); err != nil {
+		t.Errorf("owner can not restore a post: %v", err)
+	}
+
+	if _, err := uc.GrantForumRole(mod, "forum", "troll", models.RoleBanned); err != nil {
+		t.Fatal(err)
+	}
+	_, err = uc.GrantForumRole(mod, "forum", "owner", models.RoleBanned)
+	expectCode(t, "ban the owner", err, errs.Forbidden)
+	_, err = uc.CreatePosts(troll, []models.Post{{Author: "troll", Message: "spam"}}, thread)
+	expectCode(t, "CreatePosts while banned", err, errs.Forbidden)
+	_, err = uc.ChangeVote(troll, models.Vote{Nickname: "troll", Voice: -1, Thread: thread.ID}, thread)
+	expectCode(t, "ChangeVote while banned", err, errs.Forbidden)
+	_, err = uc.CreateThread(troll, models.Thread{Title: "spam", Author: "troll", Forum: "forum", Message: "m"})
+	expectCode(t, "CreateThread while banned", err, errs.Forbidden)
+	bans, err := uc.GetForumRoles(ctx, "forum", models.RoleBanned)
+	if err != nil || len(bans) != 1 || bans[0].Nickname != "troll" {
+		t.Errorf("GetForumRoles(banned): %+v, %v", bans, err)
+	}
+	if err := uc.RevokeForumRole(mod, "forum", "troll", models.RoleBanned); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uc.CreatePosts(troll, []models.Post{{Author: "troll", Message: "sorry"}}, thread); err != nil {
+		t.Errorf("CreatePosts after unban: %v", err)
+	}
+
+	expectCode(t, "RevokeForumRole(moderator) by moderator", uc.RevokeForumRole(mod, "forum", "mod", models.RoleModerator), errs.Forbidden)
+	if err := uc.RevokeForumRole(owner, "forum", "mod", models.RoleModerator); err != nil {
+		t.Fatal(err)
+	}
+	_, err = uc.ChangePostInfo(mod, models.Post{Message: "again"}, post)
+	expectCode(t, "ChangePostInfo after revoke", err, errs.Forbidden)
+	expectCode(t, "RevokeForumRole(again)", uc.RevokeForumRole(owner, "forum", "mod", models.RoleModerator), errs.NotFound)
+
+	_, err = uc.Clear(owner, "", false)
+	expectCode(t, "Clear by owner", err, errs.Forbidden)
+	if _, err := uc.GrantForumRole(root, "forum", "alice", models.RoleModerator); err != nil {
+		t.Errorf("admin can not grant a moderator: %v", err)
+	}
+	if _, err := uc.Clear(root, "", false); err != nil {
+		t.Errorf("admin can not Clear: %v", err)
+	}
+}
+
+func TestClear(t *testing.T) {
+	r := repo.NewRepoMemory()
+	if err := r.CreateUser(ctx, models.User{NickName: "root", Email: "root@mail.ru"}, ""); err != nil {
+		t.Fatal(err)
+	}
+	for _, slug := range []string{"a", "b"} {
+		if _, err := r.CreateForum(ctx, models.Forum{Title: slug, User: "root", Slug: slug}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	admin := NewRepoUseCase(r, nil, config.Auth{Admins: []string{"root"}}, nil)
+	_, err := admin.Clear(ctx, "", true)
+	expectCode(t, "anonymous Clear with admins", err, errs.Unauthorized)
+	uc := NewRepoUseCase(r, nil, config.Auth{}, nil)
+	report, err := uc.Clear(ctx, "", true)
+	if err != nil || report != (models.ClearReport{DryRun: true, Users: 1, Forums: 2}) {
+		t.Errorf("anonymous dry run: got %+v, %v", report, err)
+	}
+	if _, err := uc.Clear(auth.WithCaller(ctx, "root"), "", true); err != nil {
+		t.Errorf("signed-in dry run without admins: %v", err)
+	}
+	_, err = NewRepoUseCase(r, nil, config.Auth{Required: true}, nil).Clear(ctx, "", true)
+	expectCode(t, "anonymous Clear with required auth", err, errs.Unauthorized)
+	report, err = uc.Clear(ctx, "A", false)
+	if err != nil || report != (models.ClearReport{Forum: "a", Forums: 1}) {
+		t.Errorf("Clear(a): got %+v, %v", report, err)
+	}
+	if info, _ := uc.GetStatus(ctx, true); info.Users != 1 || info.Forums != 1 || info.Threads != 0 || info.Posts != 0 {
+		t.Errorf("GetStatus after Clear(a): %+v", info)
+	}
+	report, err = admin.Clear(auth.WithCaller(ctx, "root"), "", false)
+	if err != nil || report != (models.ClearReport{Users: 1, Forums: 1}) {
+		t.Errorf("Clear: got %+v, %v", report, err)
+	}
+	if info, _ := uc.GetStatus(ctx, true); info.Users != 0 || info.Forums != 0 || len(info.ByForum) != 0 {
+		t.Errorf("GetStatus after Clear: %+v", info)
+	}
+}
+
+func TestConsistencyRequiresAdmin(t *testing.T) {
+	r := repo.NewRepoMemory()
+	if err := r.CreateUser(ctx, models.User{NickName: "alice", Email: "alice@mail.ru"}, ""); err != nil {
+		t.Fatal(err)
+	}
+	for _, cfg := range []config.Auth{{}, {Admins: []string{"root"}}, {Required: true, Admins: []string{"root"}}} {
+		uc := NewRepoUseCase(r, nil, cfg, nil)
+		for _, repair := range []bool{false, true} {
+			_, err := uc.CheckConsistency(ctx, repair)
+			expectCode(t, fmt.Sprintf("anonymous check (repair=%v) with %+v", repair, cfg), err, errs.Unauthorized)
+			_, err = uc.CheckConsistency(auth.WithCaller(ctx, "alice"), repair)
+			expectCode(t, fmt.Sprintf("check (repair=%v) by member with %+v", repair, cfg), err, errs.Forbidden)
+		}
+	}
+
+	uc := NewRepoUseCase(r, nil, config.Auth{Admins: []string{"root"}}, nil)
+	for _, repair := range []bool{false, true} {
+		if _, err := uc.CheckConsistency(auth.WithCaller(ctx, "root"), repair); err != nil {
+			t.Errorf("admin check (repair=%v): %v", repair, err)
+		}
+	}
+}
+
+func TestImportRequiresModerator(t *testing.T) {
+	r := repo.NewRepoMemory()
+	for _, nickname := range []string{"owner", "mod", "alice"} {
+		if err := r.CreateUser(ctx, models.User{NickName: nickname, Email: nickname + "@mail.ru"}, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := r.CreateForum(ctx, models.Forum{Title: "f", User: "owner", Slug: "forum"}); err != nil {
+		t.Fatal(err)
+	}
+	thread, err := r.CreateThread(ctx, models.Thread{Title: "t", Author: "alice", Forum: "forum", Message: "m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.SetForumRole(ctx, models.ForumRole{Forum: "forum", Nickname: "mod", Role: models.RoleModerator}); err != nil {
+		t.Fatal(err)
+	}
+	posts := []models.ImportPost{{Ref: "1", Author: "alice", Message: "imported"}}
+
+	// Вход не обязателен, но импорт без входа всё равно запрещён.
+	uc := NewRepoUseCase(r, nil, config.Auth{}, nil)
+	_, err = uc.ImportPosts(ctx, posts, thread)
+	expectCode(t, "anonymous ImportPosts", err, errs.Unauthorized)
+	_, err = uc.ImportPosts(auth.WithCaller(ctx, "alice"), posts, thread)
+	expectCode(t, "ImportPosts by member", err, errs.Forbidden)
+	for _, nickname := range []string{"mod", "owner"} {
+		result, err := uc.ImportPosts(auth.WithCaller(ctx, nickname), posts, thread)
+		if err != nil || result.Imported != 1 {
+			t.Errorf("ImportPosts by %s: %+v, %v", nickname, result, err)
+		}
+	}
+}
+
+func TestRestoreRequiresModerator(t *testing.T) {
+	r := repo.NewRepoMemory()
+	for _, nickname := range []string{"owner", "mod", "alice"} {
+		if err := r.CreateUser(ctx, models.User{NickName: nickname, Email: nickname + "@mail.ru"}, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := r.CreateForum(ctx, models.Forum{Title: "f", User: "owner", Slug: "forum"}); err != nil {
+		t.Fatal(err)
+	}
+	thread, err := r.CreateThread(ctx, models.Thread{Title: "t", Author: "alice", Forum: "forum", Message: "m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.SetForumRole(ctx, models.ForumRole{Forum: "forum", Nickname: "mod", Role: models.RoleModerator}); err != nil {
+		t.Fatal(err)
+	}
+	uc := NewRepoUseCase(r, nil, config.Auth{}, nil)
+	alice, mod := auth.WithCaller(ctx, "alice"), auth.WithCaller(ctx, "mod")
+	posts, err := uc.CreatePosts(alice, []models.Post{{Author: "alice", Message: "mine"}}, thread)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uc.DeletePost(mod, posts[0].ID); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = uc.RestorePost(alice, posts[0].ID)
+	expectCode(t, "RestorePost by the author", err, errs.Forbidden)
+	_, err = uc.RestorePost(ctx, posts[0].ID)
+	expectCode(t, "anonymous RestorePost", err, errs.Unauthorized)
+	_, err = uc.RestorePost(mod, posts[0].ID+1)
+	expectCode(t, "RestorePost of a missing post", err, errs.NotFound)
+	restored, err := uc.RestorePost(mod, posts[0].ID)
+	if err != nil || restored.IsDeleted || restored.Message != "mine" {
+		t.Errorf("RestorePost by moderator: %+v, %v", restored, err)
+	}
+}
@@ -0,0 +1,204 @@
+package usecase
+
+import (
+	"context"
+	"github.com/BigBullas/TP_DB_project/internal/models"
+	"github.com/BigBullas/TP_DB_project/internal/pkg/forume"
+	"github.com/BigBullas/TP_DB_project/internal/tracing"
+)
+
+// useCaseTracing открывает span на каждый вызов next; запросы к базе внутри
+// становятся его дочерними спанами.
+type useCaseTracing struct {
+	next forume.UseCase
+}
+
+func WithTracing(next forume.UseCase) forume.UseCase {
+	return &useCaseTracing{next: next}
+}
+
+func (u *useCaseTracing) CreateUser(ctx context.Context, user models.User) (_ []models.User, err error) {
+	ctx, span := tracing.Start(ctx, "UseCase.CreateUser")
+	defer tracing.End(span, &err)
+	return u.next.CreateUser(ctx, user)
+}
+
+func (u *useCaseTracing) GetUser(ctx context.Context, nickname string) (_ models.User, err error) {
+	ctx, span := tracing.Start(ctx, "UseCase.GetUser")
+	defer tracing.End(span, &err)
+	return u.next.GetUser(ctx, nickname)
+}
+
+func (u *useCaseTracing) ChangeUserInfo(ctx context.Context, user models.User) (_ models.User, err error) {
+	ctx, span := tracing.Start(ctx, "UseCase.ChangeUserInfo")
+	defer tracing.End(span, &err)
+	return u.next.ChangeUserInfo(ctx, user)
+}
+
+func (u *useCaseTracing) CreateForum(ctx context.Context, forum models.Forum) (_ models.Forum, err error) {
+	ctx, span := tracing.Start(ctx, "UseCase.CreateForum")
+	defer tracing.End(span, &err)
+	return u.next.CreateForum(ctx, forum)
+}
+
+func (u *useCaseTracing) GetForumDetails(ctx context.Context, slug string) (_ models.Forum, err error) {
+	ctx, span := tracing.Start(ctx, "UseCase.GetForumDetails")
+	defer tracing.End(span, &err)
+	return u.next.GetForumDetails(ctx, slug)
+}
+
+func (u *useCaseTracing) CreateThread(ctx context.Context, thread models.Thread) (_ models.Thread, err error) {
+	ctx, span := tracing.Start(ctx, "UseCase.CreateThread")
+	defer tracing.End(span, &err)
+	return u.next.CreateThread(ctx, thread)
+}
+
+func (u *useCaseTracing) GetThreads(ctx context.Context, slug string, params models.RequestParameters) (_ []models.Thread, _ models.Page, err error) {
+	ctx, span := tracing.Start(ctx, "UseCase.GetThreads")
+	defer tracing.End(span, &err)
+	return u.next.GetThreads(ctx, slug, params)
+}
+
+func (u *useCaseTracing) GetThreadBySlugOrId(ctx context.Context, slugOrId string) (_ models.Thread, err error) {
+	ctx, span := tracing.Start(ctx, "UseCase.GetThreadBySlugOrId")
+	defer tracing.End(span, &err)
+	return u.next.GetThreadBySlugOrId(ctx, slugOrId)
+}
+
+func (u *useCaseTracing) CreatePosts(ctx context.Context, posts []models.Post, thread models.Thread) (_ []models.Post, err error) {
+	ctx, span := tracing.Start(ctx, "UseCase.CreatePosts")
+	defer tracing.End(span, &err)
+	return u.next.CreatePosts(ctx, posts, thread)
+}
+
+func (u *useCaseTracing) ImportPosts(ctx context.Context, posts []models.ImportPost, thread models.Thread) (_ models.ImportResult, err error) {
+	ctx, span := tracing.Start(ctx, "UseCase.ImportPosts")
+	defer tracing.End(span, &err)
+	return u.next.ImportPosts(ctx, posts, thread)
+}
+
+func (u *useCaseTracing) ChangeVote(ctx context.Context, vote models.Vote, thread models.Thread) (_ models.Thread, err error) {
+	ctx, span := tracing.Start(ctx, "UseCase.ChangeVote")
+	defer tracing.End(span, &err)
+	return u.next.ChangeVote(ctx, vote, thread)
+}
+
+func (u *useCaseTracing) ChangeThreadInfo(ctx context.Context, newThread models.Thread, oldThread models.Thread) (_ models.Thread, err error) {
+	ctx, span := tracing.Start(ctx, "UseCase.ChangeThreadInfo")
+	defer tracing.End(span, &err)
+	return u.next.ChangeThreadInfo(ctx, newThread, oldThread)
+}
+
+func (u *useCaseTracing) GetUsers(ctx context.Context, slug string, params models.RequestParameters) (_ []models.User, _ models.Page, err error) {
+	ctx, span := tracing.Start(ctx, "UseCase.GetUsers")
+	defer tracing.End(span, &err)
+	return u.next.GetUsers(ctx, slug, params)
+}
+
+func (u *useCaseTracing) GetPostDetails(ctx context.Context, id int, related []string) (_ models.PostDetailed, err error) {
+	ctx, span := tracing.Start(ctx, "UseCase.GetPostDetails")
+	defer tracing.End(span, &err)
+	return u.next.GetPostDetails(ctx, id, related)
+}
+
+func (u *useCaseTracing) ChangePostInfo(ctx context.Context, newPost models.Post, oldPost models.Post) (_ models.Post, err error) {
+	ctx, span := tracing.Start(ctx, "UseCase.ChangePostInfo")
+	defer tracing.End(span, &err)
+	return u.next.ChangePostInfo(ctx, newPost, oldPost)
+}
+
+func (u *useCaseTracing) DeletePost(ctx context.Context, id int) (_ models.Post, err error) {
+	ctx, span := tracing.Start(ctx, "UseCase.DeletePost")
+	defer tracing.End(span, &err)
+	return u.next.DeletePost(ctx, id)
+}
+
+func (u *useCaseTracing) RestorePost(ctx context.Context, id int) (_ models.Post, err error) {
+	ctx, span := tracing.Start(ctx, "UseCase.RestorePost")
+	defer tracing.End(span, &err)
+	return u.next.RestorePost(ctx, id)
+}
+
+func (u *useCaseTracing) GetPostHistory(ctx context.Context, id int) (_ []models.PostRevision, err error) {
+	ctx, span := tracing.Start(ctx, "UseCase.GetPostHistory")
+	defer tracing.End(span, &err)
+	return u.next.GetPostHistory(ctx, id)
+}
+
+func (u *useCaseTracing) GetPostDiff(ctx context.Context, id int, from int, to int) (_ models.PostDiff, err error) {
+	ctx, span := tracing.Start(ctx, "UseCase.GetPostDiff")
+	defer tracing.End(span, &err)
+	return u.next.GetPostDiff(ctx, id, from, to)
+}
+
+func (u *useCaseTracing) GetStatus(ctx context.Context, exact bool) (_ models.Info, err error) {
+	ctx, span := tracing.Start(ctx, "UseCase.GetStatus")
+	defer tracing.End(span, &err)
+	return u.next.GetStatus(ctx, exact)
+}
+
+func (u *useCaseTracing) Clear(ctx context.Context, forum string, dryRun bool) (_ models.ClearReport, err error) {
+	ctx, span := tracing.Start(ctx, "UseCase.Clear")
+	defer tracing.End(span, &err)
+	return u.next.Clear(ctx, forum, dryRun)
+}
+
+func (u *useCaseTracing) CheckConsistency(ctx context.Context, repair bool) (_ models.ConsistencyReport, err error) {
+	ctx, span := tracing.Start(ctx, "UseCase.CheckConsistency")
+	defer tracing.End(span, &err)
+	return u.next.CheckConsistency(ctx, repair)
+}
+
+func (u *useCaseTracing) GetPosts(ctx context.Context, idPost int, params models.RequestParameters) (_ []models.Post, _ models.Page, err error) {
+	ctx, span := tracing.Start(ctx, "UseCase.GetPosts")
+	defer tracing.End(span, &err)
+	return u.next.GetPosts(ctx, idPost, params)
+}
+
+func (u *useCaseTracing) Search(ctx context.Context, query models.SearchQuery, params models.RequestParameters) (_ []models.SearchHit, _ models.Page, err error) {
+	ctx, span := tracing.Start(ctx, "UseCase.Search")
+	defer tracing.End(span, &err)
+	return u.next.Search(ctx, query, params)
+}
+
+func (u *useCaseTracing) Login(ctx context.Context, login models.Login) (_ models.Credential, err error) {
+	ctx, span := tracing.Start(ctx, "UseCase.Login")
+	defer tracing.End(span, &err)
+	return u.next.Login(ctx, login)
+}
+
+func (u *useCaseTracing) CreateToken(ctx context.Context, name string) (_ models.Credential, err error) {
+	ctx, span := tracing.Start(ctx, "UseCase.CreateToken")
+	defer tracing.End(span, &err)
+	return u.next.CreateToken(ctx, name)
+}
+
+func (u *useCaseTracing) Logout(ctx context.Context, token string) (err error) {
+	ctx, span := tracing.Start(ctx, "UseCase.Logout")
+	defer tracing.End(span, &err)
+	return u.next.Logout(ctx, token)
+}
+
+func (u *useCaseTracing) Authenticate(ctx context.Context, token string) (_ string, err error) {
+	ctx, span := tracing.Start(ctx, "UseCase.Authenticate")
+	defer tracing.End(span, &err)
+	return u.next.Authenticate(ctx, token)
+}
+
+func (u *useCaseTracing) GetForumRoles(ctx context.Context, slug string, role string) (_ []models.ForumRole, err error) {
+	ctx, span := tracing.Start(ctx, "UseCase.GetForumRoles")
+	defer tracing.End(span, &err)
+	return u.next.GetForumRoles(ctx, slug, role)
+}
+
+func (u *useCaseTracing) GrantForumRole(ctx context.Context, slug string, nickname string, role string) (_ models.ForumRole, err error) {
+	ctx, span := tracing.Start(ctx, "UseCase.GrantForumRole")
+	defer tracing.End(span, &err)
+	return u.next.GrantForumRole(ctx, slug, nickname, role)
+}
+
+func (u *useCaseTracing) RevokeForumRole(ctx context.Context, slug string, nickname string, role string) (err error) {
+	ctx, span := tracing.Start(ctx, "UseCase.RevokeForumRole")
+	defer tracing.End(span, &err)
+	return u.next.RevokeForumRole(ctx, slug, nickname, role)
+}
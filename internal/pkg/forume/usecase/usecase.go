@@ -3,26 +3,60 @@ package usecase
 import (
 	"context"
 	"fmt"
+	"github.com/BigBullas/TP_DB_project/internal/auth"
+	"github.com/BigBullas/TP_DB_project/internal/config"
+	"github.com/BigBullas/TP_DB_project/internal/diff"
+	"github.com/BigBullas/TP_DB_project/internal/errs"
+	"github.com/BigBullas/TP_DB_project/internal/events"
+	"github.com/BigBullas/TP_DB_project/internal/logging"
 	"github.com/BigBullas/TP_DB_project/internal/models"
 	"github.com/BigBullas/TP_DB_project/internal/pkg/forume"
-	"net/http"
+	"log/slog"
 	"strconv"
+	"strings"
 )
 
 type UseCase struct {
 	repo forume.Repository
+	// Изменения тредов и постов публикуются после успешной записи; nil — не публиковать.
+	bus  *events.Bus
+	auth config.Auth
+	log  *slog.Logger
 }
 
-func NewRepoUseCase(repo forume.Repository) forume.UseCase { // почему не *forume.Repository
-	return &UseCase{repo: repo}
+// log может быть nil, тогда UseCase ничего не пишет.
+func NewRepoUseCase(repo forume.Repository, bus *events.Bus, auth config.Auth, log *slog.Logger) forume.UseCase { // почему не *forume.Repository
+	if log == nil {
+		log = logging.Discard()
+	}
+	return &UseCase{repo: repo, bus: bus, auth: auth, log: log}
 }
 
 func (u *UseCase) CreateUser(ctx context.Context, user models.User) ([]models.User, error) {
-	usersWithSameInfo, _ := u.repo.CheckUserForUniq(ctx, user)
+	password := user.Password
+	user.Password = ""
+	if password == "" && u.auth.Required {
+		return nil, errs.New(errs.CodeBadRequest, "password is required").WithField("password")
+	}
+	var hash string
+	if password != "" {
+		if err := checkPassword(password); err != nil {
+			return nil, err
+		}
+		var err error
+		if hash, err = hashPassword(password); err != nil {
+			return nil, err
+		}
+	}
+
+	usersWithSameInfo, err := u.repo.CheckUserForUniq(ctx, user)
+	if err != nil {
+		return nil, err
+	}
 	if len(usersWithSameInfo) > 0 {
-		return usersWithSameInfo, models.Conflict
+		return usersWithSameInfo, errs.New(errs.CodeConflict, "user with nickname %s or email %s already exists", user.NickName, user.Email)
 	}
-	err := u.repo.CreateUser(ctx, user)
+	err = u.repo.CreateUser(ctx, user, hash)
 	if err != nil {
 		return nil, err
 	}
@@ -33,13 +67,26 @@ func (u *UseCase) GetUser(ctx context.Context, nickname string) (models.User, er
 	return u.repo.GetUser(ctx, nickname)
 }
 
-func (u *UseCase) ChangeUserInfo(ctx context.Context, user models.User) (models.User, int) {
+func (u *UseCase) ChangeUserInfo(ctx context.Context, user models.User) (models.User, error) {
+	if err := u.actAs(ctx, user.NickName, "nickname"); err != nil {
+		return models.User{}, err
+	}
+	password := user.Password
+	user.Password = ""
+	if password != "" {
+		// Пароль меняет только сам пользователь, даже когда вход не обязателен: иначе анонимный
+		// запрос назначил бы пароль чужой учётной записи и вошёл бы под ней.
+		if caller := auth.Caller(ctx); caller == "" || !strings.EqualFold(caller, user.NickName) {
+			return models.User{}, errs.New(errs.CodeForbidden, "only %s can change their password", user.NickName).WithField("password")
+		}
+		if err := checkPassword(password); err != nil {
+			return models.User{}, err
+		}
+	}
+
 	thisUser, err := u.repo.GetUser(ctx, user.NickName)
 	if err != nil {
-		return models.User{}, http.StatusInternalServerError
-	}
-	if thisUser == (models.User{}) {
-		return models.User{}, http.StatusNotFound
+		return models.User{}, err
 	}
 
 	if user.Email == "" {
@@ -52,25 +99,36 @@ func (u *UseCase) ChangeUserInfo(ctx context.Context, user models.User) (models.
 		user.FullName = thisUser.FullName
 	}
 
-	usersWithSameInfo, _ := u.repo.CheckUserForUniq(ctx, user)
+	usersWithSameInfo, err := u.repo.CheckUserForUniq(ctx, user)
+	if err != nil {
+		return models.User{}, err
+	}
 	if len(usersWithSameInfo) > 1 {
-		return models.User{}, http.StatusConflict
+		return models.User{}, errs.New(errs.CodeConflict, "email %s is already taken", user.Email).WithField("email")
+	}
+	if password != "" {
+		if err := u.setPassword(ctx, thisUser.NickName, password); err != nil {
+			return models.User{}, err
+		}
 	}
 	return u.repo.ChangeUserInfo(ctx, user)
 }
 
-func (u *UseCase) CreateForum(ctx context.Context, forum models.Forum) ([]models.Forum, int) {
-	forumsWithSameSlug, _ := u.repo.CheckForumForUniq(ctx, forum)
+func (u *UseCase) CreateForum(ctx context.Context, forum models.Forum) (models.Forum, error) {
+	if err := u.actAs(ctx, forum.User, "user"); err != nil {
+		return models.Forum{}, err
+	}
+	forumsWithSameSlug, err := u.repo.CheckForumForUniq(ctx, forum)
+	if err != nil {
+		return models.Forum{}, err
+	}
 	if len(forumsWithSameSlug) > 0 {
-		return forumsWithSameSlug, http.StatusConflict
+		return forumsWithSameSlug[0], errs.New(errs.CodeConflict, "forum %s already exists", forum.Slug)
 	}
 
 	author, err := u.repo.GetUser(ctx, forum.User)
 	if err != nil {
-		return []models.Forum{}, http.StatusInternalServerError
-	}
-	if author == (models.User{}) {
-		return []models.Forum{}, http.StatusNotFound
+		return models.Forum{}, err
 	}
 	forum.User = author.NickName
 	return u.repo.CreateForum(ctx, forum)
@@ -80,76 +138,108 @@ func (u *UseCase) GetForumDetails(ctx context.Context, slug string) (models.Foru
 	return u.repo.GetForumDetails(ctx, slug)
 }
 
-func (u *UseCase) CreateThread(ctx context.Context, thread models.Thread) ([]models.Thread, int) {
+func (u *UseCase) CreateThread(ctx context.Context, thread models.Thread) (models.Thread, error) {
+	if err := u.actAs(ctx, thread.Author, "author"); err != nil {
+		return models.Thread{}, err
+	}
 	if thread.Slug != "" {
-		threadsWithSameSlug, _ := u.repo.CheckThreadForUniq(ctx, thread)
+		threadsWithSameSlug, err := u.repo.CheckThreadForUniq(ctx, thread)
+		if err != nil {
+			return models.Thread{}, err
+		}
 		if len(threadsWithSameSlug) > 0 {
-			return threadsWithSameSlug, http.StatusConflict
+			return threadsWithSameSlug[0], errs.New(errs.CodeConflict, "thread %s already exists", thread.Slug)
 		}
 	}
 
 	author, err := u.repo.GetUser(ctx, thread.Author)
 	if err != nil {
-		return []models.Thread{}, http.StatusInternalServerError
-	}
-	if author == (models.User{}) {
-		return []models.Thread{}, http.StatusNotFound
+		return models.Thread{}, err
 	}
 	thread.Author = author.NickName
 
 	forum, err := u.repo.GetForumDetails(ctx, thread.Forum)
 	if err != nil {
-		return []models.Thread{}, http.StatusInternalServerError
-	}
-	if forum == (models.Forum{}) {
-		return []models.Thread{}, http.StatusNotFound
+		return models.Thread{}, err
 	}
 	thread.Forum = forum.Slug
+	if err := u.notBanned(ctx, forum.Slug); err != nil {
+		return models.Thread{}, err
+	}
 
 	return u.repo.CreateThread(ctx, thread)
 }
 
-func (u *UseCase) GetThreads(ctx context.Context, slug string, params models.RequestParameters) ([]models.Thread, error) {
-	thisForum, err := u.repo.GetForumDetails(ctx, slug)
+func (u *UseCase) GetThreads(ctx context.Context, slug string, params models.RequestParameters) ([]models.Thread, models.Page, error) {
+	forum, err := u.repo.GetForumDetails(ctx, slug)
 	if err != nil {
-		return []models.Thread{}, models.InternalError
+		return nil, models.Page{}, err
 	}
-	if thisForum == (models.Forum{}) {
-		return []models.Thread{}, models.NotFound
-	}
-	return u.repo.GetThreads(ctx, slug, params)
+	return threadPager("threads:"+strings.ToLower(forum.Slug), func(params models.RequestParameters) ([]models.Thread, error) {
+		return u.repo.GetThreads(ctx, slug, params)
+	}).list(params)
 }
 
 func (u *UseCase) GetThreadBySlugOrId(ctx context.Context, slugOrId string) (models.Thread, error) {
-	var thisThread models.Thread
-	var errSlug error
-	var errId error
-
 	slugOrIdNum, err := strconv.Atoi(slugOrId)
 	if err != nil {
-		thisThread, errSlug = u.repo.GetThreadBySlug(ctx, slugOrId)
-	} else {
-		thisThread, errId = u.repo.GetThreadById(ctx, slugOrIdNum)
+		return u.repo.GetThreadBySlug(ctx, slugOrId)
 	}
-	if errSlug != nil || errId != nil {
-		fmt.Println("useCase get thread error ", errSlug, errId, thisThread)
-		return models.Thread{}, models.InternalError
+	return u.repo.GetThreadById(ctx, slugOrIdNum)
+}
+
+func (u *UseCase) CreatePosts(ctx context.Context, posts []models.Post, thread models.Thread) ([]models.Post, error) {
+	for i, post := range posts {
+		if err := u.actAs(ctx, post.Author, fmt.Sprintf("posts[%d].author", i)); err != nil {
+			return nil, err
+		}
+	}
+	if err := u.notBanned(ctx, thread.Forum); err != nil {
+		return nil, err
+	}
+	created, err := u.repo.CreatePosts(ctx, posts, thread)
+	if err != nil {
+		return nil, err
 	}
-	if thisThread == (models.Thread{}) {
-		return models.Thread{}, models.NotFound
+	for _, post := range created {
+		u.bus.Publish(events.PostCreated, post.Forum, post.Thread, post)
 	}
-	return thisThread, nil
+	return created, nil
 }
 
-func (u *UseCase) CreatePosts(ctx context.Context, posts []models.Post, thread models.Thread) ([]models.Post, int) {
-	return u.repo.CreatePosts(ctx, posts, thread)
+func (u *UseCase) ImportPosts(ctx context.Context, posts []models.ImportPost, thread models.Thread) (models.ImportResult, error) {
+	// Импорт переносит посты многих авторов, поэтому доступен только модераторам форума.
+	if err := u.requireRole(ctx, thread.Forum, models.RoleModerator); err != nil {
+		return models.ImportResult{}, err
+	}
+	if len(posts) == 0 {
+		return models.ImportResult{}, nil
+	}
+	return u.repo.ImportPosts(ctx, posts, thread)
 }
 
 func (u *UseCase) ChangeVote(ctx context.Context, vote models.Vote, thread models.Thread) (models.Thread, error) {
-	return u.repo.ChangeVote(ctx, vote, thread)
+	if err := u.actAs(ctx, vote.Nickname, "nickname"); err != nil {
+		return models.Thread{}, err
+	}
+	if _, err := u.repo.GetUser(ctx, vote.Nickname); err != nil {
+		return models.Thread{}, err
+	}
+	if err := u.notBanned(ctx, thread.Forum); err != nil {
+		return models.Thread{}, err
+	}
+	voted, err := u.repo.ChangeVote(ctx, vote, thread)
+	if err != nil {
+		return models.Thread{}, err
+	}
+	u.bus.Publish(events.ThreadVoted, voted.Forum, voted.ID, voted)
+	return voted, nil
 }
 
-func (u *UseCase) ChangeThreadInfo(ctx context.Context, newThread models.Thread, oldThread models.Thread) (models.Thread, int) {
+func (u *UseCase) ChangeThreadInfo(ctx context.Context, newThread models.Thread, oldThread models.Thread) (models.Thread, error) {
+	if err := u.moderate(ctx, oldThread.Forum, oldThread.Author); err != nil {
+		return models.Thread{}, err
+	}
 	changeFlag := false
 	if newThread.Title != "" {
 		changeFlag = true
@@ -160,55 +250,211 @@ func (u *UseCase) ChangeThreadInfo(ctx context.Context, newThread models.Thread,
 		oldThread.Message = newThread.Message
 	}
 	if !changeFlag {
-		return oldThread, http.StatusOK
+		return oldThread, nil
+	}
+	changed, err := u.repo.ChangeThreadInfo(ctx, oldThread)
+	if err != nil {
+		return models.Thread{}, err
 	}
-	return u.repo.ChangeThreadInfo(ctx, oldThread)
+	u.bus.Publish(events.ThreadUpdated, changed.Forum, changed.ID, changed)
+	return changed, nil
 }
 
-func (u *UseCase) GetUsers(ctx context.Context, slug string, params models.RequestParameters) ([]models.User, error) {
-	thisForum, err := u.repo.GetForumDetails(ctx, slug)
+func (u *UseCase) GetUsers(ctx context.Context, slug string, params models.RequestParameters) ([]models.User, models.Page, error) {
+	forum, err := u.repo.GetForumDetails(ctx, slug)
 	if err != nil {
-		return []models.User{}, models.InternalError
-	}
-	if thisForum == (models.Forum{}) {
-		return []models.User{}, models.NotFound
+		return nil, models.Page{}, err
 	}
-	return u.repo.GetUsers(ctx, slug, params)
+	return userPager("users:"+strings.ToLower(forum.Slug), func(params models.RequestParameters) ([]models.User, error) {
+		return u.repo.GetUsers(ctx, slug, params)
+	}).list(params)
 }
 
 func (u *UseCase) GetPostDetails(ctx context.Context, id int, related []string) (models.PostDetailed, error) {
 	return u.repo.GetPostDetails(ctx, id, related)
 }
 
-func (u *UseCase) ChangePostInfo(ctx context.Context, newPost models.Post, oldPost models.Post) (models.Post, int) {
+func (u *UseCase) ChangePostInfo(ctx context.Context, newPost models.Post, oldPost models.Post) (models.Post, error) {
+	if oldPost.IsDeleted {
+		return models.Post{}, errs.New(errs.CodeConflict, "post %d is deleted", oldPost.ID)
+	}
+	if err := u.moderate(ctx, oldPost.Forum, oldPost.Author); err != nil {
+		return models.Post{}, err
+	}
 	if newPost.Message == "" {
-		return oldPost, http.StatusOK
+		return oldPost, nil
 	}
 	if newPost.Message == oldPost.Message {
-		return oldPost, http.StatusOK
+		return oldPost, nil
 	}
 	oldPost.Message = newPost.Message
 	oldPost.IsEdited = true
-	return u.repo.ChangePostInfo(ctx, oldPost)
+	// Анонимную правку, если она разрешена, приписываем автору поста.
+	editor := auth.Caller(ctx)
+	if editor == "" {
+		editor = oldPost.Author
+	}
+	changed, err := u.repo.ChangePostInfo(ctx, oldPost, editor)
+	if err != nil {
+		return models.Post{}, err
+	}
+	u.bus.Publish(events.PostUpdated, changed.Forum, changed.Thread, changed)
+	return changed, nil
+}
+
+func (u *UseCase) GetPostHistory(ctx context.Context, id int) ([]models.PostRevision, error) {
+	post, err := u.repo.GetPostDetails(ctx, id, nil)
+	if err != nil {
+		return nil, err
+	}
+	if post.Post.IsDeleted {
+		return nil, errs.New(errs.CodeConflict, "post %d is deleted", id)
+	}
+	return u.repo.GetPostHistory(ctx, id)
+}
+
+func (u *UseCase) GetPostDiff(ctx context.Context, id int, from int, to int) (models.PostDiff, error) {
+	revisions, err := u.GetPostHistory(ctx, id)
+	if err != nil {
+		return models.PostDiff{}, err
+	}
+	if to == 0 {
+		to = len(revisions)
+	}
+	if from == 0 {
+		from = to - 1
+	}
+	if to < 1 || to > len(revisions) {
+		return models.PostDiff{}, errs.New(errs.CodeBadRequest, "post %d has no revision %d", id, to).WithField("to")
+	}
+	if from < 1 || from > len(revisions) {
+		return models.PostDiff{}, errs.New(errs.CodeBadRequest, "post %d has no revision %d", id, from).WithField("from")
+	}
+	// Ревизии идут подряд с 1, так что номер ревизии — это позиция в истории плюс один.
+	return models.PostDiff{
+		From: from,
+		To:   to,
+		Diff: diff.Unified(revisions[from-1].Message, revisions[to-1].Message,
+			fmt.Sprintf("revision %d", from), fmt.Sprintf("revision %d", to)),
+	}, nil
 }
 
-func (u *UseCase) GetStatus(ctx context.Context) (models.Info, int) {
-	return u.repo.GetStatus(ctx)
+func (u *UseCase) DeletePost(ctx context.Context, id int) (models.Post, error) {
+	if err := u.moderatePost(ctx, id); err != nil {
+		return models.Post{}, err
+	}
+	return u.repo.DeletePost(ctx, id)
 }
 
-func (u *UseCase) Clear(ctx context.Context) int {
-	return u.repo.Clear(ctx)
+// RestorePost доступен только модераторам форума и выше: автор не может вернуть пост,
+// который удалил модератор.
+func (u *UseCase) RestorePost(ctx context.Context, id int) (models.Post, error) {
+	post, err := u.repo.GetPostDetails(ctx, id, nil)
+	if err != nil {
+		return models.Post{}, err
+	}
+	if err := u.requireRole(ctx, post.Post.Forum, models.RoleModerator); err != nil {
+		return models.Post{}, err
+	}
+	return u.repo.RestorePost(ctx, id)
 }
 
-func (u *UseCase) GetPosts(ctx context.Context, threadID int, params models.RequestParameters) ([]models.Post, error) {
+// moderatePost берёт автора из первой ревизии: у удалённого поста автор в ответах скрыт.
+func (u *UseCase) moderatePost(ctx context.Context, id int) error {
+	if auth.Caller(ctx) == "" {
+		return u.authenticated(ctx)
+	}
+	post, err := u.repo.GetPostDetails(ctx, id, nil)
+	if err != nil {
+		return err
+	}
+	revisions, err := u.repo.GetPostHistory(ctx, id)
+	if err != nil {
+		return err
+	}
+	return u.moderate(ctx, post.Post.Forum, revisions[0].Editor)
+}
+
+func (u *UseCase) GetStatus(ctx context.Context, exact bool) (models.Info, error) {
+	return u.repo.GetStatus(ctx, exact)
+}
+
+// Clear доступен только администраторам. Если вход не обязателен и администраторы не заданы,
+// как при прогоне тестов курса, назначать их некому, и Clear доступен любому вызывающему.
+func (u *UseCase) Clear(ctx context.Context, forum string, dryRun bool) (models.ClearReport, error) {
+	open := !u.auth.Required && len(u.auth.Admins) == 0
+	if !open {
+		if err := u.requireRole(ctx, "", models.RoleAdmin); err != nil {
+			return models.ClearReport{}, err
+		}
+	}
+	if dryRun {
+		report, err := u.repo.CountClear(ctx, forum)
+		report.DryRun = true
+		return report, err
+	}
+	var report models.ClearReport
+	var err error
+	if forum != "" {
+		report, err = u.repo.ClearForum(ctx, forum)
+	} else {
+		report, err = u.repo.Clear(ctx)
+	}
+	if err != nil {
+		return models.ClearReport{}, err
+	}
+	u.log.WarnContext(ctx, "data cleared", "forum", report.Forum, "users", report.Users, "forums", report.Forums,
+		"threads", report.Threads, "posts", report.Posts)
+	return report, nil
+}
+
+// CheckConsistency доступна только администраторам и без repair: проверка пересчитывает
+// все счётчики в одной транзакции и нагружает базу не меньше исправления.
+func (u *UseCase) CheckConsistency(ctx context.Context, repair bool) (models.ConsistencyReport, error) {
+	if err := u.requireRole(ctx, "", models.RoleAdmin); err != nil {
+		return models.ConsistencyReport{}, err
+	}
+	return u.repo.CheckConsistency(ctx, repair)
+}
+
+func (u *UseCase) GetPosts(ctx context.Context, threadID int, params models.RequestParameters) ([]models.Post, models.Page, error) {
+	if params.Cursor != nil {
+		params.Sort = params.Cursor.Sort
+	}
+	var fetch func(ctx context.Context, params models.RequestParameters, threadID int) ([]models.Post, error)
+	var split func(posts []models.Post) [][]models.Post
 	switch params.Sort {
 	case "flat":
-		return u.repo.GetPostsFlat(ctx, params, threadID)
+		fetch = u.repo.GetPostsFlat
 	case "tree":
-		return u.repo.GetPostsTree(ctx, params, threadID)
+		fetch = u.repo.GetPostsTree
 	case "parent_tree":
-		return u.repo.GetPostsParent(ctx, params, threadID)
+		fetch, split = u.repo.GetPostsParent, splitByRoots
+	default:
+		return nil, models.Page{}, errs.New(errs.CodeBadRequest, "unknown sort %q", params.Sort).WithField("sort")
+	}
+	p := postPager("posts:"+strconv.Itoa(threadID), func(params models.RequestParameters) ([]models.Post, error) {
+		return fetch(ctx, params, threadID)
+	})
+	p.split = split
+	return p.list(params)
+}
+
+func (u *UseCase) Search(ctx context.Context, query models.SearchQuery, params models.RequestParameters) ([]models.SearchHit, models.Page, error) {
+	if strings.TrimSpace(query.Query) == "" {
+		return nil, models.Page{}, errs.New(errs.CodeBadRequest, "search query is required").WithField("q")
+	}
+	switch query.Kind {
+	case "", models.SearchKindPost, models.SearchKindThread:
 	default:
-		return []models.Post{}, models.InternalError
+		return nil, models.Page{}, errs.New(errs.CodeBadRequest, "unknown type %q", query.Kind).WithField("type")
+	}
+	if query.Forum != "" {
+		if _, err := u.repo.GetForumDetails(ctx, query.Forum); err != nil {
+			return nil, models.Page{}, err
+		}
 	}
+	return searchPager(query, func(query models.SearchQuery) ([]models.SearchHit, error) {
+		return u.repo.Search(ctx, query)
+	}).list(params)
 }
@@ -0,0 +1,76 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Limiter — token bucket на каждый ключ: корзина вмещает burst запросов и пополняется
+// со скоростью rate в секунду. Корзины, которые успели наполниться, удаляются,
+// так что память растёт только с числом активных клиентов.
+type Limiter struct {
+	rate  float64
+	burst float64
+	now   func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+func New(rate float64, burst int) *Limiter {
+	return &Limiter{rate: rate, burst: float64(burst), now: time.Now, buckets: make(map[string]*bucket)}
+}
+
+// Allow списывает запрос с корзины key. Если корзина пуста, возвращает false и время,
+// через которое появится следующий токен.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, updated: now}
+		l.buckets[key] = b
+	}
+	b.tokens = l.refill(b, now)
+	b.updated = now
+	if b.tokens < 1 {
+		return false, time.Duration(math.Ceil((1 - b.tokens) / l.rate * float64(time.Second)))
+	}
+	b.tokens--
+	return true, 0
+}
+
+func (l *Limiter) refill(b *bucket, now time.Time) float64 {
+	return math.Min(l.burst, b.tokens+now.Sub(b.updated).Seconds()*l.rate)
+}
+
+// sweep раз в время полного наполнения корзины выбрасывает полные корзины:
+// новая корзина для того же ключа ничем от них не отличается.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep).Seconds() < l.burst/l.rate {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if l.refill(b, now) >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// Len возвращает число корзин, которые сейчас хранятся.
+func (l *Limiter) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.buckets)
+}
@@ -0,0 +1,65 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time { return c.t }
+
+func newLimiter(rate float64, burst int) (*Limiter, *clock) {
+	c := &clock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	l := New(rate, burst)
+	l.now = c.now
+	return l, c
+}
+
+func TestBurstAndRefill(t *testing.T) {
+	l, c := newLimiter(2, 3)
+	for i := 0; i < 3; i++ {
+		if ok, _ := l.Allow("a"); !ok {
+			t.Fatalf("request %d within burst rejected", i)
+		}
+	}
+	ok, retry := l.Allow("a")
+	if ok || retry != 500*time.Millisecond {
+		t.Fatalf("over burst: got %v, retry %v", ok, retry)
+	}
+	if ok, _ := l.Allow("b"); !ok {
+		t.Error("other key shares the bucket")
+	}
+
+	c.t = c.t.Add(250 * time.Millisecond)
+	if ok, retry := l.Allow("a"); ok || retry != 250*time.Millisecond {
+		t.Errorf("half a token: got %v, retry %v", ok, retry)
+	}
+	c.t = c.t.Add(250 * time.Millisecond)
+	if ok, _ := l.Allow("a"); !ok {
+		t.Error("refilled token rejected")
+	}
+	c.t = c.t.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		if ok, _ := l.Allow("a"); !ok {
+			t.Fatalf("refill is not capped at burst: request %d rejected", i)
+		}
+	}
+	if ok, _ := l.Allow("a"); ok {
+		t.Error("refill exceeded burst")
+	}
+}
+
+func TestSweep(t *testing.T) {
+	l, c := newLimiter(1, 2)
+	l.Allow("a")
+	c.t = c.t.Add(1500 * time.Millisecond)
+	l.Allow("b")
+	l.Allow("b")
+	c.t = c.t.Add(time.Second)
+	l.Allow("c")
+	// a уже полна, b ещё нет, c только что создана.
+	if n := l.Len(); n != 2 {
+		t.Errorf("after sweep: %d buckets, want 2", n)
+	}
+}
@@ -0,0 +1,140 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/BigBullas/TP_DB_project/internal/config"
+	"github.com/BigBullas/TP_DB_project/internal/logging"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// Сколько ждать одну проверку готовности: проба оркестратора не должна висеть на медленной базе.
+const checkTimeout = 2 * time.Second
+
+type Server struct {
+	cfg        config.HTTP
+	log        *slog.Logger
+	draining   atomic.Bool
+	onShutdown []func()
+	checks     []check
+}
+
+type check struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+func New(cfg config.HTTP, log *slog.Logger) *Server {
+	if log == nil {
+		log = logging.Discard()
+	}
+	return &Server{cfg: cfg, log: log}
+}
+
+func (s *Server) Draining() bool {
+	return s.draining.Load()
+}
+
+// OnShutdown регистрирует f, который вызывается в начале остановки, — например,
+// чтобы закрыть долгие потоки, которые иначе держали бы Shutdown до таймаута.
+func (s *Server) OnShutdown(f func()) {
+	s.onShutdown = append(s.onShutdown, f)
+}
+
+// AddCheck добавляет зависимость, без которой сервер не готов принимать запросы.
+// fn вызывается на каждый /readyz, поэтому должна быть дешёвой.
+func (s *Server) AddCheck(name string, fn func(ctx context.Context) error) {
+	s.checks = append(s.checks, check{name: name, fn: fn})
+}
+
+// Live отвечает 200, пока процесс обслуживает запросы. Зависимости не проверяются:
+// перезапуск не поможет, если недоступна база.
+func (s *Server) Live(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+}
+
+// Ready отвечает 503, как только сервер начал останавливаться или не прошла
+// одна из проверок, чтобы балансировщик перестал присылать новые запросы.
+// В теле — результат каждой проверки.
+func (s *Server) Ready(w http.ResponseWriter, r *http.Request) {
+	status := http.StatusOK
+	results := make(map[string]string, len(s.checks)+1)
+	if s.Draining() {
+		status = http.StatusServiceUnavailable
+		results["draining"] = "server is shutting down"
+	}
+	for _, c := range s.checks {
+		ctx, cancel := context.WithTimeout(r.Context(), checkTimeout)
+		err := c.fn(ctx)
+		cancel()
+		if err != nil {
+			status = http.StatusServiceUnavailable
+			results[c.name] = err.Error()
+			s.log.WarnContext(r.Context(), "readiness check failed", "check", c.name, "err", err)
+			continue
+		}
+		results[c.name] = "ok"
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(results)
+}
+
+// Run обслуживает запросы до отмены ctx, затем DrainDelay сообщает в /readyz об остановке,
+// перестаёт принимать соединения и ждёт завершения активных обработчиков не дольше ShutdownTimeout.
+// Если обработчики не успели, их контексты отменяются, а соединения закрываются.
+func (s *Server) Run(ctx context.Context, handler http.Handler) error {
+	baseCtx, cancelHandlers := context.WithCancel(context.Background())
+	defer cancelHandlers()
+
+	srv := &http.Server{
+		Addr:         s.cfg.Listen,
+		Handler:      handler,
+		ReadTimeout:  s.cfg.ReadTimeout,
+		WriteTimeout: s.cfg.WriteTimeout,
+		IdleTimeout:  s.cfg.IdleTimeout,
+		BaseContext:  func(net.Listener) context.Context { return baseCtx },
+		ErrorLog:     slog.NewLogLogger(s.log.Handler(), slog.LevelWarn),
+	}
+	for _, f := range s.onShutdown {
+		srv.RegisterOnShutdown(f)
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	// Порт ещё открыт: /readyz отвечает 503, и балансировщик успевает убрать сервер
+	// из ротации до того, как новые соединения начнут получать отказ.
+	s.draining.Store(true)
+	if s.cfg.DrainDelay > 0 {
+		s.log.Info("draining, readiness reports 503", "delay", s.cfg.DrainDelay)
+		time.Sleep(s.cfg.DrainDelay)
+	}
+	s.log.Info("shutting down, waiting for active requests")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.cfg.ShutdownTimeout)
+	defer cancel()
+	err := srv.Shutdown(shutdownCtx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		s.log.Warn("shutdown timeout exceeded, cancelling active requests")
+		cancelHandlers()
+		err = srv.Close()
+	}
+	if srvErr := <-errCh; !errors.Is(srvErr, http.ErrServerClosed) {
+		return srvErr
+	}
+	return err
+}
@@ -0,0 +1,110 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/BigBullas/TP_DB_project/internal/config"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func probe(t *testing.T, h http.HandlerFunc) (int, map[string]string) {
+	rec := httptest.NewRecorder()
+	h(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	var body map[string]string
+	if rec.Body.Len() > 0 {
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("bad body %q: %v", rec.Body.String(), err)
+		}
+	}
+	return rec.Code, body
+}
+
+func TestReady(t *testing.T) {
+	s := New(config.HTTP{}, nil)
+	var dbErr error
+	s.AddCheck("postgres", func(ctx context.Context) error { return dbErr })
+
+	if code, body := probe(t, s.Ready); code != http.StatusOK || body["postgres"] != "ok" {
+		t.Errorf("healthy: %d %v", code, body)
+	}
+
+	dbErr = errors.New("connection refused")
+	if code, body := probe(t, s.Ready); code != http.StatusServiceUnavailable || body["postgres"] != "connection refused" {
+		t.Errorf("db down: %d %v", code, body)
+	}
+	if code, _ := probe(t, s.Live); code != http.StatusOK {
+		t.Errorf("liveness must not depend on the database, got %d", code)
+	}
+
+	dbErr = nil
+	s.draining.Store(true)
+	if code, body := probe(t, s.Ready); code != http.StatusServiceUnavailable || body["draining"] == "" {
+		t.Errorf("draining: %d %v", code, body)
+	}
+}
+
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().String()
+}
+
+func TestRunDrainDelay(t *testing.T) {
+	addr := freeAddr(t)
+	s := New(config.HTTP{Listen: addr, DrainDelay: 300 * time.Millisecond, ShutdownTimeout: time.Second}, nil)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/readyz", s.Ready)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.Run(ctx, mux) }()
+
+	url := "http://" + addr + "/readyz"
+	var resp *http.Response
+	var err error
+	for i := 0; i < 50; i++ {
+		if resp, err = http.Get(url); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("server did not start: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("before shutdown: %d", resp.StatusCode)
+	}
+
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+	resp, err = http.Get(url)
+	if err != nil {
+		t.Fatalf("listener closed before the drain delay: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("during drain: %d, want 503", resp.StatusCode)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Run: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run did not return after the drain delay")
+	}
+	if _, err := http.Get(url); err == nil {
+		t.Error("listener is still open after Run returned")
+	}
+}
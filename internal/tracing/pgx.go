@@ -0,0 +1,108 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+	"strings"
+	"time"
+)
+
+// QueryLogger превращает записи логгера pgx о выполненных запросах в спаны.
+// В pgx v4 нет хуков трассировки, зато логгер получает SQL, длительность и число строк
+// уже после запроса, поэтому span открывается задним числом.
+// Ставится в ConnConfig.Logger с LogLevel не ниже pgx.LogLevelInfo.
+type QueryLogger struct{}
+
+func (QueryLogger) Log(ctx context.Context, level pgx.LogLevel, msg string, data map[string]interface{}) {
+	sql, _ := data["sql"].(string)
+	if sql == "" && msg != "CopyFrom" && msg != "SendBatch" {
+		return
+	}
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		// Запрос вне трассируемого запроса, например пинг пула.
+		return
+	}
+
+	end := time.Now()
+	start := end
+	if d, ok := data["time"].(time.Duration); ok {
+		start = end.Add(-d)
+	}
+
+	attrs := []attribute.KeyValue{semconv.DBSystemPostgreSQL}
+	name := msg
+	switch msg {
+	case "CopyFrom":
+		table := tableName(data["tableName"])
+		name = "COPY " + table
+		attrs = append(attrs, semconv.DBOperation("COPY"), semconv.DBSQLTable(table))
+	case "SendBatch":
+		name = "BATCH"
+		if n, ok := data["batchLen"].(int); ok {
+			attrs = append(attrs, attribute.Int("db.batch_len", n))
+		}
+	default:
+		op, table := statementName(sql)
+		name = strings.TrimSpace(op + " " + table)
+		attrs = append(attrs, semconv.DBStatement(sql), semconv.DBOperation(op))
+		if table != "" {
+			attrs = append(attrs, semconv.DBSQLTable(table))
+		}
+	}
+	if n, ok := data["rowCount"].(int); ok {
+		attrs = append(attrs, attribute.Int("db.rows", n))
+	} else if n, ok := data["rowCount"].(int64); ok {
+		attrs = append(attrs, attribute.Int64("db.rows", n))
+	} else if tag, ok := data["commandTag"].(pgconn.CommandTag); ok {
+		attrs = append(attrs, attribute.Int64("db.rows", tag.RowsAffected()))
+	}
+
+	_, span := otel.Tracer(instrumentation).Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient), trace.WithTimestamp(start), trace.WithAttributes(attrs...))
+	if err, ok := data["err"].(error); ok && !errors.Is(err, context.Canceled) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End(trace.WithTimestamp(end))
+}
+
+// statementName возвращает операцию и первую таблицу запроса: "SELECT", "post".
+// Этого хватает, чтобы различать запросы в трассе; полный текст лежит в db.statement.
+func statementName(sql string) (op string, table string) {
+	words := strings.Fields(sql)
+	if len(words) == 0 {
+		return "", ""
+	}
+	op = strings.ToUpper(words[0])
+	for i := 0; i < len(words)-1; i++ {
+		switch strings.ToUpper(words[i]) {
+		case "FROM", "INTO", "UPDATE", "TABLE":
+			if next := strings.ToUpper(words[i+1]); next == "ONLY" || next == "(" || strings.HasPrefix(next, "(") {
+				continue
+			}
+			return op, tableName(words[i+1])
+		}
+	}
+	return op, ""
+}
+
+func tableName(v interface{}) string {
+	var name string
+	switch t := v.(type) {
+	case string:
+		name = t
+	case pgx.Identifier:
+		name = t.Sanitize()
+	}
+	if i := strings.IndexAny(name, "(;,"); i >= 0 {
+		name = name[:i]
+	}
+	return strings.ToLower(strings.Trim(name, `"`))
+}
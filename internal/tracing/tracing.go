@@ -0,0 +1,129 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/BigBullas/TP_DB_project/internal/config"
+	"github.com/BigBullas/TP_DB_project/internal/errs"
+	"github.com/BigBullas/TP_DB_project/internal/logging"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"os"
+	"sync/atomic"
+)
+
+const (
+	serviceName     = "forum"
+	instrumentation = "github.com/BigBullas/TP_DB_project"
+)
+
+var enabled atomic.Bool
+
+// Enabled сообщает, настроен ли экспорт. Без него спаны ничего не стоят,
+// но хуки вроде логгера pgx лучше не ставить вовсе.
+func Enabled() bool {
+	return enabled.Load()
+}
+
+// Setup ставит глобальный TracerProvider с экспортёром из cfg и возвращает функцию,
+// которая при остановке досылает накопленные спаны.
+func Setup(ctx context.Context, cfg config.Tracing) (shutdown func(context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	var file *os.File
+	switch cfg.Exporter {
+	case "none":
+		return func(context.Context) error { return nil }, nil
+	case "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case "file":
+		file, err = os.OpenFile(cfg.File, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("open trace file: %w", err)
+		}
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(file))
+	case "otlp":
+		var opts []otlptracehttp.Option
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+		}
+		exporter, err = otlptracehttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("create %s trace exporter: %w", cfg.Exporter, err)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(serviceName))),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(tp)
+	enabled.Store(true)
+	return func(ctx context.Context) error {
+		err := tp.Shutdown(ctx)
+		if file != nil {
+			err = errors.Join(err, file.Close())
+		}
+		return err
+	}, nil
+}
+
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentation).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End закрывает span. Ошибки клиента (не найдено, конфликт) помечаются кодом,
+// статус Error получают только внутренние.
+func End(span trace.Span, err *error) {
+	RecordError(span, *err)
+	span.End()
+}
+
+func RecordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	code := errs.CodeOf(err)
+	span.SetAttributes(attribute.String("forum.error_code", string(code)))
+	if code == errs.CodeInternal {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+// Middleware открывает серверный span на запрос, продолжая трассу из traceparent, если он есть.
+// Span называется по шаблону маршрута, чтобы запросы к разным объектам сводились в одну операцию.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := "unknown"
+		if current := mux.CurrentRoute(r); current != nil {
+			if tmpl, err := current.GetPathTemplate(); err == nil {
+				route = tmpl
+			}
+		}
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := otel.Tracer(instrumentation).Start(ctx, r.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(semconv.HTTPRequestMethodKey.String(r.Method), semconv.HTTPRoute(route)))
+		defer span.End()
+		if id := logging.RequestID(ctx); id != "" {
+			span.SetAttributes(attribute.String("request_id", id))
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,140 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"github.com/BigBullas/TP_DB_project/internal/errs"
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func record(t *testing.T) *tracetest.SpanRecorder {
+	rec := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+	return rec
+}
+
+func attr(span sdktrace.ReadOnlySpan, key attribute.Key) attribute.Value {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestStatementName(t *testing.T) {
+	for sql, want := range map[string][2]string{
+		`SELECT * FROM users WHERE Nickname = $1;`:                    {"SELECT", "users"},
+		`INSERT INTO post_revisions (Post, Revision) VALUES ($1, $2)`: {"INSERT", "post_revisions"},
+		`UPDATE thread SET Title = $1 WHERE Id = $2;`:                 {"UPDATE", "thread"},
+		`TRUNCATE TABLE users, forum CASCADE;`:                        {"TRUNCATE", "users"},
+		`select Title, "user" from "forum" where Slug = $1`:           {"SELECT", "forum"},
+		`SELECT id FROM (SELECT id FROM post WHERE parent = 0) p`:     {"SELECT", "post"},
+		`SELECT set_config('forum.bulk_import', 'on', true);`:         {"SELECT", ""},
+	} {
+		op, table := statementName(sql)
+		if op != want[0] || table != want[1] {
+			t.Errorf("%q: got %q %q, want %q %q", sql, op, table, want[0], want[1])
+		}
+	}
+}
+
+func TestQueryLogger(t *testing.T) {
+	rec := record(t)
+	ctx, parent := Start(context.Background(), "UseCase.GetPosts")
+
+	QueryLogger{}.Log(ctx, pgx.LogLevelInfo, "Query", map[string]interface{}{
+		"sql": "SELECT Id FROM post WHERE Thread = $1", "time": 30 * time.Millisecond, "rowCount": 7,
+	})
+	QueryLogger{}.Log(ctx, pgx.LogLevelInfo, "Exec", map[string]interface{}{
+		"sql": "UPDATE post SET Message = $1 WHERE Id = $2", "time": time.Millisecond, "commandTag": pgconn.CommandTag("UPDATE 1"),
+	})
+	QueryLogger{}.Log(ctx, pgx.LogLevelError, "Query", map[string]interface{}{
+		"sql": "SELECT * FROM nope", "err": errors.New("relation does not exist"),
+	})
+	// Вне трассы спанов нет.
+	QueryLogger{}.Log(context.Background(), pgx.LogLevelInfo, "Query", map[string]interface{}{"sql": "SELECT 1"})
+	parent.End()
+
+	spans := rec.Ended()
+	if len(spans) != 4 {
+		t.Fatalf("got %d spans, want 3 queries and the parent", len(spans))
+	}
+	sel, upd, bad := spans[0], spans[1], spans[2]
+	if sel.Name() != "SELECT post" || attr(sel, "db.rows").AsInt64() != 7 {
+		t.Errorf("select span: %s rows=%v", sel.Name(), attr(sel, "db.rows").Emit())
+	}
+	if d := sel.EndTime().Sub(sel.StartTime()); d != 30*time.Millisecond {
+		t.Errorf("select span lasted %s, want 30ms", d)
+	}
+	if sel.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Error("query span is not a child of the usecase span")
+	}
+	if upd.Name() != "UPDATE post" || attr(upd, "db.rows").AsInt64() != 1 {
+		t.Errorf("update span: %s rows=%v", upd.Name(), attr(upd, "db.rows").Emit())
+	}
+	if bad.Status().Code != codes.Error {
+		t.Errorf("failed query status %v, want Error", bad.Status().Code)
+	}
+}
+
+func TestEnd(t *testing.T) {
+	rec := record(t)
+	for _, err := range []error{nil, errs.New(errs.CodeNotFound, "no user"), errors.New("connection reset")} {
+		_, span := Start(context.Background(), "op")
+		End(span, &err)
+	}
+	spans := rec.Ended()
+	for i, want := range []codes.Code{codes.Unset, codes.Unset, codes.Error} {
+		if got := spans[i].Status().Code; got != want {
+			t.Errorf("span %d: status %v, want %v", i, got, want)
+		}
+	}
+	if code := attr(spans[1], "forum.error_code").AsString(); code != string(errs.CodeNotFound) {
+		t.Errorf("error code %q", code)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	rec := record(t)
+	router := mux.NewRouter()
+	router.Use(Middleware)
+	router.HandleFunc("/thread/{slug_or_id}/posts", func(w http.ResponseWriter, r *http.Request) {
+		_, span := Start(r.Context(), "UseCase.GetPosts")
+		span.End()
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/thread/42/posts", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := rec.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(spans))
+	}
+	uc, server := spans[0], spans[1]
+	if server.Name() != "GET /thread/{slug_or_id}/posts" {
+		t.Errorf("server span name %q", server.Name())
+	}
+	if server.SpanContext().TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Error("incoming traceparent was not continued")
+	}
+	if uc.Parent().SpanID() != server.SpanContext().SpanID() {
+		t.Error("usecase span is not a child of the server span")
+	}
+}
@@ -2,24 +2,17 @@ package utils
 
 import (
 	"encoding/json"
-	"fmt"
+	"errors"
+	"github.com/BigBullas/TP_DB_project/internal/errs"
 	"github.com/BigBullas/TP_DB_project/internal/models"
 	"net/http"
 )
 
-func Response(w http.ResponseWriter, status int, body interface{}, flagMessageConflict bool) {
+func Response(w http.ResponseWriter, status int, body interface{}) {
 	if body != nil {
 		w.Header().Set("Content-Type", "application/json")
 	}
 	w.WriteHeader(status)
-	if status == http.StatusNotFound && body != nil || flagMessageConflict {
-		jsn, err := json.Marshal(models.ErrorResponse{Message: fmt.Sprintf("Can't find the required #%s\\n", body)})
-		if err != nil {
-			return
-		}
-		_, _ = w.Write(jsn)
-		return
-	}
 	if body != nil {
 		jsn, err := json.Marshal(body)
 		if err != nil {
@@ -28,3 +21,17 @@ func Response(w http.ResponseWriter, status int, body interface{}, flagMessageCo
 		_, _ = w.Write(jsn)
 	}
 }
+
+// ErrorBody переводит ошибку в models.ErrorResponse. Причина внутренних ошибок наружу не отдаётся.
+func ErrorBody(err error) models.ErrorResponse {
+	resp := models.ErrorResponse{Code: string(errs.CodeOf(err))}
+	var e *errs.Error
+	if errors.As(err, &e) {
+		resp.Message = e.Message
+		resp.Field = e.Field
+	}
+	if resp.Message == "" {
+		resp.Message = http.StatusText(errs.HTTPStatus(err))
+	}
+	return resp
+}